@@ -1,16 +1,46 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
+	"errors"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/rijey/kindl/backend/internal/account"
 	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/blinddate"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/discovery"
+	"github.com/rijey/kindl/backend/internal/events"
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/likes"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/moderation"
+	"github.com/rijey/kindl/backend/internal/notifications"
 	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/pubsub"
+	"github.com/rijey/kindl/backend/internal/recommend"
+	"github.com/rijey/kindl/backend/internal/rooms"
+	"github.com/rijey/kindl/backend/internal/safety"
+	"github.com/rijey/kindl/backend/internal/scoring"
+	"github.com/rijey/kindl/backend/internal/screening"
+	"github.com/rijey/kindl/backend/internal/snooze"
+	"github.com/rijey/kindl/backend/internal/sparks"
+	"github.com/rijey/kindl/backend/internal/verification"
 )
 
 func main() {
@@ -37,37 +67,213 @@ func main() {
 	}
 	jwtKey := []byte(jwtSecret)
 
-	authHandler, err := auth.NewHandler(logger, jwtKey, os.Getenv("GOOGLE_CLIENT_ID"))
+	// Choose store implementations.
+	// If DATABASE_URL is set and Postgres is reachable, use the Postgres-backed stores.
+	// Otherwise, fall back to in-memory storage.
+	db := openDB(logger)
+	if db != nil {
+		defer db.Close()
+	}
+
+	var (
+		onboardingStore onboarding.Store
+		discoveryStore  discovery.Store
+		likesStore      likes.Store
+		matchStore      matches.Store
+		sparkStore      sparks.Store
+		messageStore    chat.MessageStore
+		bus             pubsub.Bus
+		blindDateStore  blinddate.Store
+		sessionStore    blinddate.SessionStore
+		roomStore       rooms.Store
+		intentStore     intents.Store
+		eventStore      events.Store
+		safetyStore     safety.Store
+		reportStore     safety.ReportStore
+		accountStore    auth.AccountStore
+		moderationStore moderation.Store
+		verifyStore     verification.Store
+		lifecycleStore  account.Store
+		pushStore       notifications.Store
+	)
+	if db != nil {
+		onboardingStore = onboarding.NewPGStore(db)
+		discoveryStore = discovery.NewPGStore(db)
+		likesStore = likes.NewPGStore(db)
+		matchStore = matches.NewPGStore(db)
+		sparkStore = sparks.NewPGStore(db)
+		messageStore = chat.NewPGMessageStore(db)
+		bus = pubsub.NewPostgres(logger, db, os.Getenv("DATABASE_URL"))
+		blindDateStore = blinddate.NewPGStore(db)
+		sessionStore = blinddate.NewPGSessionStore(db)
+		roomStore = rooms.NewPGStore(db)
+		intentStore = intents.NewPGStore(db)
+		eventStore = events.NewPGStore(db)
+		safetyStore = safety.NewPGStore(db)
+		reportStore = safety.NewPGReportStore(db)
+		accountStore = auth.NewPGAccountStore(db)
+		moderationStore = moderation.NewPGStore(db)
+		verifyStore = verification.NewPGStore(db)
+		lifecycleStore = account.NewPGStore(db)
+		pushStore = notifications.NewPGStore(db)
+	} else {
+		onboardingStore = onboarding.NewInMemoryStore()
+		likesStore = likes.NewInMemoryStore()
+		matchStore = matches.NewInMemoryStore()
+		sparkStore = sparks.NewInMemoryStore()
+		messageStore = chat.NewInMemoryMessageStore()
+		bus = pubsub.NewInProcess()
+		safetyStore = safety.NewInMemoryStore()
+		reportStore = safety.NewInMemoryReportStore()
+		accountStore = auth.NewInMemoryAccountStore()
+		moderationStore = moderation.NewInMemoryStore()
+		verifyStore = verification.NewInMemoryStore()
+		lifecycleStore = account.NewInMemoryStore()
+		pushStore = notifications.NewInMemoryStore()
+		blindDateStore = blinddate.NewInMemoryStore(safetyStore)
+		sessionStore = blinddate.NewInMemorySessionStore()
+		roomStore = rooms.NewInMemoryStore()
+		intentStore = intents.NewInMemoryStore()
+		eventStore = events.NewInMemoryStore()
+		discoveryStore = discovery.NewInMemoryStore(onboardingStore, likesStore, sparkStore, safetyStore)
+	}
+
+	// DEV_DEBUG_USER_HEADER=1 lets requests without a token name their
+	// user in X-Debug-UserID, for local development only. Anyone can then
+	// act as anyone, so it refuses to start alongside admins.
+	debugUserHeader := os.Getenv("DEV_DEBUG_USER_HEADER") == "1"
+	if debugUserHeader && strings.TrimSpace(os.Getenv("ADMIN_USER_IDS")) != "" {
+		logger.Fatalf("DEV_DEBUG_USER_HEADER cannot be combined with ADMIN_USER_IDS")
+	}
+	if debugUserHeader {
+		logger.Printf("WARNING: trusting %s; never enable DEV_DEBUG_USER_HEADER in production", auth.DebugUserHeader)
+	}
+
+	// ADMIN_USER_IDS is a comma-separated list of users granted the admin
+	// role at startup.
+	for _, id := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if err := accountStore.SetRole(id, auth.RoleAdmin); err != nil {
+			logger.Fatalf("failed to grant admin role to %s: %v", id, err)
+		}
+	}
+
+	authHandler, err := auth.NewHandler(logger, jwtKey, os.Getenv("GOOGLE_CLIENT_ID"), accountStore)
 	if err != nil {
 		logger.Fatalf("failed to initialise auth handler: %v", err)
 	}
+	// Content screening: the built-in rules, plus an external classifier
+	// when SCREENING_URL is set. Flagged content is filed as a report.
+	classifiers := []screening.ContentClassifier{screening.NewRuleClassifier(screening.DefaultRules())}
+	if u := os.Getenv("SCREENING_URL"); u != "" {
+		classifiers = append(classifiers, screening.NewHTTPClassifier(u, nil))
+	}
+	screener := screening.NewScreener(logger, screening.Chain(classifiers...), safety.NewScreeningReviews(reportStore))
 
-	// Choose onboarding store implementation.
-	// If DATABASE_URL is set and Postgres is reachable, use the Postgres-backed store.
-	// Otherwise, fall back to in-memory storage.
-	var onboardingStore onboarding.Store
-
-	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
-		db, err := sql.Open("pgx", dsn)
-		if err != nil {
-			logger.Printf("failed to connect to Postgres (using in-memory onboarding store): %v", err)
-			onboardingStore = onboarding.NewInMemoryStore()
-		} else {
-			if err := db.Ping(); err != nil {
-				logger.Printf("Postgres ping failed (using in-memory onboarding store): %v", err)
-				onboardingStore = onboarding.NewInMemoryStore()
-				_ = db.Close()
-			} else {
-				logger.Printf("using Postgres-backed onboarding store")
-				onboardingStore = onboarding.NewPGStore(db)
-			}
+	// Pushes go to the gateway at PUSH_GATEWAY_URL; without one they are
+	// only logged.
+	var pushSender notifications.PushSender = notifications.NewFake(logger)
+	if u := os.Getenv("PUSH_GATEWAY_URL"); u != "" {
+		pushSender = notifications.NewHTTPSender(u, nil)
+	}
+	pushService := notifications.NewService(logger, pushStore, onboardingStore, pushSender)
+	notificationsHandler := notifications.NewHandler(logger, pushStore)
+
+	onboardingHandler := onboarding.NewHandler(logger, onboardingStore, intentStore, screener)
+	intentScores := events.NewScores(eventStore)
+	weights, err := scoring.WeightsFromJSON(os.Getenv("SCORING_WEIGHTS"))
+	if err != nil {
+		logger.Fatalf("invalid SCORING_WEIGHTS: %v", err)
+	}
+	// Feed cursors are signed with the JWT secret, so they stop working when
+	// it is rotated and clients start their feed over.
+	discoveryHandler := discovery.NewHandler(logger, onboardingStore, discoveryStore, intentStore, intentScores,
+		scoring.New(weights), jwtKey)
+	intentsHandler := intents.NewHandler(logger, intentStore, intentScores)
+	recommendHandler := recommend.NewHandler(logger,
+		recommend.NewRecommender(intentStore, onboardingStore, intentScores, eventStore))
+	eventsHandler := events.NewHandler(logger, eventStore, intentStore)
+	eventAggregator := events.NewAggregator(logger, eventStore)
+	likesHandler := likes.NewHandler(logger, likesStore, matchStore, onboardingStore, safetyStore, pushService)
+
+	// SPARK_DAILY_QUOTA overrides sparks.DefaultDailyQuota.
+	var sparkQuota int
+	if v := os.Getenv("SPARK_DAILY_QUOTA"); v != "" {
+		if sparkQuota, err = strconv.Atoi(v); err != nil || sparkQuota <= 0 {
+			logger.Fatalf("invalid SPARK_DAILY_QUOTA %q: want a positive number", v)
 		}
-	} else {
-		logger.Printf("DATABASE_URL not set, using in-memory onboarding store")
-		onboardingStore = onboarding.NewInMemoryStore()
 	}
+	sparksHandler := sparks.NewHandler(logger, sparkStore, matchStore, onboardingStore, safetyStore, screener, pushService,
+		sparkQuota)
+	matchesHandler := matches.NewHandler(logger, matchStore, onboardingStore, messageStore)
 
-	onboardingHandler := onboarding.NewHandler(logger, onboardingStore)
+	blobStore := openBlobStore(logger)
+	signingKey := []byte(os.Getenv("MEDIA_SIGNING_KEY"))
+	if len(signingKey) == 0 {
+		signingKey = jwtKey
+	}
+	mediaSigner := media.NewSigner(signingKey, os.Getenv("PUBLIC_BASE_URL"), media.DefaultURLTTL)
+	mediaHandler := media.NewHandler(logger, blobStore, mediaSigner)
+
+	chatHub := chat.NewHub(logger, bus, matchStore)
+	chatHandler := chat.NewHandler(logger, chatHub, matchStore, messageStore, safetyStore, blobStore, mediaSigner, screener,
+		pushService)
+	mediaHandler.Protect("chat", chatHandler.CanAccessMedia)
+
+	// Durations such as "30s"; unset means the package defaults.
+	chatDuration := durationEnv(logger, "BLIND_DATE_CHAT_DURATION")
+	decisionWindow := durationEnv(logger, "BLIND_DATE_DECISION_WINDOW")
+	blindDateSessions := blinddate.NewSessions(logger, sessionStore, matchStore, messageStore, onboardingStore,
+		chatHub, screener, chatDuration, decisionWindow)
+	blindDateQueue := blinddate.NewQueue(logger, blindDateStore, onboardingStore, bus, blindDateSessions)
+	blindDateHandler := blinddate.NewHandler(logger, blindDateQueue, blindDateSessions)
+	roomsHandler := rooms.NewHandler(logger, roomStore, onboardingStore, intentStore, safetyStore, chatHub, screener)
+	safetyHandler := safety.NewHandler(logger, safetyStore, reportStore, onboardingStore, matchStore,
+		messageStore, roomStore, blindDateSessions)
+
+	// Selfies go to moderators unless VERIFIER=fake, which approves every
+	// selfie at once for local development.
+	verifier := verification.NewManualReview(safety.NewVerificationReviews(reportStore))
+	if os.Getenv("VERIFIER") == "fake" {
+		verifier = verification.NewFake(verification.Decision{Status: verification.StatusApproved})
+	}
+	verifyService := verification.NewService(logger, verifyStore, onboardingStore, accountStore, blobStore, mediaSigner,
+		verifier, chatHub)
+	verifyHandler := verification.NewHandler(logger, verifyService)
+	mediaHandler.Protect("verification", verifyService.CanAccessMedia)
+
+	snoozeService := snooze.NewService(logger, onboardingStore, blindDateQueue, chatHub)
+	snoozeHandler := snooze.NewHandler(logger, snoozeService)
+
+	moderationHandler := moderation.NewHandler(logger, moderationStore, reportStore, accountStore, onboardingStore,
+		messageStore, chatHub, blindDateQueue, verifyService)
+
+	// ACCOUNT_DELETION_GRACE is a duration such as "720h"; unset means
+	// account.DefaultGracePeriod.
+	deletionGrace := durationEnv(logger, "ACCOUNT_DELETION_GRACE")
+	exporter := account.NewExporter(onboardingStore, matchStore, messageStore, roomStore, likesStore, sparkStore,
+		safetyStore, eventStore, verifyStore, blobStore)
+	accountHandler := account.NewHandler(logger, lifecycleStore, exporter, blobStore, mediaSigner, deletionGrace)
+	mediaHandler.Protect("exports", accountHandler.CanAccessMedia)
+	// The onboarding store deletes the users row, so it erases last.
+	accountPurger := account.NewPurger(logger, lifecycleStore, accountStore, chatHub, matchStore, messageStore, blobStore,
+		likesStore, sparkStore, safetyStore, reportStore, roomStore, account.EraserFunc(blindDateQueue.Leave),
+		sessionStore, eventStore, verifyStore, pushStore, onboardingStore)
+
+	// Background workers stop when the server receives SIGINT/SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go matches.RunExpiry(ctx, logger, matchStore, matches.DefaultExpiry, time.Hour)
+	go chatHub.RunPresence(ctx, chat.PresenceHeartbeat)
+	go blindDateQueue.Run(ctx)
+	go blindDateSessions.Run(ctx)
+	go eventAggregator.Run(ctx)
+	go accountPurger.Run(ctx, time.Minute)
+	go snoozeService.Run(ctx, time.Minute)
+	go pushService.Run(ctx, 2*time.Second)
 
 	mux := http.NewServeMux()
 
@@ -91,14 +297,176 @@ func main() {
 	mux.HandleFunc("/v1/onboarding/lifestyle", onboardingHandler.UpdateLifestyle)
 	mux.HandleFunc("/v1/onboarding/interests", onboardingHandler.UpdateInterests)
 	mux.HandleFunc("/v1/onboarding/location", onboardingHandler.UpdateLocation)
+	mux.HandleFunc("/v1/onboarding/primary-photo", onboardingHandler.UpdatePrimaryPhoto)
 	mux.HandleFunc("/v1/onboarding/complete", onboardingHandler.Complete)
 
+	// Discovery routes (v1)
+	mux.HandleFunc("/v1/discover", discoveryHandler.Discover)
+
+	// Intent routes (v1)
+	mux.HandleFunc("/v1/intents", intentsHandler.List)
+	mux.HandleFunc("/v1/intents/recommended", recommendHandler.Recommended)
+	mux.HandleFunc("/v1/intents/{id}", intentsHandler.Get)
+	mux.HandleFunc("/v1/intents/{id}/feed", discoveryHandler.IntentFeed)
+
+	// Behaviour events (v1)
+	mux.HandleFunc("/v1/events", eventsHandler.Ingest)
+
+	// Likes routes (v1)
+	mux.HandleFunc("/v1/likes", likesHandler.Like)
+	mux.HandleFunc("/v1/likes/received", likesHandler.Received)
+	mux.HandleFunc("/v1/passes", likesHandler.Pass)
+
+	// Sparks routes (v1)
+	mux.HandleFunc("/v1/sparks", sparksHandler.Send)
+	mux.HandleFunc("/v1/sparks/inbox", sparksHandler.Inbox)
+	mux.HandleFunc("/v1/sparks/sent", sparksHandler.Sent)
+	mux.HandleFunc("/v1/sparks/{id}/accept", sparksHandler.Accept)
+	mux.HandleFunc("/v1/sparks/{id}/decline", sparksHandler.Decline)
+
+	// Matches routes (v1)
+	mux.HandleFunc("/v1/matches", matchesHandler.List)
+	mux.HandleFunc("/v1/matches/{id}", matchesHandler.Unmatch)
+
+	// Chat routes (v1)
+	mux.HandleFunc("/v1/ws", chatHandler.ServeWS)
+	mux.HandleFunc("/v1/conversations/{id}/messages", chatHandler.Conversation)
+	mux.HandleFunc("/v1/conversations/{id}/messages/{messageId}", chatHandler.MessageItem)
+	mux.HandleFunc("/v1/conversations/{id}/messages/{messageId}/reaction", chatHandler.Reaction)
+	mux.HandleFunc("/v1/conversations/{id}/voice", chatHandler.SendVoice)
+	mux.HandleFunc("/v1/conversations/{id}/images", chatHandler.SendImage)
+	mux.HandleFunc("/v1/conversations/{id}/messages/{messageId}/reveal", chatHandler.Reveal)
+	mux.HandleFunc("/v1/conversations/{id}/settings", chatHandler.Settings)
+
+	// Blind date routes (v1)
+	mux.HandleFunc("/v1/blind-date/queue", blindDateHandler.Queue)
+	mux.HandleFunc("/v1/blind-date/ws", blindDateHandler.ServeWS)
+	mux.HandleFunc("/v1/blind-date/sessions/{id}", blindDateHandler.Session)
+	mux.HandleFunc("/v1/blind-date/sessions/{id}/messages", blindDateHandler.SessionMessages)
+	mux.HandleFunc("/v1/blind-date/sessions/{id}/decision", blindDateHandler.Decision)
+
+	// Rooms routes (v1)
+	mux.HandleFunc("/v1/rooms", roomsHandler.Rooms)
+	mux.HandleFunc("/v1/rooms/{id}", roomsHandler.Room)
+	mux.HandleFunc("/v1/rooms/{id}/join", roomsHandler.Join)
+	mux.HandleFunc("/v1/rooms/{id}/leave", roomsHandler.Leave)
+	mux.HandleFunc("/v1/rooms/{id}/members", roomsHandler.Members)
+	mux.HandleFunc("/v1/rooms/{id}/members/{userId}/role", roomsHandler.MemberRole)
+	mux.HandleFunc("/v1/rooms/{id}/members/{userId}/moderation", roomsHandler.Moderate)
+	mux.HandleFunc("/v1/rooms/{id}/messages", roomsHandler.Messages)
+	mux.HandleFunc("/v1/rooms/{id}/messages/{messageId}", roomsHandler.MessageItem)
+
+	// Safety routes (v1)
+	mux.HandleFunc("/v1/blocks", safetyHandler.Blocks)
+	mux.HandleFunc("/v1/blocks/{userId}", safetyHandler.Unblock)
+	mux.HandleFunc("/v1/reports", safetyHandler.Reports)
+
+	// Verification routes (v1)
+	mux.HandleFunc("/v1/verification", verifyHandler.Verification)
+	mux.HandleFunc("/v1/verification/{id}/selfie", verifyHandler.Selfie)
+
+	// Snooze routes (v1)
+	mux.HandleFunc("/v1/snooze", snoozeHandler.Snooze)
+
+	// Notification routes (v1)
+	mux.HandleFunc("/v1/devices", notificationsHandler.Devices)
+	mux.HandleFunc("/v1/devices/{id}", notificationsHandler.Device)
+	mux.HandleFunc("/v1/notifications/quiet-hours", notificationsHandler.QuietHours)
+
+	// Account routes (v1)
+	mux.HandleFunc("/v1/account", accountHandler.Account)
+	mux.HandleFunc("/v1/account/deletion", accountHandler.Deletion)
+	mux.HandleFunc("/v1/account/export", accountHandler.Export)
+
+	// Admin routes (v1) – the auth middleware restricts /v1/admin/ to admins.
+	mux.HandleFunc("/v1/admin/cases", moderationHandler.Cases)
+	mux.HandleFunc("/v1/admin/cases/{id}", moderationHandler.Case)
+	mux.HandleFunc("/v1/admin/cases/{id}/actions", moderationHandler.CaseActions)
+	mux.HandleFunc("/v1/admin/audit", moderationHandler.Audit)
+
+	// Media routes (v1) – signed URLs, see media.Signer.
+	mux.HandleFunc("/v1/media/{key...}", mediaHandler.Serve)
+
 	addr := ":8080"
 	logger.Printf("backend listening on %s, log file %s", addr, logPath)
-	rootHandler := loggingMiddleware(logger, auth.JWTUserContextMiddleware(logger, jwtKey, mux))
-	if err := http.ListenAndServe(addr, rootHandler); err != nil {
+	rootHandler := loggingMiddleware(logger, auth.JWTUserContextMiddleware(logger, jwtKey, accountStore, debugUserHeader,
+		onboarding.TrackActivity(logger, onboardingStore, mux)))
+
+	server := &http.Server{Addr: addr, Handler: rootHandler}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		logger.Fatalf("server error: %v", err)
+	case <-ctx.Done():
 	}
+
+	logger.Printf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Printf("shutdown error: %v", err)
+	}
+	// Shutdown doesn't track hijacked connections, so close WebSockets explicitly.
+	chatHub.Close()
+	blindDateQueue.Close()
+	_ = bus.Close()
+}
+
+// openDB connects to DATABASE_URL. It returns nil when the variable is unset
+// or Postgres is unreachable so callers can fall back to in-memory stores.
+func openDB(logger *log.Logger) *sql.DB {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		logger.Printf("DATABASE_URL not set, using in-memory stores")
+		return nil
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		logger.Printf("failed to connect to Postgres (using in-memory stores): %v", err)
+		return nil
+	}
+	if err := db.Ping(); err != nil {
+		logger.Printf("Postgres ping failed (using in-memory stores): %v", err)
+		_ = db.Close()
+		return nil
+	}
+
+	logger.Printf("using Postgres-backed stores")
+	return db
+}
+
+// openBlobStore stores media under MEDIA_DIR, or in memory when unset.
+func openBlobStore(logger *log.Logger) media.BlobStore {
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		logger.Printf("MEDIA_DIR not set, keeping media in memory")
+		return media.NewInMemoryBlobStore()
+	}
+	blobs, err := media.NewFSBlobStore(dir)
+	if err != nil {
+		logger.Fatalf("failed to open MEDIA_DIR: %v", err)
+	}
+	return blobs
+}
+
+// durationEnv parses the duration in the named variable, or returns 0 when it
+// is unset. A value that isn't a positive duration stops the server.
+func durationEnv(logger *log.Logger, name string) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		logger.Fatalf("invalid %s %q: want a positive duration such as \"30s\"", name, v)
+	}
+	return d
 }
 
 // loggingResponseWriter wraps http.ResponseWriter so we can capture status and bytes.
@@ -117,6 +485,17 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	}
 }
 
+// Hijack lets WebSocket upgrades take over the underlying connection.
+func (lrw *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	lrw.status = http.StatusSwitchingProtocols
+	lrw.wroteHeader = true
+	return hj.Hijack()
+}
+
 func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
 	if !lrw.wroteHeader {
 		// Default to 200 OK if WriteHeader was not called explicitly.
@@ -0,0 +1,57 @@
+// Package account lets people leave: deleting an account once a grace
+// period has passed, and exporting everything held about them.
+package account
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultGracePeriod is how long a deletion can be cancelled before the
+// account is erased.
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+var (
+	ErrNotScheduled = errors.New("no account deletion is scheduled")
+	ErrNoExport     = errors.New("no export yet")
+)
+
+// Deletion is a scheduled account deletion. Until PurgeAt the account works
+// as before and the deletion can be cancelled.
+type Deletion struct {
+	UserID      string    `json:"userId"`
+	RequestedAt time.Time `json:"requestedAt"`
+	PurgeAt     time.Time `json:"purgeAt"`
+}
+
+// Export is a user's data archive. URL is signed for the owner each time
+// the export is handed out and is never stored.
+type Export struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Key       string    `json:"-"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// Store persists scheduled deletions and each user's latest export.
+type Store interface {
+	// Schedule records a deletion. Scheduling again keeps the original.
+	Schedule(d Deletion) (*Deletion, error)
+	// Deletion returns userID's scheduled deletion, or ErrNotScheduled.
+	Deletion(userID string) (*Deletion, error)
+	// Cancel drops userID's scheduled deletion, or returns ErrNotScheduled.
+	Cancel(userID string) error
+	// Due returns up to limit deletions whose PurgeAt is at or before now,
+	// oldest first.
+	Due(now time.Time, limit int) ([]Deletion, error)
+	// Forget removes the deletion and export records of a purged user.
+	Forget(userID string) error
+
+	// SaveExport records e as its user's latest export and returns the one
+	// it replaces, if any.
+	SaveExport(e Export) (previous *Export, err error)
+	// LatestExport returns userID's most recent export, or ErrNoExport.
+	LatestExport(userID string) (*Export, error)
+}
@@ -0,0 +1,336 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/events"
+	"github.com/rijey/kindl/backend/internal/likes"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/rooms"
+	"github.com/rijey/kindl/backend/internal/safety"
+	"github.com/rijey/kindl/backend/internal/sparks"
+	"github.com/rijey/kindl/backend/internal/verification"
+)
+
+// exportLimit caps lists the stores only hand out in pages of a given size.
+const exportLimit = 100000
+
+// messagePage is how many chat messages are read at a time.
+const messagePage = 200
+
+const exportReadme = `This archive holds everything Kindl stores about you.
+
+profile.json         your profile and interests
+matches.json         everyone you matched with
+messages.json        the chat messages you sent; media files are in photos/ and voice/
+room_messages.json   what you posted in rooms
+likes.json           the likes you sent
+sparks.json          the sparks you sent
+blocks.json          the people you blocked
+events.json          how you used the app, as recorded for recommendations
+verification.json    your selfie verifications; the selfies are in verification/
+
+Profile photos live on your device; profile.json names your primary photo.
+Messages other people sent you are theirs and aren't included.
+`
+
+// exportProfile is profile.json.
+type exportProfile struct {
+	UserID            string     `json:"userId"`
+	Intent            string     `json:"intent,omitempty"`
+	PreferredGenders  []string   `json:"preferredGenders,omitempty"`
+	DisplayName       string     `json:"displayName,omitempty"`
+	Gender            string     `json:"gender,omitempty"`
+	Pronouns          string     `json:"pronouns,omitempty"`
+	Birthdate         string     `json:"birthdate,omitempty"`
+	ConnectionStyle   string     `json:"connectionStyle,omitempty"`
+	HeightCm          int        `json:"heightCm,omitempty"`
+	Drinks            string     `json:"drinks,omitempty"`
+	Smokes            string     `json:"smokes,omitempty"`
+	ExerciseLevel     string     `json:"exerciseLevel,omitempty"`
+	RelationshipStyle string     `json:"relationshipStyle,omitempty"`
+	Interests         []string   `json:"interests"`
+	Lat               float64    `json:"lat,omitempty"`
+	Lng               float64    `json:"lng,omitempty"`
+	PrimaryPhotoID    string     `json:"primaryPhotoId,omitempty"`
+	VerifiedAt        *time.Time `json:"verifiedAt,omitempty"`
+	SnoozedAt         *time.Time `json:"snoozedAt,omitempty"`
+	SnoozedUntil      *time.Time `json:"snoozedUntil,omitempty"`
+	OnboardedAt       *time.Time `json:"onboardedAt,omitempty"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// exportMessage is a chat message with the archive path of its media.
+type exportMessage struct {
+	chat.Message
+	File string `json:"file,omitempty"`
+}
+
+// exportAttempt is a verification attempt with the archive path of its
+// selfie.
+type exportAttempt struct {
+	verification.Attempt
+	Selfie string `json:"selfie,omitempty"`
+}
+
+// Exporter gathers everything held about a user into a zip archive.
+type Exporter struct {
+	profiles      onboarding.Store
+	matches       matches.Store
+	messages      chat.MessageStore
+	rooms         rooms.Store
+	likes         likes.Store
+	sparks        sparks.Store
+	blocks        safety.Store
+	events        events.Store
+	verifications verification.Store
+	blobs         media.BlobStore
+}
+
+func NewExporter(profiles onboarding.Store, matchStore matches.Store, messages chat.MessageStore,
+	roomStore rooms.Store, likeStore likes.Store, sparkStore sparks.Store, blocks safety.Store,
+	eventStore events.Store, verifications verification.Store, blobs media.BlobStore) *Exporter {
+	return &Exporter{
+		profiles:      profiles,
+		matches:       matchStore,
+		messages:      messages,
+		rooms:         roomStore,
+		likes:         likeStore,
+		sparks:        sparkStore,
+		blocks:        blocks,
+		events:        eventStore,
+		verifications: verifications,
+		blobs:         blobs,
+	}
+}
+
+// archive writes the files of one export.
+type archive struct {
+	zw    *zip.Writer
+	blobs media.BlobStore
+}
+
+func (a *archive) json(name string, v any) error {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// blob copies a stored blob to name plus an extension for its type and
+// returns the path, or "" if the blob is gone.
+func (a *archive) blob(name, key string) (string, error) {
+	f, info, err := a.blobs.Open(key)
+	if errors.Is(err, media.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	name += extension(info.ContentType)
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case media.ImageJPEG:
+		return ".jpg"
+	case media.ImagePNG:
+		return ".png"
+	case media.AudioMP4:
+		return ".m4a"
+	case media.AudioWAV:
+		return ".wav"
+	}
+	return ""
+}
+
+// Build returns the zip archive for userID.
+func (e *Exporter) Build(userID string) ([]byte, error) {
+	var buf bytes.Buffer
+	a := &archive{zw: zip.NewWriter(&buf), blobs: e.blobs}
+
+	w, err := a.zw.Create("README.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, exportReadme); err != nil {
+		return nil, err
+	}
+
+	for _, step := range []func(*archive, string) error{
+		e.profile,
+		e.conversations,
+		e.activity,
+		e.verification,
+	} {
+		if err := step(a, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *Exporter) profile(a *archive, userID string) error {
+	p, err := e.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return a.json("profile.json", nil)
+	}
+	if err != nil {
+		return err
+	}
+	return a.json("profile.json", exportProfile{
+		UserID:            p.UserID,
+		Intent:            p.Intent,
+		PreferredGenders:  p.PreferredGenders,
+		DisplayName:       p.DisplayName,
+		Gender:            p.Gender,
+		Pronouns:          p.Pronouns,
+		Birthdate:         p.Birthdate,
+		ConnectionStyle:   p.ConnectionStyle,
+		HeightCm:          p.HeightCm,
+		Drinks:            p.Drinks,
+		Smokes:            p.Smokes,
+		ExerciseLevel:     p.ExerciseLevel,
+		RelationshipStyle: p.RelationshipStyle,
+		Interests:         nonNil(p.Interests),
+		Lat:               p.Lat,
+		Lng:               p.Lng,
+		PrimaryPhotoID:    p.PrimaryPhotoID,
+		VerifiedAt:        p.VerifiedAt,
+		SnoozedAt:         p.SnoozedAt,
+		SnoozedUntil:      p.SnoozedUntil,
+		OnboardedAt:       p.OnboardedAt,
+		UpdatedAt:         p.UpdatedAt,
+	})
+}
+
+// conversations writes matches.json and the messages userID sent in them,
+// with their media.
+func (e *Exporter) conversations(a *archive, userID string) error {
+	ms, err := e.matches.List(userID)
+	if err != nil {
+		return err
+	}
+	if err := a.json("matches.json", nonNil(ms)); err != nil {
+		return err
+	}
+
+	sent := []exportMessage{}
+	for _, m := range ms {
+		before := ""
+		for {
+			page, more, err := e.messages.List(m.ID, before, messagePage)
+			if err != nil {
+				return err
+			}
+			for _, msg := range page {
+				if msg.SenderID != userID {
+					continue
+				}
+				out := exportMessage{Message: msg}
+				if att := msg.Attachment; att != nil && att.Key != "" {
+					dir := "photos/"
+					if msg.Kind == chat.KindAudio {
+						dir = "voice/"
+					}
+					if out.File, err = a.blob(dir+msg.ID, att.Key); err != nil {
+						return err
+					}
+				}
+				sent = append(sent, out)
+			}
+			if !more || len(page) == 0 {
+				break
+			}
+			before = page[len(page)-1].ID
+		}
+	}
+	if err := a.json("messages.json", sent); err != nil {
+		return err
+	}
+
+	posts, err := e.rooms.SentMessages(userID)
+	if err != nil {
+		return err
+	}
+	return a.json("room_messages.json", nonNil(posts))
+}
+
+// activity writes likes, sparks, blocks and behaviour events.
+func (e *Exporter) activity(a *archive, userID string) error {
+	ls, err := e.likes.Sent(userID, exportLimit)
+	if err != nil {
+		return err
+	}
+	if err := a.json("likes.json", nonNil(ls)); err != nil {
+		return err
+	}
+	ss, err := e.sparks.Sent(userID, exportLimit)
+	if err != nil {
+		return err
+	}
+	if err := a.json("sparks.json", nonNil(ss)); err != nil {
+		return err
+	}
+	bs, err := e.blocks.ListBlocks(userID)
+	if err != nil {
+		return err
+	}
+	if err := a.json("blocks.json", nonNil(bs)); err != nil {
+		return err
+	}
+	evs, err := e.events.List(userID)
+	if err != nil {
+		return err
+	}
+	return a.json("events.json", nonNil(evs))
+}
+
+func (e *Exporter) verification(a *archive, userID string) error {
+	attempts, err := e.verifications.List(userID)
+	if err != nil {
+		return err
+	}
+	out := make([]exportAttempt, 0, len(attempts))
+	for _, at := range attempts {
+		ea := exportAttempt{Attempt: at}
+		if at.SelfieKey != "" {
+			if ea.Selfie, err = a.blob("verification/"+at.ID, at.SelfieKey); err != nil {
+				return err
+			}
+		}
+		out = append(out, ea)
+	}
+	return a.json("verification.json", out)
+}
+
+// nonNil makes empty lists encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/events"
+	"github.com/rijey/kindl/backend/internal/likes"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/rooms"
+	"github.com/rijey/kindl/backend/internal/safety"
+	"github.com/rijey/kindl/backend/internal/sparks"
+	"github.com/rijey/kindl/backend/internal/verification"
+)
+
+type exportEnv struct {
+	exporter *Exporter
+	handler  *Handler
+	store    Store
+	blobs    media.BlobStore
+}
+
+// newExportEnv sets up u1, matched with u2, with a message and a photo
+// each way, a like and a block.
+func newExportEnv(t *testing.T) *exportEnv {
+	t.Helper()
+	profiles := onboarding.NewInMemoryStore()
+	matchStore := matches.NewInMemoryStore()
+	messages := chat.NewInMemoryMessageStore()
+	likeStore := likes.NewInMemoryStore()
+	blocks := safety.NewInMemoryStore()
+	e := &exportEnv{store: NewInMemoryStore(), blobs: media.NewInMemoryBlobStore()}
+	e.exporter = NewExporter(profiles, matchStore, messages, rooms.NewInMemoryStore(), likeStore,
+		sparks.NewInMemoryStore(), blocks, events.NewInMemoryStore(), verification.NewInMemoryStore(), e.blobs)
+	e.handler = NewHandler(log.New(io.Discard, "", 0), e.store, e.exporter, e.blobs,
+		media.NewSigner([]byte("k"), "", 0), 0)
+
+	for _, id := range []string{"u1", "u2"} {
+		if err := profiles.MarkOnboardingComplete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, _, err := matchStore.Create("u1", "u2", "like")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []chat.Message{
+		{ID: "m1", SenderID: "u1", Kind: chat.KindText, Text: "hi"},
+		{ID: "m2", SenderID: "u2", Kind: chat.KindText, Text: "hey"},
+		{ID: "m3", SenderID: "u1", Kind: chat.KindImage, Attachment: &chat.Attachment{Key: "chat/" + m.ID + "/p1"}},
+		{ID: "m4", SenderID: "u2", Kind: chat.KindImage, Attachment: &chat.Attachment{Key: "chat/" + m.ID + "/p2"}},
+	} {
+		msg.ConversationID = m.ID
+		msg.SentAt = time.Now()
+		if msg.Attachment != nil {
+			if err := e.blobs.Put(msg.Attachment.Key, media.ImageJPEG, []byte("jpeg "+msg.ID)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, _, err := messages.Append(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := likeStore.Like(likes.LikeInput{FromUserID: "u1", ToUserID: "u3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := blocks.Block("u1", "u4", "profile"); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestExportContents(t *testing.T) {
+	e := newExportEnv(t)
+	data, err := e.exporter.Build("u1")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	files := readArchive(t, data)
+
+	for _, name := range []string{"README.txt", "profile.json", "matches.json", "messages.json", "room_messages.json",
+		"likes.json", "sparks.json", "blocks.json", "events.json", "verification.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive has no %s", name)
+		}
+	}
+
+	var sent []exportMessage
+	if err := json.Unmarshal(files["messages.json"], &sent); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, m := range sent {
+		got[m.ID] = m.File
+	}
+	if len(got) != 2 || got["m3"] != "photos/m3.jpg" {
+		t.Errorf("messages.json holds %v, want only u1's m1 and m3 with its photo", got)
+	}
+	if _, ok := got["m1"]; !ok {
+		t.Errorf("messages.json holds %v, want m1", got)
+	}
+	if string(files["photos/m3.jpg"]) != "jpeg m3" {
+		t.Errorf("photos/m3.jpg = %q", files["photos/m3.jpg"])
+	}
+	if _, ok := files["photos/m4.jpg"]; ok {
+		t.Error("the archive holds a photo u2 sent")
+	}
+
+	for name, want := range map[string]int{"likes.json": 1, "blocks.json": 1, "sparks.json": 0, "events.json": 0} {
+		var list []json.RawMessage
+		if err := json.Unmarshal(files[name], &list); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(list) != want {
+			t.Errorf("%s has %d entries, want %d", name, len(list), want)
+		}
+	}
+}
+
+// Export requests from several devices at once must leave one archive
+// stored, the latest, and no orphaned blobs.
+func TestConcurrentExports(t *testing.T) {
+	e := newExportEnv(t)
+	const n = 6
+	keys := make(chan string, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ex, err := e.handler.export("u1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			keys <- ex.Key
+		}()
+	}
+	wg.Wait()
+	close(keys)
+
+	latest, err := e.store.LatestExport("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := 0
+	for key := range keys {
+		f, _, err := e.blobs.Open(key)
+		if errors.Is(err, media.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		kept++
+		if key != latest.Key {
+			t.Errorf("archive %s was left behind; the latest is %s", key, latest.Key)
+		}
+	}
+	if kept != 1 {
+		t.Errorf("%d archives stored, want 1", kept)
+	}
+}
@@ -0,0 +1,205 @@
+package account
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/media"
+)
+
+// Handler exposes account deletion and data export.
+type Handler struct {
+	logger   *log.Logger
+	store    Store
+	exporter *Exporter
+	blobs    media.BlobStore
+	signer   *media.Signer
+	grace    time.Duration
+}
+
+// NewHandler returns a Handler. grace is how long deletions wait; zero
+// means DefaultGracePeriod.
+func NewHandler(logger *log.Logger, store Store, exporter *Exporter, blobs media.BlobStore, signer *media.Signer,
+	grace time.Duration) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	return &Handler{
+		logger:   logger,
+		store:    store,
+		exporter: exporter,
+		blobs:    blobs,
+		signer:   signer,
+		grace:    grace,
+	}
+}
+
+// --- Helpers ---
+
+// withURL signs the export's download link for its owner.
+func (h *Handler) withURL(e *Export) *Export {
+	e.URL = h.signer.URL(e.Key, e.UserID)
+	return e
+}
+
+// --- Handlers ---
+
+// Account handles DELETE /v1/account
+//
+// It schedules the account for deletion after the grace period and returns
+// the deletion. Until purgeAt the account keeps working, and DELETE
+// /v1/account/deletion cancels; afterwards the profile, matches and their
+// conversations, likes, sparks, room posts, events, selfies and media are
+// erased for good.
+func (h *Handler) Account(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	d, err := h.store.Schedule(Deletion{UserID: userID, RequestedAt: now, PurgeAt: now.Add(h.grace)})
+	if err != nil {
+		h.logger.Printf("Schedule deletion error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to schedule deletion"))
+		return
+	}
+	httpjson.Write(w, http.StatusAccepted, map[string]any{"deletion": d})
+}
+
+// Deletion handles /v1/account/deletion
+//
+//	GET     the scheduled deletion, or 404 if there is none
+//	DELETE  cancels it
+func (h *Handler) Deletion(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		d, err := h.store.Deletion(userID)
+		if errors.Is(err, ErrNotScheduled) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("Get deletion error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load deletion"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"deletion": d})
+
+	case http.MethodDelete:
+		err := h.store.Cancel(userID)
+		if errors.Is(err, ErrNotScheduled) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("Cancel deletion error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to cancel deletion"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// Export handles /v1/account/export
+//
+//	POST  builds a zip archive of everything held about the caller and
+//	      returns it with a signed download url
+//	GET   the latest export with a fresh url, or 404
+//
+// Each export replaces the previous one.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		e, err := h.store.LatestExport(userID)
+		if errors.Is(err, ErrNoExport) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("Get export error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load export"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"export": h.withURL(e)})
+
+	case http.MethodPost:
+		e, err := h.export(userID)
+		if err != nil {
+			h.logger.Printf("Export error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to export data"))
+			return
+		}
+		httpjson.Write(w, http.StatusCreated, map[string]any{"export": h.withURL(e)})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// export builds and stores a new archive, then drops the one it replaces.
+func (h *Handler) export(userID string) (*Export, error) {
+	data, err := h.exporter.Build(userID)
+	if err != nil {
+		return nil, err
+	}
+	e := Export{
+		ID:        idgen.New(),
+		UserID:    userID,
+		Size:      int64(len(data)),
+		CreatedAt: time.Now().UTC(),
+	}
+	e.Key = "exports/" + media.KeySegment(userID) + "/" + e.ID
+	if err := h.blobs.Put(e.Key, "application/zip", data); err != nil {
+		return nil, err
+	}
+	previous, err := h.store.SaveExport(e)
+	if err != nil {
+		if derr := h.blobs.Delete(e.Key); derr != nil {
+			h.logger.Printf("account: export cleanup error: %v", derr)
+		}
+		return nil, err
+	}
+	if previous != nil {
+		if err := h.blobs.Delete(previous.Key); err != nil {
+			h.logger.Printf("account: delete previous export: %v", err)
+		}
+	}
+	return &e, nil
+}
+
+// CanAccessMedia is the media.AccessFunc for the "exports" namespace:
+// archives are only served to their owner.
+func (h *Handler) CanAccessMedia(viewerID, key string) (bool, error) {
+	parts := strings.Split(key, "/")
+	return len(parts) == 3 && parts[1] == media.KeySegment(viewerID), nil
+}
@@ -0,0 +1,128 @@
+package account
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+)
+
+// purgeBatch bounds how many accounts one pass erases.
+const purgeBatch = 50
+
+// Eraser removes what one store holds about a user.
+type Eraser interface {
+	DeleteUser(userID string) error
+}
+
+// EraserFunc adapts a function, such as (*blinddate.Queue).Leave, to an
+// Eraser.
+type EraserFunc func(userID string) error
+
+func (f EraserFunc) DeleteUser(userID string) error { return f(userID) }
+
+// Connections closes users' live sockets; *chat.Hub implements it.
+type Connections interface {
+	Disconnect(userID string)
+}
+
+// Purger erases accounts once their grace period is over.
+type Purger struct {
+	logger   *log.Logger
+	store    Store
+	accounts auth.AccountStore
+	conns    Connections
+	matches  matches.Store
+	messages chat.MessageStore
+	blobs    media.BlobStore
+	erasers  []Eraser
+}
+
+// NewPurger returns a Purger. erasers run in order after the user's
+// conversations are gone; the onboarding store, whose users row everything
+// else references, belongs last.
+func NewPurger(logger *log.Logger, store Store, accounts auth.AccountStore, conns Connections,
+	matchStore matches.Store, messages chat.MessageStore, blobs media.BlobStore, erasers ...Eraser) *Purger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Purger{
+		logger:   logger,
+		store:    store,
+		accounts: accounts,
+		conns:    conns,
+		matches:  matchStore,
+		messages: messages,
+		blobs:    blobs,
+		erasers:  erasers,
+	}
+}
+
+// Purge erases userID now: their sessions end, then their conversations
+// (for both sides), stored data and media are deleted. The account record
+// stays so a ban outlives the person. Every step can be repeated, so a
+// failed purge is simply retried.
+func (p *Purger) Purge(userID string) error {
+	if err := p.accounts.RevokeSessions(userID, time.Now().UTC()); err != nil {
+		return err
+	}
+	p.conns.Disconnect(userID)
+
+	// Attachments are found through the matches, so they go first.
+	ms, err := p.matches.List(userID)
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		if err := p.blobs.DeleteAll("chat/" + m.ID); err != nil {
+			return err
+		}
+		if err := p.messages.DeleteConversation(m.ID); err != nil {
+			return err
+		}
+	}
+	if err := p.matches.DeleteUser(userID); err != nil {
+		return err
+	}
+	for _, e := range p.erasers {
+		if err := e.DeleteUser(userID); err != nil {
+			return err
+		}
+	}
+	for _, ns := range []string{"verification", "exports"} {
+		if err := p.blobs.DeleteAll(ns + "/" + media.KeySegment(userID)); err != nil {
+			return err
+		}
+	}
+	return p.store.Forget(userID)
+}
+
+// Run purges due deletions every interval. It blocks until ctx is
+// cancelled.
+func (p *Purger) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		due, err := p.store.Due(time.Now(), purgeBatch)
+		if err != nil {
+			p.logger.Printf("account purge error: %v", err)
+		}
+		for _, d := range due {
+			if err := p.Purge(d.UserID); err != nil {
+				p.logger.Printf("account purge %s error: %v", d.UserID, err)
+				continue
+			}
+			p.logger.Printf("purged account %s", d.UserID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package account
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/safety"
+)
+
+type recordingConns struct {
+	mu           sync.Mutex
+	disconnected []string
+}
+
+func (c *recordingConns) Disconnect(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disconnected = append(c.disconnected, userID)
+}
+
+type purgeEnv struct {
+	purger   *Purger
+	store    Store
+	accounts auth.AccountStore
+	conns    *recordingConns
+	matches  matches.Store
+	messages chat.MessageStore
+	blobs    media.BlobStore
+	reports  safety.ReportStore
+	profiles onboarding.Store
+	match    *matches.Match
+}
+
+// newPurgeEnv sets up u1, matched and chatting with u2, with a photo in the
+// conversation, a report each way with u3 and a scheduled deletion.
+func newPurgeEnv(t *testing.T) *purgeEnv {
+	t.Helper()
+	e := &purgeEnv{
+		store:    NewInMemoryStore(),
+		accounts: auth.NewInMemoryAccountStore(),
+		conns:    &recordingConns{},
+		matches:  matches.NewInMemoryStore(),
+		messages: chat.NewInMemoryMessageStore(),
+		blobs:    media.NewInMemoryBlobStore(),
+		reports:  safety.NewInMemoryReportStore(),
+		profiles: onboarding.NewInMemoryStore(),
+	}
+	e.purger = NewPurger(log.New(io.Discard, "", 0), e.store, e.accounts, e.conns, e.matches, e.messages, e.blobs,
+		e.reports, e.profiles)
+
+	for _, id := range []string{"u1", "u2"} {
+		if err := e.profiles.MarkOnboardingComplete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, _, err := e.matches.Create("u1", "u2", "like")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.match = m
+	photoKey := "chat/" + m.ID + "/p1"
+	if err := e.blobs.Put(photoKey, media.ImageJPEG, []byte("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []chat.Message{
+		{ID: "m1", ConversationID: m.ID, SenderID: "u1", Kind: chat.KindText, Text: "hi", SentAt: time.Now()},
+		{ID: "m2", ConversationID: m.ID, SenderID: "u2", Kind: chat.KindImage, SentAt: time.Now(),
+			Attachment: &chat.Attachment{Key: photoKey}},
+	} {
+		if _, _, err := e.messages.Append(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []safety.Report{
+		{ID: "r-by", ReporterID: "u1", ReportedUserID: "u3", Status: safety.ReportStatusOpen},
+		{ID: "r-about", ReporterID: "u3", ReportedUserID: "u1", Status: safety.ReportStatusOpen},
+	} {
+		if _, err := e.reports.CreateReport(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	if _, err := e.store.Schedule(Deletion{UserID: "u1", RequestedAt: now.Add(-time.Hour), PurgeAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// checkPurged asserts everything about u1 is gone except what must outlive
+// them.
+func (e *purgeEnv) checkPurged(t *testing.T) {
+	t.Helper()
+	a, err := e.accounts.Get("u1")
+	if err != nil || a.SessionsRevokedAt == nil {
+		t.Errorf("account %+v, %v: want it kept with sessions revoked", a, err)
+	}
+	if _, err := e.profiles.GetProfile("u1"); !errors.Is(err, onboarding.ErrProfileNotFound) {
+		t.Errorf("profile lookup error = %v, want ErrProfileNotFound", err)
+	}
+	if _, err := e.profiles.GetProfile("u2"); err != nil {
+		t.Errorf("the other side's profile went too: %v", err)
+	}
+	if ms, _ := e.matches.List("u2"); len(ms) != 0 {
+		t.Errorf("u2 still has matches %+v", ms)
+	}
+	if msgs, _, _ := e.messages.List(e.match.ID, "", 10); len(msgs) != 0 {
+		t.Errorf("conversation still holds %d messages", len(msgs))
+	}
+	if _, _, err := e.blobs.Open("chat/" + e.match.ID + "/p1"); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("chat photo open error = %v, want ErrNotFound", err)
+	}
+	if _, err := e.store.Deletion("u1"); !errors.Is(err, ErrNotScheduled) {
+		t.Errorf("deletion lookup error = %v, want ErrNotScheduled", err)
+	}
+
+	filed, err := e.reports.GetReport("r-by")
+	if err != nil || filed.ReporterID != "" || filed.ReportedUserID != "u3" {
+		t.Errorf("report filed by u1: %+v, %v; want it kept without a reporter", filed, err)
+	}
+	about, err := e.reports.ReportsAgainst("u1", 10)
+	if err != nil || len(about) != 1 || about[0].ID != "r-about" || about[0].ReporterID != "u3" {
+		t.Errorf("reports about u1: %+v, %v; want r-about kept as filed", about, err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	e := newPurgeEnv(t)
+	if err := e.purger.Purge("u1"); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	e.checkPurged(t)
+	if len(e.conns.disconnected) != 1 || e.conns.disconnected[0] != "u1" {
+		t.Errorf("disconnected %v, want [u1]", e.conns.disconnected)
+	}
+
+	// A retried purge finds nothing left to do.
+	if err := e.purger.Purge("u1"); err != nil {
+		t.Fatalf("second Purge: %v", err)
+	}
+	e.checkPurged(t)
+}
+
+// Instances run the worker side by side, so two of them can pick up the same
+// deletion.
+func TestConcurrentPurges(t *testing.T) {
+	e := newPurgeEnv(t)
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- e.purger.Purge("u1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Purge: %v", err)
+		}
+	}
+	e.checkPurged(t)
+}
@@ -0,0 +1,97 @@
+package account
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	deletions map[string]*Deletion
+	exports   map[string]*Export
+}
+
+// NewInMemoryStore returns an in-memory account Store.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		deletions: make(map[string]*Deletion),
+		exports:   make(map[string]*Export),
+	}
+}
+
+func (s *memoryStore) Schedule(d Deletion) (*Deletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.deletions[d.UserID]; ok {
+		out := *existing
+		return &out, nil
+	}
+	s.deletions[d.UserID] = &d
+	out := d
+	return &out, nil
+}
+
+func (s *memoryStore) Deletion(userID string) (*Deletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deletions[userID]
+	if !ok {
+		return nil, ErrNotScheduled
+	}
+	out := *d
+	return &out, nil
+}
+
+func (s *memoryStore) Cancel(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deletions[userID]; !ok {
+		return ErrNotScheduled
+	}
+	delete(s.deletions, userID)
+	return nil
+}
+
+func (s *memoryStore) Due(now time.Time, limit int) ([]Deletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Deletion
+	for _, d := range s.deletions {
+		if !d.PurgeAt.After(now) {
+			out = append(out, *d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PurgeAt.Before(out[j].PurgeAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Forget(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deletions, userID)
+	delete(s.exports, userID)
+	return nil
+}
+
+func (s *memoryStore) SaveExport(e Export) (*Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.exports[e.UserID]
+	s.exports[e.UserID] = &e
+	return previous, nil
+}
+
+func (s *memoryStore) LatestExport(userID string) (*Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.exports[userID]
+	if !ok {
+		return nil, ErrNoExport
+	}
+	out := *e
+	return &out, nil
+}
@@ -0,0 +1,151 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// pgStore keeps deletions in account_deletions and exports in
+// account_exports (sql/0022_account_lifecycle.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs an account Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+func scanDeletion(row interface{ Scan(...any) error }) (*Deletion, error) {
+	var d Deletion
+	if err := row.Scan(&d.UserID, &d.RequestedAt, &d.PurgeAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotScheduled
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *pgStore) Schedule(d Deletion) (*Deletion, error) {
+	ctx := context.Background()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO account_deletions (user_id, requested_at, purge_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`, d.UserID, d.RequestedAt, d.PurgeAt); err != nil {
+		return nil, err
+	}
+	return s.Deletion(d.UserID)
+}
+
+func (s *pgStore) Deletion(userID string) (*Deletion, error) {
+	return scanDeletion(s.db.QueryRowContext(context.Background(), `
+		SELECT user_id, requested_at, purge_at FROM account_deletions WHERE user_id = $1
+	`, userID))
+}
+
+func (s *pgStore) Cancel(userID string) error {
+	res, err := s.db.ExecContext(context.Background(), `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotScheduled
+	}
+	return nil
+}
+
+func (s *pgStore) Due(now time.Time, limit int) ([]Deletion, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT user_id, requested_at, purge_at FROM account_deletions
+		WHERE purge_at <= $1
+		ORDER BY purge_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Deletion
+	for rows.Next() {
+		d, err := scanDeletion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Forget(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM account_deletions WHERE user_id = $1`,
+		`DELETE FROM account_exports WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const exportColumns = `id, user_id, blob_key, size, created_at`
+
+func scanExport(row interface{ Scan(...any) error }) (*Export, error) {
+	var e Export
+	if err := row.Scan(&e.ID, &e.UserID, &e.Key, &e.Size, &e.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoExport
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *pgStore) SaveExport(e Export) (*Export, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	previous, err := scanExport(tx.QueryRowContext(ctx,
+		`SELECT `+exportColumns+` FROM account_exports WHERE user_id = $1 FOR UPDATE`, e.UserID))
+	if errors.Is(err, ErrNoExport) {
+		previous, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO account_exports (id, user_id, blob_key, size, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			id = EXCLUDED.id,
+			blob_key = EXCLUDED.blob_key,
+			size = EXCLUDED.size,
+			created_at = EXCLUDED.created_at
+	`, e.ID, e.UserID, e.Key, e.Size, e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return previous, tx.Commit()
+}
+
+func (s *pgStore) LatestExport(userID string) (*Export, error) {
+	return scanExport(s.db.QueryRowContext(context.Background(),
+		`SELECT `+exportColumns+` FROM account_exports WHERE user_id = $1`, userID))
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Roles. Admins may use the /v1/admin API; everyone else is a user.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Account statuses set by moderation.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusBanned    = "banned"
+)
+
+// ErrAccountRestricted is returned when a suspended or banned account tries
+// to sign in.
+var ErrAccountRestricted = errors.New("account is restricted")
+
+// Account is the server-side state behind a user ID that tokens alone can't
+// carry: their role, whether moderation restricted them, and when their
+// sessions were last revoked.
+type Account struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+	// SuspendedUntil is set while Status is suspended; the suspension lifts
+	// by itself once it passes.
+	SuspendedUntil *time.Time `json:"suspendedUntil,omitempty"`
+	// SessionsRevokedAt invalidates every token issued before it.
+	SessionsRevokedAt *time.Time `json:"sessionsRevokedAt,omitempty"`
+	UpdatedAt         time.Time  `json:"updatedAt,omitzero"`
+}
+
+// Restricted reports whether the account may not use the API at now.
+func (a *Account) Restricted(now time.Time) bool {
+	switch a.Status {
+	case StatusBanned:
+		return true
+	case StatusSuspended:
+		return a.SuspendedUntil == nil || now.Before(*a.SuspendedUntil)
+	}
+	return false
+}
+
+// Revoked reports whether a token issued at issuedAt has been revoked.
+func (a *Account) Revoked(issuedAt time.Time) bool {
+	return a.SessionsRevokedAt != nil && issuedAt.Before(*a.SessionsRevokedAt)
+}
+
+// defaultAccount is the state of anyone without a stored account row.
+func defaultAccount(userID string) *Account {
+	return &Account{UserID: userID, Role: RoleUser, Status: StatusActive}
+}
+
+// AccountStore persists accounts. Users who were never touched by an admin
+// have no row and read back as active users.
+type AccountStore interface {
+	Get(userID string) (*Account, error)
+	SetRole(userID, role string) error
+	// Restrict sets status (active, suspended or banned). Suspending or
+	// banning also revokes every session issued before at.
+	Restrict(userID, status string, until *time.Time, at time.Time) (*Account, error)
+	// RevokeSessions invalidates every token issued to userID before at.
+	RevokeSessions(userID string, at time.Time) error
+}
+
+const roleContextKey contextKey = "role"
+
+// ContextWithRole stores the authenticated user's role in the context.
+func ContextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleContextKey, role)
+}
+
+// RoleFromContext returns the role attached by the middleware, or RoleUser.
+func RoleFromContext(ctx context.Context) string {
+	if role, ok := ctx.Value(roleContextKey).(string); ok && role != "" {
+		return role
+	}
+	return RoleUser
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryAccountStore struct {
+	mu       sync.Mutex
+	accounts map[string]*Account
+}
+
+// NewInMemoryAccountStore returns an in-memory AccountStore.
+func NewInMemoryAccountStore() AccountStore {
+	return &memoryAccountStore{accounts: make(map[string]*Account)}
+}
+
+func (s *memoryAccountStore) getOrCreateLocked(userID string) *Account {
+	a, ok := s.accounts[userID]
+	if !ok {
+		a = defaultAccount(userID)
+		s.accounts[userID] = a
+	}
+	return a
+}
+
+func (s *memoryAccountStore) Get(userID string) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[userID]; ok {
+		cp := *a
+		return &cp, nil
+	}
+	return defaultAccount(userID), nil
+}
+
+func (s *memoryAccountStore) SetRole(userID, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.getOrCreateLocked(userID)
+	a.Role = role
+	a.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (s *memoryAccountStore) Restrict(userID, status string, until *time.Time, at time.Time) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.getOrCreateLocked(userID)
+	a.Status = status
+	a.SuspendedUntil = nil
+	if status == StatusSuspended {
+		a.SuspendedUntil = until
+	}
+	if status != StatusActive {
+		a.SessionsRevokedAt = &at
+	}
+	a.UpdatedAt = at
+	cp := *a
+	return &cp, nil
+}
+
+func (s *memoryAccountStore) RevokeSessions(userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.getOrCreateLocked(userID)
+	a.SessionsRevokedAt = &at
+	a.UpdatedAt = at
+	return nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// pgAccountStore keeps accounts in the accounts table
+// (sql/0019_moderation.sql).
+type pgAccountStore struct {
+	db *sql.DB
+}
+
+// NewPGAccountStore constructs an AccountStore backed by Postgres.
+func NewPGAccountStore(db *sql.DB) AccountStore {
+	return &pgAccountStore{db: db}
+}
+
+func (s *pgAccountStore) Get(userID string) (*Account, error) {
+	a, err := scanAccount(s.db.QueryRowContext(context.Background(), `
+		SELECT user_id, role, status, suspended_until, sessions_revoked_at, updated_at
+		FROM accounts WHERE user_id = $1
+	`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultAccount(userID), nil
+	}
+	return a, err
+}
+
+func (s *pgAccountStore) SetRole(userID, role string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO accounts (user_id, role, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+	`, userID, role)
+	return err
+}
+
+func (s *pgAccountStore) Restrict(userID, status string, until *time.Time, at time.Time) (*Account, error) {
+	var suspendedUntil sql.NullTime
+	if status == StatusSuspended && until != nil {
+		suspendedUntil = sql.NullTime{Time: *until, Valid: true}
+	}
+	var revokedAt sql.NullTime
+	if status != StatusActive {
+		revokedAt = sql.NullTime{Time: at, Valid: true}
+	}
+	// Lifting a restriction keeps the last revocation: tokens issued before
+	// it stay dead.
+	return scanAccount(s.db.QueryRowContext(context.Background(), `
+		INSERT INTO accounts (user_id, status, suspended_until, sessions_revoked_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			suspended_until = EXCLUDED.suspended_until,
+			sessions_revoked_at = COALESCE(EXCLUDED.sessions_revoked_at, accounts.sessions_revoked_at),
+			updated_at = EXCLUDED.updated_at
+		RETURNING user_id, role, status, suspended_until, sessions_revoked_at, updated_at
+	`, userID, status, suspendedUntil, revokedAt, at))
+}
+
+func (s *pgAccountStore) RevokeSessions(userID string, at time.Time) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO accounts (user_id, sessions_revoked_at, updated_at)
+		VALUES ($1, $2, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			sessions_revoked_at = EXCLUDED.sessions_revoked_at,
+			updated_at = EXCLUDED.updated_at
+	`, userID, at)
+	return err
+}
+
+func scanAccount(row *sql.Row) (*Account, error) {
+	var (
+		a              Account
+		until, revoked sql.NullTime
+	)
+	if err := row.Scan(&a.UserID, &a.Role, &a.Status, &until, &revoked, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if until.Valid {
+		a.SuspendedUntil = &until.Time
+	}
+	if revoked.Valid {
+		a.SessionsRevokedAt = &revoked.Time
+	}
+	return &a, nil
+}
@@ -23,6 +23,7 @@ import (
 type Handler struct {
 	logger    *log.Logger
 	jwtSecret []byte
+	accounts  AccountStore
 
 	googleClientID string
 	googleVerifier *oidc.IDTokenVerifier
@@ -36,8 +37,9 @@ type Handler struct {
 }
 
 // NewHandler constructs an auth handler. It initialises a Google ID token verifier if
-// GOOGLE_CLIENT_ID is provided via environment or argument.
-func NewHandler(logger *log.Logger, jwtSecret []byte, googleClientID string) (*Handler, error) {
+// GOOGLE_CLIENT_ID is provided via environment or argument. Restricted
+// accounts in accounts are refused new tokens.
+func NewHandler(logger *log.Logger, jwtSecret []byte, googleClientID string, accounts AccountStore) (*Handler, error) {
 	if logger == nil {
 		logger = log.New(os.Stdout, "[auth] ", log.LstdFlags|log.Lshortfile)
 	}
@@ -45,6 +47,7 @@ func NewHandler(logger *log.Logger, jwtSecret []byte, googleClientID string) (*H
 	h := &Handler{
 		logger:      logger,
 		jwtSecret:   jwtSecret,
+		accounts:    accounts,
 		phoneOTPs:   make(map[string]phoneOTPEntry),
 		otpLifetime: 5 * time.Minute,
 	}
@@ -185,7 +188,7 @@ func (h *Handler) GoogleSignIn(w http.ResponseWriter, r *http.Request) {
 
 		accessToken, refreshToken, err := h.issueTokens(userID)
 		if err != nil {
-			h.writeError(w, http.StatusInternalServerError, err)
+			h.tokenError(w, err)
 			return
 		}
 
@@ -209,7 +212,7 @@ func (h *Handler) GoogleSignIn(w http.ResponseWriter, r *http.Request) {
 
 	accessToken, refreshToken, err := h.issueTokens(userID)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, err)
+		h.tokenError(w, err)
 		return
 	}
 
@@ -225,6 +228,15 @@ func (h *Handler) GoogleSignIn(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, resp)
 }
 
+// tokenError reports a failure from issueTokens.
+func (h *Handler) tokenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrAccountRestricted) {
+		h.writeError(w, http.StatusForbidden, err)
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, err)
+}
+
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -291,7 +303,7 @@ func (h *Handler) AppleSignIn(w http.ResponseWriter, r *http.Request) {
 
 	accessToken, refreshToken, err := h.issueTokens(userID)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, err)
+		h.tokenError(w, err)
 		return
 	}
 
@@ -423,7 +435,7 @@ func (h *Handler) VerifyPhoneOTP(w http.ResponseWriter, r *http.Request) {
 	userID := "phone:" + phone
 	accessToken, refreshToken, err := h.issueTokens(userID)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, err)
+		h.tokenError(w, err)
 		return
 	}
 
@@ -19,9 +19,18 @@ type Claims struct {
 }
 
 // issueTokens creates a new pair of access and refresh JWTs for a given user ID.
+// Suspended and banned accounts get ErrAccountRestricted instead.
 func (h *Handler) issueTokens(userID string) (accessToken string, refreshToken string, err error) {
 	now := time.Now().UTC()
 
+	account, err := h.accounts.Get(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if account.Restricted(now) {
+		return "", "", ErrAccountRestricted
+	}
+
 	accessClaims := Claims{
 		TokenType: "access",
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -2,9 +2,12 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -28,64 +31,147 @@ func UserIDFromContext(ctx context.Context) (string, bool) {
 	return id, ok && id != ""
 }
 
+// ErrNoUser is returned by UserID for requests nobody is signed in to.
+var ErrNoUser = errors.New("missing user context")
+
+// UserID returns the caller JWTUserContextMiddleware identified, or
+// ErrNoUser. Handlers never read identity from the request themselves.
+func UserID(r *http.Request) (string, error) {
+	if uid, ok := UserIDFromContext(r.Context()); ok {
+		return uid, nil
+	}
+	return "", ErrNoUser
+}
+
+// ParseAccessToken verifies an access JWT and returns its subject (user ID).
+func ParseAccessToken(jwtSecret []byte, tokenStr string) (string, error) {
+	claims, err := parseAccessClaims(jwtSecret, tokenStr)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func parseAccessClaims(jwtSecret []byte, tokenStr string) (*Claims, error) {
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.TokenType != "access" {
+		return nil, errors.New("invalid token type")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing subject in token")
+	}
+	return &claims, nil
+}
+
+// DebugUserHeader names the caller in development, in place of a token,
+// when JWTUserContextMiddleware is told to trust it.
+const DebugUserHeader = "X-Debug-UserID"
+
 // JWTUserContextMiddleware parses an Authorization: Bearer <accessToken> header,
 // verifies the JWT, and, on success, attaches the user ID (subject) to the
-// request context. It only attempts this for onboarding routes; other routes
-// pass through untouched.
+// request context. It only attempts this for user-facing /v1 routes; auth
+// endpoints and anything outside /v1 pass through untouched.
+//
+// WebSocket upgrades may pass the token as an access_token query parameter
+// instead, since browser WebSocket clients cannot set headers.
 //
 // If a bearer token is present but invalid, it returns 401. If no bearer token
-// is present, the request is allowed to continue so that legacy/X-Debug flows
-// still work during development.
-func JWTUserContextMiddleware(logger *log.Logger, jwtSecret []byte, next http.Handler) http.Handler {
+// is present, the request continues without a user and handlers that need one
+// answer 401. Only when debugHeader is set, which must never be the case in
+// production, does DebugUserHeader identify such a request instead.
+//
+// Every identified request is also checked against the caller's account:
+// tokens issued before their sessions were revoked get 401 and suspended and
+// banned accounts get 403. /v1/admin/ routes need a verified token for an
+// account with the admin role; a debug identity never counts.
+func JWTUserContextMiddleware(logger *log.Logger, jwtSecret []byte, accounts AccountStore, debugHeader bool,
+	next http.Handler) http.Handler {
 	if logger == nil {
 		logger = log.Default()
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Only care about onboarding paths for now.
-		if !strings.HasPrefix(r.URL.Path, "/v1/onboarding/") {
+		// Sign-in endpoints are how clients obtain a token in the first place.
+		if !strings.HasPrefix(r.URL.Path, "/v1/") || strings.HasPrefix(r.URL.Path, "/v1/auth/") {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		authz := r.Header.Get("Authorization")
-		if authz == "" {
-			// Fall back to X-Debug-UserID mechanism in onboarding handlers.
-			next.ServeHTTP(w, r)
-			return
+		var tokenStr string
+		if authz := r.Header.Get("Authorization"); authz != "" {
+			parts := strings.SplitN(authz, " ", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+				http.Error(w, "invalid Authorization header", http.StatusUnauthorized)
+				return
+			}
+			tokenStr = parts[1]
+		} else if isWebSocketUpgrade(r) {
+			tokenStr = r.URL.Query().Get("access_token")
 		}
 
-		parts := strings.SplitN(authz, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			http.Error(w, "invalid Authorization header", http.StatusUnauthorized)
+		admin := strings.HasPrefix(r.URL.Path, "/v1/admin/")
+		if admin && tokenStr == "" {
+			http.Error(w, "missing access token", http.StatusUnauthorized)
 			return
 		}
 
-		tokenStr := parts[1]
-
-		var claims Claims
-		token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-		if err != nil || !token.Valid {
-			logger.Printf("JWT parse error: %v", err)
-			http.Error(w, "invalid token", http.StatusUnauthorized)
+		var (
+			userID   string
+			issuedAt *jwt.NumericDate
+		)
+		if tokenStr != "" {
+			claims, err := parseAccessClaims(jwtSecret, tokenStr)
+			if err != nil {
+				logger.Printf("JWT parse error: %v", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			userID, issuedAt = claims.Subject, claims.IssuedAt
+		} else if debugHeader {
+			userID = r.Header.Get(DebugUserHeader)
+		}
+		if userID == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		if claims.TokenType != "access" {
-			http.Error(w, "invalid token type", http.StatusUnauthorized)
+		a, err := accounts.Get(userID)
+		if err != nil {
+			logger.Printf("account lookup error: %v", err)
+			http.Error(w, "failed to load account", http.StatusInternalServerError)
 			return
 		}
-
-		if claims.Subject == "" {
-			http.Error(w, "missing subject in token", http.StatusUnauthorized)
+		if issuedAt != nil && a.Revoked(issuedAt.Time) {
+			http.Error(w, "session revoked", http.StatusUnauthorized)
 			return
 		}
+		if a.Restricted(time.Now()) {
+			http.Error(w, "account "+a.Status, http.StatusForbidden)
+			return
+		}
+		if admin && a.Role != RoleAdmin {
+			http.Error(w, "admin role required", http.StatusForbidden)
+			return
+		}
+		ctx := ContextWithUserID(r.Context(), userID)
+		if issuedAt != nil {
+			// Roles only come with a verified token.
+			ctx = ContextWithRole(ctx, a.Role)
+		}
 
-		ctx := ContextWithUserID(r.Context(), claims.Subject)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
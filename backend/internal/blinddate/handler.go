@@ -0,0 +1,319 @@
+package blinddate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// pollTimeout bounds a long-poll; it must stay well under StaleAfter so a
+// client that polls again straight away is never dropped.
+const pollTimeout = 25 * time.Second
+
+// WebSocket timings, as for the chat socket.
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// Frame and response statuses.
+const (
+	statusWaiting = "waiting"
+	statusPaired  = "paired"
+)
+
+// Handler exposes the blind date queue over long-poll and WebSocket, and
+// the sessions it starts over REST.
+type Handler struct {
+	logger   *log.Logger
+	queue    *Queue
+	sessions *Sessions
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(logger *log.Logger, queue *Queue, sessions *Sessions) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		queue:    queue,
+		sessions: sessions,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Mobile clients don't send an Origin header; see chat.Handler.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// queueResponse is returned by the REST endpoints and sent as WebSocket
+// frames (with Type set).
+type queueResponse struct {
+	Type    string       `json:"type,omitempty"`
+	Status  string       `json:"status"`
+	Pairing *Pairing     `json:"pairing,omitempty"`
+	Session *SessionView `json:"session,omitempty"`
+}
+
+// statusOf describes userID's place in the queue, with the new session
+// once they're paired.
+func (h *Handler) statusOf(userID string, p *Pairing) queueResponse {
+	if p == nil {
+		return queueResponse{Status: statusWaiting}
+	}
+	resp := queueResponse{Status: statusPaired, Pairing: p}
+	if sess, err := h.sessions.Get(p.SessionID, userID); err == nil {
+		resp.Session = h.sessions.View(sess, userID)
+	} else {
+		h.logger.Printf("blinddate: load session for pairing: %v", err)
+	}
+	return resp
+}
+
+// --- Helpers ---
+
+// join adds the user to the queue and writes any error response.
+func (h *Handler) join(w http.ResponseWriter, userID string, opts JoinOptions) (*Pairing, bool) {
+	p, err := h.queue.Join(userID, opts)
+	switch {
+	case errors.Is(err, ErrInvalidAgeRange):
+		httpjson.Error(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrNotOnboarded), errors.Is(err, ErrNoBirthdate), errors.Is(err, ErrSnoozed):
+		httpjson.Error(w, http.StatusConflict, err)
+	case err != nil:
+		h.logger.Printf("blind date join error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to join the queue"))
+	default:
+		return p, true
+	}
+	return nil, false
+}
+
+// parseAgeRange reads minAge/maxAge from the query string for clients that
+// can't send a body, such as WebSocket handshakes.
+func parseAgeRange(r *http.Request) (JoinOptions, error) {
+	var opts JoinOptions
+	for name, dst := range map[string]*int{"minAge": &opts.MinAge, "maxAge": &opts.MaxAge} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New(name + " must be a number")
+		}
+		*dst = n
+	}
+	return opts, nil
+}
+
+// --- Handlers ---
+
+// Queue handles /v1/blind-date/queue
+//
+//	POST   joins (body: {"minAge":25,"maxAge":35}, both optional) and pairs
+//	       immediately when someone compatible is waiting
+//	GET    long-polls for up to 25s until paired; poll again on "waiting"
+//	DELETE leaves the queue
+//
+// A pairing is handed out once; the entry is then removed. Users who stop
+// polling are dropped from the queue after StaleAfter, and straight away if
+// they disconnect mid-poll.
+func (h *Handler) Queue(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var opts JoinOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		p, ok := h.join(w, userID, opts)
+		if !ok {
+			return
+		}
+		if p != nil {
+			h.queue.Collect(userID)
+		}
+		httpjson.Write(w, http.StatusOK, h.statusOf(userID, p))
+
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), pollTimeout)
+		defer cancel()
+		p, err := h.queue.Wait(ctx, userID)
+		if errors.Is(err, ErrNotQueued) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("blind date wait error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to check the queue"))
+			return
+		}
+		if p == nil && r.Context().Err() != nil {
+			// The client hung up rather than the poll timing out.
+			if err := h.queue.Leave(userID); err != nil {
+				h.logger.Printf("blind date leave error: %v", err)
+			}
+			return
+		}
+		if p != nil {
+			h.queue.Collect(userID)
+		}
+		httpjson.Write(w, http.StatusOK, h.statusOf(userID, p))
+
+	case http.MethodDelete:
+		if err := h.queue.Leave(userID); err != nil {
+			h.logger.Printf("blind date leave error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to leave the queue"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// ServeWS handles GET /v1/blind-date/ws?minAge=25&maxAge=35
+//
+// Connecting joins the queue. The server sends {"type":"queue","status":
+// "waiting"} and later {"type":"queue","status":"paired","pairing":{...},
+// "session":{...}}, then closes the socket; the session continues over
+// REST and /v1/ws. Closing it first leaves the queue.
+func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	opts, err := parseAgeRange(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Join before upgrading so errors are plain HTTP responses.
+	p, ok := h.join(w, userID, opts)
+	if !ok {
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote an HTTP error response.
+		h.logger.Printf("blinddate: upgrade error: %v", err)
+		h.leaveUnlessPaired(userID, p)
+		return
+	}
+	defer conn.Close()
+
+	paired := h.serveConn(conn, userID, p)
+	if paired {
+		h.queue.Collect(userID)
+	} else if err := h.queue.Leave(userID); err != nil {
+		h.logger.Printf("blind date leave error: %v", err)
+	}
+}
+
+func (h *Handler) leaveUnlessPaired(userID string, p *Pairing) {
+	if p != nil {
+		return
+	}
+	if err := h.queue.Leave(userID); err != nil {
+		h.logger.Printf("blind date leave error: %v", err)
+	}
+}
+
+// serveConn waits on an upgraded socket until the user is paired or goes
+// away, and reports whether the pairing was delivered.
+func (h *Handler) serveConn(conn *websocket.Conn, userID string, p *Pairing) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client has nothing to say; reading only surfaces pongs and the
+	// close handshake.
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(p *Pairing) bool {
+		frame := h.statusOf(userID, p)
+		frame.Type = "queue"
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return conn.WriteJSON(frame) == nil
+	}
+
+	if p == nil {
+		if !send(nil) {
+			return false
+		}
+		waitCtx, stopWaiting := context.WithCancel(ctx)
+		defer stopWaiting()
+		result := make(chan *Pairing, 1)
+		go func() {
+			p, err := h.queue.Wait(waitCtx, userID)
+			if err != nil && !errors.Is(err, ErrNotQueued) {
+				h.logger.Printf("blind date wait error: %v", err)
+			}
+			result <- p
+		}()
+
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+	wait:
+		for {
+			select {
+			case p = <-result:
+				break wait
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					stopWaiting()
+					<-result
+					return false
+				}
+			}
+		}
+		if p == nil {
+			return false
+		}
+	}
+
+	if !send(p) {
+		return false
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, statusPaired)
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	return true
+}
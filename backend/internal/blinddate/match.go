@@ -0,0 +1,126 @@
+package blinddate
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/discovery"
+	"github.com/rijey/kindl/backend/internal/geo"
+)
+
+// StaleAfter is how long an entry survives without its user being seen.
+// Long-poll clients must poll again within this window.
+const StaleAfter = 35 * time.Second
+
+// radiusSteps widen the search the longer someone has waited, so people in
+// sparse areas still get paired eventually.
+var radiusSteps = []struct {
+	after    time.Duration
+	radiusKm float64
+}{
+	{0, 25},
+	{30 * time.Second, 50},
+	{60 * time.Second, 100},
+	{2 * time.Minute, 250},
+}
+
+// searchRadiusKm returns the distance allowed after waiting for waited.
+func searchRadiusKm(waited time.Duration) float64 {
+	r := radiusSteps[0].radiusKm
+	for _, s := range radiusSteps {
+		if waited >= s.after {
+			r = s.radiusKm
+		}
+	}
+	return r
+}
+
+// widest reports whether waited has reached the last radius step.
+func widest(waited time.Duration) bool {
+	return waited >= radiusSteps[len(radiusSteps)-1].after
+}
+
+// compatible reports whether a newcomer and a waiting entry may be paired.
+// Blocked pairs never are. Preferences must hold in both directions. The distance allowed follows
+// whoever has waited longer; people who haven't shared a location are only
+// paired once that wait reaches the widest step.
+func compatible(newcomer, waiting *Entry, now time.Time) bool {
+	if newcomer.UserID == waiting.UserID {
+		return false
+	}
+	if _, ok := newcomer.Blocked[waiting.UserID]; ok {
+		return false
+	}
+	if _, ok := waiting.Blocked[newcomer.UserID]; ok {
+		return false
+	}
+	if !discovery.AcceptsGender(newcomer.PreferredGenders, waiting.Gender) ||
+		!discovery.AcceptsGender(waiting.PreferredGenders, newcomer.Gender) {
+		return false
+	}
+	if !inAgeRange(newcomer, waiting.Age) || !inAgeRange(waiting, newcomer.Age) {
+		return false
+	}
+
+	waited := now.Sub(earliest(newcomer.JoinedAt, waiting.JoinedAt))
+	if !newcomer.HasLocation || !waiting.HasLocation {
+		return widest(waited)
+	}
+	d := geo.HaversineKm(newcomer.Lat, newcomer.Lng, waiting.Lat, waiting.Lng)
+	return d <= searchRadiusKm(waited)
+}
+
+func inAgeRange(e *Entry, age int) bool {
+	return age >= e.MinAge && age <= e.MaxAge
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// pick returns the index of the longest-waiting entry compatible with
+// newcomer, or -1. Choosing strictly by queue order means nobody can be
+// overtaken indefinitely by later arrivals who'd suit the same newcomer.
+func pick(newcomer *Entry, waiting []*Entry, now time.Time) int {
+	order := make([]int, len(waiting))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return waiting[a].JoinedAt.Compare(waiting[b].JoinedAt)
+	})
+	for _, i := range order {
+		if compatible(newcomer, waiting[i], now) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pairWaiting greedily pairs waiting entries, oldest first, each with the
+// longest-waiting compatible entry behind it.
+func pairWaiting(waiting []*Entry, now time.Time) [][2]*Entry {
+	sorted := slices.Clone(waiting)
+	slices.SortStableFunc(sorted, func(a, b *Entry) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+
+	var pairs [][2]*Entry
+	taken := make([]bool, len(sorted))
+	for i, a := range sorted {
+		if taken[i] {
+			continue
+		}
+		for j := i + 1; j < len(sorted); j++ {
+			if !taken[j] && compatible(a, sorted[j], now) {
+				taken[i], taken[j] = true, true
+				pairs = append(pairs, [2]*Entry{a, sorted[j]})
+				break
+			}
+		}
+	}
+	return pairs
+}
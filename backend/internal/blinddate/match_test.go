@@ -0,0 +1,136 @@
+package blinddate
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type noBlocks struct{}
+
+func (noBlocks) ExcludedUserIDs(string) (map[string]struct{}, error) { return nil, nil }
+
+// entry is a 30-year-old in Berlin looking for anyone aged 25-35 who joined
+// at joined.
+func entry(userID, gender string, joined time.Time) Entry {
+	return Entry{
+		UserID:           userID,
+		Gender:           gender,
+		PreferredGenders: []string{"women", "men"},
+		Age:              30,
+		MinAge:           25,
+		MaxAge:           35,
+		Lat:              52.52,
+		Lng:              13.40,
+		HasLocation:      true,
+		JoinedAt:         joined,
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		edit    func(newcomer, waiting *Entry)
+		waitFor time.Duration
+		want    bool
+	}{
+		{"match", func(n, w *Entry) {}, 0, true},
+		{"same user", func(n, w *Entry) { w.UserID = n.UserID }, 0, false},
+		{"blocked by newcomer", func(n, w *Entry) { n.Blocked = map[string]struct{}{w.UserID: {}} }, 0, false},
+		{"blocked by waiting", func(n, w *Entry) { w.Blocked = map[string]struct{}{n.UserID: {}} }, 0, false},
+		{"newcomer's gender preference", func(n, w *Entry) { n.PreferredGenders = []string{"women"}; w.Gender = "male" }, 0, false},
+		{"waiting's gender preference", func(n, w *Entry) { w.PreferredGenders = []string{"men"}; n.Gender = "female" }, 0, false},
+		{"too old for newcomer", func(n, w *Entry) { w.Age = 40 }, 0, false},
+		{"too young for waiting", func(n, w *Entry) { n.Age = 22 }, 0, false},
+		{"40km apart, just joined", func(n, w *Entry) { w.Lat += 0.36 }, 0, false},
+		{"40km apart, after 30s", func(n, w *Entry) { w.Lat += 0.36 }, 30 * time.Second, true},
+		{"no location, just joined", func(n, w *Entry) { w.HasLocation = false }, time.Minute, false},
+		{"no location, at the widest step", func(n, w *Entry) { w.HasLocation = false }, 2 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waiting := entry("w", "female", now.Add(-tt.waitFor))
+			newcomer := entry("n", "male", now)
+			tt.edit(&newcomer, &waiting)
+			if got := compatible(&newcomer, &waiting, now); got != tt.want {
+				t.Errorf("compatible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinPairsLongestWaiting(t *testing.T) {
+	s := NewInMemoryStore(noBlocks{})
+	now := time.Now()
+	for i, id := range []string{"w1", "w2"} {
+		// Both are only looking for men, so they wait for one.
+		e := entry(id, "female", now.Add(time.Duration(i-2)*time.Second))
+		e.PreferredGenders = []string{"men"}
+		if p, err := s.Join(e, now); err != nil || p != nil {
+			t.Fatalf("join %s: %+v, %v; want them waiting", id, p, err)
+		}
+	}
+
+	p, err := s.Join(entry("m", "male", now), now)
+	if err != nil || p == nil {
+		t.Fatalf("join m: %+v, %v; want a pairing", p, err)
+	}
+	if p.Partner("m") != "w1" {
+		t.Errorf("m was paired with %s, want w1", p.Partner("m"))
+	}
+	if got, _ := s.Status("w1"); got == nil || got.SessionID != p.SessionID {
+		t.Errorf("w1 holds %+v, want the pairing", got)
+	}
+	if got, _ := s.Status("w2"); got != nil {
+		t.Errorf("w2 holds %+v, want them still waiting", got)
+	}
+}
+
+// However many people join at once, everyone ends up in at most one
+// pairing, and only one person is left waiting at the end.
+func TestConcurrentJoins(t *testing.T) {
+	s := NewInMemoryStore(noBlocks{})
+	now := time.Now()
+	const n = 41
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Join(entry(fmt.Sprintf("u%02d", i), "female", now), now); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	partners := map[string]string{}
+	waiting := 0
+	for i := range n {
+		id := fmt.Sprintf("u%02d", i)
+		p, err := s.Status(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p == nil {
+			waiting++
+			continue
+		}
+		partner := p.Partner(id)
+		if partner == id {
+			t.Errorf("%s was paired with themselves", id)
+		}
+		partners[id] = partner
+	}
+	for id, partner := range partners {
+		if partners[partner] != id {
+			t.Errorf("%s is paired with %s, who is paired with %s", id, partner, partners[partner])
+		}
+	}
+	if waiting != 1 {
+		t.Errorf("%d people left waiting, want 1", waiting)
+	}
+}
@@ -0,0 +1,311 @@
+package blinddate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/discovery"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/pubsub"
+)
+
+// Age range defaults and limits for JoinOptions.
+const (
+	MinAge = 18
+	MaxAge = 99
+)
+
+// sweepInterval is how often Run refreshes local waiters, drops stale
+// entries and retries pairing with widened radii.
+const sweepInterval = 5 * time.Second
+
+// topicPaired carries pairings to the instance holding each user's
+// connection or long-poll.
+const topicPaired = "blinddate.paired"
+
+var (
+	// ErrNotOnboarded is returned by Join for profiles that haven't
+	// completed onboarding.
+	ErrNotOnboarded = errors.New("complete onboarding before joining blind dates")
+	// ErrNoBirthdate is returned by Join when the profile has no age.
+	ErrNoBirthdate = errors.New("a birthdate is required for blind dates")
+	// ErrInvalidAgeRange is returned by Join for out-of-range options.
+	ErrInvalidAgeRange = errors.New("age range must be within 18-99 with minAge <= maxAge")
+	// ErrSnoozed is returned by Join while the user is snoozed.
+	ErrSnoozed = errors.New("wake up from snooze before joining blind dates")
+)
+
+// JoinOptions are the per-session preferences sent when opting in.
+type JoinOptions struct {
+	MinAge int `json:"minAge"`
+	MaxAge int `json:"maxAge"`
+}
+
+// Queue runs matchmaking on top of a Store and tells waiting users when
+// they've been paired, wherever they are connected.
+type Queue struct {
+	logger   *log.Logger
+	store    Store
+	profiles onboarding.Store
+	bus      pubsub.Bus
+	sessions *Sessions
+
+	mu       sync.Mutex
+	watchers map[string]map[chan *Pairing]struct{}
+
+	unsubscribe func()
+}
+
+// NewQueue returns a Queue subscribed to bus. Every pairing it makes opens
+// a session in sessions.
+func NewQueue(logger *log.Logger, store Store, profiles onboarding.Store, bus pubsub.Bus, sessions *Sessions) *Queue {
+	if logger == nil {
+		logger = log.Default()
+	}
+	q := &Queue{
+		logger:   logger,
+		store:    store,
+		profiles: profiles,
+		bus:      bus,
+		sessions: sessions,
+		watchers: make(map[string]map[chan *Pairing]struct{}),
+	}
+	q.unsubscribe = bus.Subscribe(topicPaired, q.receive)
+	return q
+}
+
+// pairedMessage is a Pairing on the wire; Pairing hides its users from
+// JSON responses.
+type pairedMessage struct {
+	SessionID string    `json:"sessionId"`
+	UserIDs   [2]string `json:"userIds"`
+	PairedAt  time.Time `json:"pairedAt"`
+}
+
+// Join puts userID in the queue, pairing them straight away when someone
+// compatible is waiting.
+func (q *Queue) Join(userID string, opts JoinOptions) (*Pairing, error) {
+	if opts.MinAge == 0 {
+		opts.MinAge = MinAge
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = MaxAge
+	}
+	if opts.MinAge < MinAge || opts.MaxAge > MaxAge || opts.MinAge > opts.MaxAge {
+		return nil, ErrInvalidAgeRange
+	}
+
+	p, err := q.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, ErrNotOnboarded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.OnboardedAt == nil {
+		return nil, ErrNotOnboarded
+	}
+	now := time.Now().UTC()
+	if p.Snoozed(now) {
+		return nil, ErrSnoozed
+	}
+	if _, err := q.status(userID); err != nil && !errors.Is(err, ErrNotQueued) {
+		return nil, err
+	}
+	age, ok := discovery.Age(p, now)
+	if !ok {
+		return nil, ErrNoBirthdate
+	}
+
+	pairing, err := q.store.Join(Entry{
+		UserID:           userID,
+		Gender:           p.Gender,
+		PreferredGenders: p.PreferredGenders,
+		Age:              age,
+		MinAge:           opts.MinAge,
+		MaxAge:           opts.MaxAge,
+		Lat:              p.Lat,
+		Lng:              p.Lng,
+		HasLocation:      discovery.HasLocation(p),
+		JoinedAt:         now,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if pairing != nil {
+		if err := q.sessions.Start(pairing); err != nil {
+			return nil, err
+		}
+		q.announce(pairing)
+	}
+	return pairing, nil
+}
+
+// Wait blocks until userID is paired or ctx ends, returning a nil pairing
+// in the latter case. ErrNotQueued if the user isn't in the queue.
+func (q *Queue) Wait(ctx context.Context, userID string) (*Pairing, error) {
+	ch, stop := q.watch(userID)
+	defer stop()
+
+	// Check after watching so a pairing made in between isn't missed.
+	p, err := q.status(userID)
+	if err != nil || p != nil {
+		return p, err
+	}
+	select {
+	case p := <-ch:
+		return p, nil
+	case <-ctx.Done():
+		return nil, nil
+	}
+}
+
+// status is Store.Status, except that a pairing whose session has already
+// ended is dropped rather than handed out: its user never collected it and
+// the session ran out without them.
+func (q *Queue) status(userID string) (*Pairing, error) {
+	p, err := q.store.Status(userID)
+	if err != nil || p == nil {
+		return p, err
+	}
+	live, err := q.sessions.live(p.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if live {
+		return p, nil
+	}
+	if err := q.store.Leave(userID); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotQueued
+}
+
+// Collect removes a delivered pairing's entry from the queue.
+func (q *Queue) Collect(userID string) {
+	if err := q.store.Leave(userID); err != nil {
+		q.logger.Printf("blinddate: collect: %v", err)
+	}
+}
+
+// Leave drops userID from the queue.
+func (q *Queue) Leave(userID string) error {
+	return q.store.Leave(userID)
+}
+
+// watch registers a channel that receives userID's next pairing.
+func (q *Queue) watch(userID string) (<-chan *Pairing, func()) {
+	ch := make(chan *Pairing, 1)
+	q.mu.Lock()
+	set, ok := q.watchers[userID]
+	if !ok {
+		set = make(map[chan *Pairing]struct{})
+		q.watchers[userID] = set
+	}
+	set[ch] = struct{}{}
+	q.mu.Unlock()
+
+	return ch, func() {
+		q.mu.Lock()
+		delete(set, ch)
+		if len(set) == 0 {
+			delete(q.watchers, userID)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// announce publishes a pairing so both users' instances wake their waiters.
+func (q *Queue) announce(p *Pairing) {
+	payload, err := json.Marshal(pairedMessage{SessionID: p.SessionID, UserIDs: p.UserIDs, PairedAt: p.PairedAt})
+	if err != nil {
+		q.logger.Printf("blinddate: marshal pairing: %v", err)
+		return
+	}
+	if err := q.bus.Publish(topicPaired, payload); err != nil {
+		// Waiters elsewhere still find it on their next Status check.
+		q.logger.Printf("blinddate: publish pairing: %v", err)
+		q.notifyLocal(p)
+	}
+}
+
+func (q *Queue) receive(payload []byte) {
+	var msg pairedMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		q.logger.Printf("blinddate: bad pairing message: %v", err)
+		return
+	}
+	q.notifyLocal(&Pairing{SessionID: msg.SessionID, UserIDs: msg.UserIDs, PairedAt: msg.PairedAt})
+}
+
+func (q *Queue) notifyLocal(p *Pairing) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, id := range p.UserIDs {
+		for ch := range q.watchers[id] {
+			select {
+			case ch <- p:
+			default:
+			}
+		}
+	}
+}
+
+// localUsers lists users with a connection or long-poll on this instance.
+func (q *Queue) localUsers() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]string, 0, len(q.watchers))
+	for id := range q.watchers {
+		out = append(out, id)
+	}
+	return out
+}
+
+// Run keeps the queue healthy until ctx is cancelled: it marks users still
+// connected here as seen, drops entries whose users went away, and pairs
+// people whose search radius has widened since they joined.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.sweep(time.Now().UTC())
+		}
+	}
+}
+
+func (q *Queue) sweep(now time.Time) {
+	if err := q.store.Touch(q.localUsers(), now); err != nil {
+		q.logger.Printf("blinddate: touch: %v", err)
+	}
+	if dropped, err := q.store.ExpireStale(now.Add(-StaleAfter)); err != nil {
+		q.logger.Printf("blinddate: expire: %v", err)
+	} else if len(dropped) > 0 {
+		q.logger.Printf("blinddate: dropped %d stale queue entries", len(dropped))
+	}
+	pairings, err := q.store.Rematch(now)
+	if err != nil {
+		q.logger.Printf("blinddate: rematch: %v", err)
+		return
+	}
+	for _, p := range pairings {
+		if err := q.sessions.Start(p); err != nil {
+			q.logger.Printf("blinddate: start session: %v", err)
+			continue
+		}
+		q.announce(p)
+	}
+}
+
+// Close unsubscribes from the bus.
+func (q *Queue) Close() {
+	q.unsubscribe()
+}
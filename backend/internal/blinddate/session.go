@@ -0,0 +1,143 @@
+package blinddate
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Session lifecycle. A session is active while the chat timer runs, then
+// deciding until DecideBy. Mutual sparks make it matched; a pass or running
+// out of time tears it down, messages included.
+const (
+	SessionActive   = "active"
+	SessionDeciding = "deciding"
+	SessionMatched  = "matched"
+	SessionEnded    = "ended"
+)
+
+// Session timing defaults. DefaultChatDuration mirrors the app's
+// CHAT_DURATION.
+const (
+	DefaultChatDuration   = 30 * time.Second
+	DefaultDecisionWindow = 60 * time.Second
+)
+
+// maxSessionText caps one blind date message, in characters.
+const maxSessionText = 1000
+
+var (
+	// ErrSessionNotFound is returned for unknown sessions and to users who
+	// aren't in the session.
+	ErrSessionNotFound = errors.New("blind date session not found")
+	// ErrChatOver is returned when sending after the chat timer ran out.
+	ErrChatOver = errors.New("the chat time is over")
+	// ErrSessionOver is returned when deciding after the session resolved.
+	ErrSessionOver = errors.New("the blind date is over")
+)
+
+// Session is a timed, anonymous chat between two paired users. Index i of
+// UserIDs, Aliases and Sparks refers to the same participant.
+type Session struct {
+	ID        string
+	UserIDs   [2]string
+	Aliases   [2]string
+	Sparks    [2]*bool
+	State     string
+	StartedAt time.Time
+	EndsAt    time.Time
+	DecideBy  time.Time
+	MatchID   string
+}
+
+// index returns userID's position in the session, or -1.
+func (s *Session) index(userID string) int {
+	switch userID {
+	case s.UserIDs[0]:
+		return 0
+	case s.UserIDs[1]:
+		return 1
+	}
+	return -1
+}
+
+// Has reports whether userID takes part in the session.
+func (s *Session) Has(userID string) bool {
+	return s.index(userID) >= 0
+}
+
+// Open reports whether the session can still be decided at now.
+func (s *Session) Open(now time.Time) bool {
+	return (s.State == SessionActive || s.State == SessionDeciding) && now.Before(s.DecideBy)
+}
+
+// mutualSpark reports whether both participants sparked.
+func (s *Session) mutualSpark() bool {
+	return s.Sparks[0] != nil && *s.Sparks[0] && s.Sparks[1] != nil && *s.Sparks[1]
+}
+
+// SessionMessage is one chat line in a session.
+type SessionMessage struct {
+	ID        string
+	SessionID string
+	SenderID  string
+	ClientID  string
+	Text      string
+	SentAt    time.Time
+}
+
+// SessionStore persists blind date sessions and their messages.
+type SessionStore interface {
+	// Create stores a new session. Creating an existing ID is a no-op.
+	Create(s *Session) error
+	// Get returns a session, or ErrSessionNotFound.
+	Get(id string) (*Session, error)
+	// AppendMessage stores a message; a retry with the same sender and
+	// client ID returns the original with created=false.
+	// ErrSessionNotFound if the session was torn down.
+	AppendMessage(m SessionMessage) (stored *SessionMessage, created bool, err error)
+	// Messages returns a session's messages, oldest first.
+	Messages(sessionID string) ([]SessionMessage, error)
+	// Decide records userID's spark or pass and returns the updated
+	// session. ErrSessionOver if the session isn't Open at now; a decision
+	// can't be changed once made.
+	Decide(sessionID, userID string, spark bool, now time.Time) (*Session, error)
+	// Transition moves a session from one of the given states to state,
+	// recording matchID when set. It reports false if the session wasn't in
+	// any of them, so only one caller acts on each transition.
+	Transition(sessionID string, from []string, state, matchID string) (bool, error)
+	// Due returns active sessions whose chat ended by now and open sessions
+	// whose decision window closed.
+	Due(now time.Time) ([]Session, error)
+	// Delete removes a session and its messages, reporting whether it
+	// existed.
+	Delete(sessionID string) (bool, error)
+	// DeleteUser removes every session userID took part in, with its
+	// messages.
+	DeleteUser(userID string) error
+}
+
+// Aliases are built from these lists so participants can tell each other
+// apart without learning who they are.
+var (
+	aliasAdjectives = []string{
+		"Amber", "Azure", "Bright", "Calm", "Coral", "Gentle", "Golden", "Hazel",
+		"Ivory", "Jade", "Lunar", "Misty", "Quiet", "Sage", "Silver", "Velvet",
+	}
+	aliasNouns = []string{
+		"Comet", "Fern", "Finch", "Fox", "Harbor", "Heron", "Lark", "Maple",
+		"Meadow", "Otter", "River", "Robin", "Sparrow", "Tide", "Willow", "Wren",
+	}
+)
+
+// newAliases returns two different random aliases.
+func newAliases() [2]string {
+	pick := func() string {
+		return aliasAdjectives[rand.IntN(len(aliasAdjectives))] + " " + aliasNouns[rand.IntN(len(aliasNouns))]
+	}
+	a, b := pick(), pick()
+	for a == b {
+		b = pick()
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,159 @@
+package blinddate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// maxClientIDLength bounds client-generated message IDs.
+const maxClientIDLength = 64
+
+type sendSessionMessageRequest struct {
+	ClientID string `json:"clientId"`
+	Text     string `json:"text"`
+}
+
+type decisionRequest struct {
+	Spark *bool `json:"spark"`
+}
+
+// sessionError writes the response for a session lookup or action error.
+func (h *Handler) sessionError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		httpjson.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrChatOver), errors.Is(err, ErrSessionOver):
+		httpjson.Error(w, http.StatusConflict, err)
+	case screening.IsBlocked(err):
+		httpjson.Error(w, http.StatusUnprocessableEntity, err)
+	default:
+		h.logger.Printf("blind date %s error: %v", action, err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to "+action))
+	}
+}
+
+// Session handles GET /v1/blind-date/sessions/{id}
+//
+// Participants see each other only by alias until both spark. endsAt and
+// decideBy are authoritative; serverTime lets clients correct for clock
+// skew when showing the countdown.
+func (h *Handler) Session(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	sess, err := h.sessions.Get(r.PathValue("id"), userID)
+	if err != nil {
+		h.sessionError(w, err, "load session")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"session": h.sessions.View(sess, userID)})
+}
+
+// SessionMessages handles /v1/blind-date/sessions/{id}/messages
+//
+//	GET  lists the chat so far, oldest first
+//	POST sends {"clientId":"...","text":"..."} while the chat timer runs;
+//	     201 when stored, 200 for a retry of the same clientId
+//
+// New messages also arrive as blinddate.message events on /v1/ws.
+func (h *Handler) SessionMessages(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	sessionID := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		msgs, err := h.sessions.Messages(sessionID, userID)
+		if err != nil {
+			h.sessionError(w, err, "load messages")
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"messages": msgs})
+
+	case http.MethodPost:
+		var req sendSessionMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		text := strings.TrimSpace(req.Text)
+		switch {
+		case req.ClientID == "":
+			httpjson.Error(w, http.StatusBadRequest, errors.New("clientId is required"))
+			return
+		case len(req.ClientID) > maxClientIDLength:
+			httpjson.Error(w, http.StatusBadRequest, errors.New("clientId is too long"))
+			return
+		case text == "":
+			httpjson.Error(w, http.StatusBadRequest, errors.New("text is required"))
+			return
+		case utf8.RuneCountInString(text) > maxSessionText:
+			httpjson.Error(w, http.StatusBadRequest, errors.New("message is too long"))
+			return
+		}
+
+		msg, created, err := h.sessions.Send(r.Context(), sessionID, userID, req.ClientID, text)
+		if err != nil {
+			h.sessionError(w, err, "send message")
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		httpjson.Write(w, status, map[string]any{"message": msg})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// Decision handles POST /v1/blind-date/sessions/{id}/decision
+//
+// Body: {"spark":true} or {"spark":false}. Decisions are final. A pass
+// ends the blind date for both; when both spark, the session becomes a
+// match, identities are revealed and the chat carries over to the new
+// conversation. Undecided sessions are torn down at decideBy.
+func (h *Handler) Decision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	var req decisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Spark == nil {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("spark is required"))
+		return
+	}
+
+	sess, err := h.sessions.Decide(r.PathValue("id"), userID, *req.Spark)
+	if err != nil {
+		h.sessionError(w, err, "record decision")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"session": h.sessions.View(sess, userID)})
+}
@@ -0,0 +1,139 @@
+package blinddate
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	messages map[string][]SessionMessage
+}
+
+// NewInMemorySessionStore returns a SessionStore for single-instance
+// deployments.
+func NewInMemorySessionStore() SessionStore {
+	return &memorySessionStore{
+		sessions: make(map[string]*Session),
+		messages: make(map[string][]SessionMessage),
+	}
+}
+
+func copySession(s *Session) *Session {
+	out := *s
+	for i, spark := range s.Sparks {
+		if spark != nil {
+			v := *spark
+			out.Sparks[i] = &v
+		}
+	}
+	return &out
+}
+
+func (s *memorySessionStore) Create(in *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[in.ID]; !ok {
+		s.sessions[in.ID] = copySession(in)
+	}
+	return nil
+}
+
+func (s *memorySessionStore) Get(id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return copySession(sess), nil
+}
+
+func (s *memorySessionStore) AppendMessage(m SessionMessage) (*SessionMessage, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[m.SessionID]; !ok {
+		return nil, false, ErrSessionNotFound
+	}
+	for _, existing := range s.messages[m.SessionID] {
+		if m.ClientID != "" && existing.SenderID == m.SenderID && existing.ClientID == m.ClientID {
+			return &existing, false, nil
+		}
+	}
+	s.messages[m.SessionID] = append(s.messages[m.SessionID], m)
+	return &m, true, nil
+}
+
+func (s *memorySessionStore) Messages(sessionID string) ([]SessionMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages[sessionID]), nil
+}
+
+func (s *memorySessionStore) Decide(sessionID, userID string, spark bool, now time.Time) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[sessionID]
+	if !ok || !sess.Has(userID) {
+		return nil, ErrSessionNotFound
+	}
+	if !sess.Open(now) {
+		return nil, ErrSessionOver
+	}
+	i := sess.index(userID)
+	if sess.Sparks[i] == nil {
+		sess.Sparks[i] = &spark
+	}
+	return copySession(sess), nil
+}
+
+func (s *memorySessionStore) Transition(sessionID string, from []string, state, matchID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[sessionID]
+	if !ok || !slices.Contains(from, sess.State) {
+		return false, nil
+	}
+	sess.State = state
+	if matchID != "" {
+		sess.MatchID = matchID
+	}
+	return true, nil
+}
+
+func (s *memorySessionStore) Due(now time.Time) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Session
+	for _, sess := range s.sessions {
+		timeUp := sess.State == SessionActive && !now.Before(sess.EndsAt)
+		closed := (sess.State == SessionActive || sess.State == SessionDeciding) && !now.Before(sess.DecideBy)
+		if timeUp || closed {
+			out = append(out, *copySession(sess))
+		}
+	}
+	return out, nil
+}
+
+func (s *memorySessionStore) Delete(sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	delete(s.messages, sessionID)
+	return ok, nil
+}
+
+func (s *memorySessionStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sess := range s.sessions {
+		if sess.Has(userID) {
+			delete(s.sessions, id)
+			delete(s.messages, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package blinddate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pgSessionStore keeps sessions in blind_date_sessions and
+// blind_date_messages (sql/0013_blind_date_sessions.sql).
+type pgSessionStore struct {
+	db *sql.DB
+}
+
+// NewPGSessionStore constructs a SessionStore backed by Postgres.
+func NewPGSessionStore(db *sql.DB) SessionStore {
+	return &pgSessionStore{db: db}
+}
+
+const sessionColumns = `id, user_a_id, user_b_id, alias_a, alias_b, spark_a, spark_b, state,
+	started_at, ends_at, decide_by, COALESCE(match_id, '')`
+
+func scanSession(row interface{ Scan(...any) error }) (*Session, error) {
+	var (
+		s              Session
+		sparkA, sparkB sql.NullBool
+	)
+	if err := row.Scan(&s.ID, &s.UserIDs[0], &s.UserIDs[1], &s.Aliases[0], &s.Aliases[1],
+		&sparkA, &sparkB, &s.State, &s.StartedAt, &s.EndsAt, &s.DecideBy, &s.MatchID); err != nil {
+		return nil, err
+	}
+	for i, v := range []sql.NullBool{sparkA, sparkB} {
+		if v.Valid {
+			b := v.Bool
+			s.Sparks[i] = &b
+		}
+	}
+	return &s, nil
+}
+
+func (s *pgSessionStore) Create(in *Session) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO blind_date_sessions (id, user_a_id, user_b_id, alias_a, alias_b, state, started_at, ends_at, decide_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO NOTHING
+	`, in.ID, in.UserIDs[0], in.UserIDs[1], in.Aliases[0], in.Aliases[1], in.State,
+		in.StartedAt, in.EndsAt, in.DecideBy)
+	return err
+}
+
+func (s *pgSessionStore) Get(id string) (*Session, error) {
+	sess, err := scanSession(s.db.QueryRowContext(context.Background(),
+		`SELECT `+sessionColumns+` FROM blind_date_sessions WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	return sess, err
+}
+
+func (s *pgSessionStore) AppendMessage(m SessionMessage) (*SessionMessage, bool, error) {
+	ctx := context.Background()
+	// The session row is locked so a concurrent teardown can't slip in
+	// between the existence check and the insert.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT true FROM blind_date_sessions WHERE id = $1 FOR SHARE`, m.SessionID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO blind_date_messages (id, session_id, sender_id, client_id, body, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (session_id, sender_id, client_id) DO NOTHING
+	`, m.ID, m.SessionID, m.SenderID, m.ClientID, m.Text, m.SentAt)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return &m, true, tx.Commit()
+	}
+
+	var existing SessionMessage
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id, session_id, sender_id, client_id, body, sent_at FROM blind_date_messages
+		WHERE session_id = $1 AND sender_id = $2 AND client_id = $3
+	`, m.SessionID, m.SenderID, m.ClientID).Scan(&existing.ID, &existing.SessionID,
+		&existing.SenderID, &existing.ClientID, &existing.Text, &existing.SentAt); err != nil {
+		return nil, false, err
+	}
+	return &existing, false, tx.Commit()
+}
+
+func (s *pgSessionStore) Messages(sessionID string) ([]SessionMessage, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT id, session_id, sender_id, client_id, body, sent_at FROM blind_date_messages
+		WHERE session_id = $1 ORDER BY sent_at, id
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SessionMessage
+	for rows.Next() {
+		var m SessionMessage
+		if err := rows.Scan(&m.ID, &m.SessionID, &m.SenderID, &m.ClientID, &m.Text, &m.SentAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgSessionStore) Decide(sessionID, userID string, spark bool, now time.Time) (*Session, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sess, err := scanSession(tx.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM blind_date_sessions WHERE id = $1 FOR UPDATE`, sessionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	i := sess.index(userID)
+	if i < 0 {
+		return nil, ErrSessionNotFound
+	}
+	if !sess.Open(now) {
+		return nil, ErrSessionOver
+	}
+	if sess.Sparks[i] != nil {
+		return sess, tx.Commit()
+	}
+
+	column := "spark_a"
+	if i == 1 {
+		column = "spark_b"
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE blind_date_sessions SET `+column+` = $2 WHERE id = $1`, sessionID, spark); err != nil {
+		return nil, err
+	}
+	sess.Sparks[i] = &spark
+	return sess, tx.Commit()
+}
+
+func (s *pgSessionStore) Transition(sessionID string, from []string, state, matchID string) (bool, error) {
+	args := []any{sessionID, state, matchID}
+	ph := placeholders(len(args)+1, len(from))
+	for _, f := range from {
+		args = append(args, f)
+	}
+	res, err := s.db.ExecContext(context.Background(), `
+		UPDATE blind_date_sessions
+		SET state = $2, match_id = COALESCE(NULLIF($3, ''), match_id)
+		WHERE id = $1 AND state IN (`+ph+`)
+	`, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *pgSessionStore) Due(now time.Time) ([]Session, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+sessionColumns+` FROM blind_date_sessions
+		WHERE (state = 'active' AND ends_at <= $1)
+		   OR (state IN ('active', 'deciding') AND decide_by <= $1)
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Session
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sess)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgSessionStore) Delete(sessionID string) (bool, error) {
+	res, err := s.db.ExecContext(context.Background(), `DELETE FROM blind_date_sessions WHERE id = $1`, sessionID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *pgSessionStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM blind_date_sessions WHERE user_a_id = $1 OR user_b_id = $1
+	`, userID)
+	return err
+}
+
+// placeholders returns "$from, $from+1, ..." for n arguments.
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = "$" + strconv.Itoa(from+i)
+	}
+	return strings.Join(ps, ", ")
+}
@@ -0,0 +1,413 @@
+package blinddate
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// sessionTick is how often Run checks for timers that ran out. It bounds
+// how late a time-up or teardown event can be.
+const sessionTick = time.Second
+
+// Real-time events, delivered over the chat socket (/v1/ws).
+const (
+	EventSessionMessage = "blinddate.message"
+	EventSessionTimeUp  = "blinddate.timeup"
+	EventSessionMatched = "blinddate.matched"
+	EventSessionEnded   = "blinddate.ended"
+)
+
+// Reasons sent with EventSessionEnded. None says who passed or left.
+const (
+	endedNoSpark = "no_mutual_spark"
+	endedExpired = "expired"
+	endedLeft    = "left"
+)
+
+// Notifier delivers frames to users' live connections; *chat.Hub
+// implements it.
+type Notifier interface {
+	SendFrame(v any, userIDs ...string)
+}
+
+// SessionView is a session as one participant sees it. The partner is
+// only identified by alias, and their decision is never shown, until both
+// sparked.
+type SessionView struct {
+	ID           string           `json:"id"`
+	State        string           `json:"state"`
+	Alias        string           `json:"alias"`
+	PartnerAlias string           `json:"partnerAlias"`
+	StartedAt    time.Time        `json:"startedAt"`
+	EndsAt       time.Time        `json:"endsAt"`
+	DecideBy     time.Time        `json:"decideBy"`
+	ServerTime   time.Time        `json:"serverTime"`
+	Spark        *bool            `json:"spark,omitempty"`
+	MatchID      string           `json:"matchId,omitempty"`
+	Partner      *RevealedPartner `json:"partner,omitempty"`
+}
+
+// RevealedPartner identifies the partner once a session turned into a
+// match.
+type RevealedPartner struct {
+	UserID      string     `json:"userId"`
+	DisplayName string     `json:"displayName"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// MessageView is a session message without its sender's identity.
+type MessageView struct {
+	ID          string    `json:"id"`
+	ClientID    string    `json:"clientId,omitempty"`
+	SenderAlias string    `json:"senderAlias"`
+	Mine        bool      `json:"mine"`
+	Text        string    `json:"text"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// sessionEvent is the frame for every blind date event.
+type sessionEvent struct {
+	Type      string       `json:"type"`
+	SessionID string       `json:"sessionId"`
+	Session   *SessionView `json:"session,omitempty"`
+	Message   *MessageView `json:"message,omitempty"`
+	Reason    string       `json:"reason,omitempty"`
+}
+
+// Sessions runs blind dates from pairing to either a match or teardown.
+// Every deadline is enforced here; clients only display the timers.
+type Sessions struct {
+	logger         *log.Logger
+	store          SessionStore
+	matches        matches.Store
+	messages       chat.MessageStore
+	profiles       onboarding.Store
+	notifier       Notifier
+	screener       *screening.Screener
+	chatDuration   time.Duration
+	decisionWindow time.Duration
+}
+
+// NewSessions returns a session service. Zero durations use
+// DefaultChatDuration and DefaultDecisionWindow.
+func NewSessions(logger *log.Logger, store SessionStore, matchStore matches.Store, messages chat.MessageStore,
+	profiles onboarding.Store, notifier Notifier, screener *screening.Screener, chatDuration, decisionWindow time.Duration) *Sessions {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if chatDuration <= 0 {
+		chatDuration = DefaultChatDuration
+	}
+	if decisionWindow <= 0 {
+		decisionWindow = DefaultDecisionWindow
+	}
+	return &Sessions{
+		logger:         logger,
+		store:          store,
+		matches:        matchStore,
+		messages:       messages,
+		profiles:       profiles,
+		notifier:       notifier,
+		screener:       screener,
+		chatDuration:   chatDuration,
+		decisionWindow: decisionWindow,
+	}
+}
+
+// Start opens the session for a pairing. The chat timer starts at the
+// moment of pairing, for both users alike.
+func (s *Sessions) Start(p *Pairing) error {
+	endsAt := p.PairedAt.Add(s.chatDuration)
+	return s.store.Create(&Session{
+		ID:        p.SessionID,
+		UserIDs:   p.UserIDs,
+		Aliases:   newAliases(),
+		State:     SessionActive,
+		StartedAt: p.PairedAt,
+		EndsAt:    endsAt,
+		DecideBy:  endsAt.Add(s.decisionWindow),
+	})
+}
+
+// Get returns a session userID takes part in.
+func (s *Sessions) Get(sessionID, userID string) (*Session, error) {
+	sess, err := s.store.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !sess.Has(userID) {
+		return nil, ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+// Partner returns the user on the other side of a session userID takes
+// part in. It's for server-side use only, such as safety actions; the
+// identity must not reach userID before a match.
+func (s *Sessions) Partner(sessionID, userID string) (string, error) {
+	sess, err := s.Get(sessionID, userID)
+	if err != nil {
+		return "", err
+	}
+	return sess.UserIDs[1-sess.index(userID)], nil
+}
+
+// End tears down a running session on userID's behalf, for instance when
+// they block or report their partner. Matched and finished sessions are
+// left alone.
+func (s *Sessions) End(sessionID, userID string) error {
+	sess, err := s.Get(sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if sess.State == SessionActive || sess.State == SessionDeciding {
+		s.teardown(sess, endedLeft)
+	}
+	return nil
+}
+
+// live reports whether a session is still running, so its pairing is
+// worth handing out.
+func (s *Sessions) live(sessionID string) (bool, error) {
+	sess, err := s.store.Get(sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sess.State == SessionActive || sess.State == SessionDeciding, nil
+}
+
+// View renders sess for viewerID.
+func (s *Sessions) View(sess *Session, viewerID string) *SessionView {
+	i := sess.index(viewerID)
+	v := &SessionView{
+		ID:           sess.ID,
+		State:        sess.State,
+		Alias:        sess.Aliases[i],
+		PartnerAlias: sess.Aliases[1-i],
+		StartedAt:    sess.StartedAt,
+		EndsAt:       sess.EndsAt,
+		DecideBy:     sess.DecideBy,
+		ServerTime:   time.Now().UTC(),
+		Spark:        sess.Sparks[i],
+	}
+	if sess.State == SessionMatched {
+		partnerID := sess.UserIDs[1-i]
+		v.MatchID = sess.MatchID
+		v.Partner = &RevealedPartner{UserID: partnerID}
+		if p, err := s.profiles.GetProfile(partnerID); err == nil {
+			v.Partner.DisplayName = p.DisplayName
+			v.Partner.VerifiedAt = p.VerifiedAt
+		} else {
+			s.logger.Printf("blinddate: load partner profile: %v", err)
+		}
+	}
+	return v
+}
+
+func (s *Sessions) viewMessage(sess *Session, m *SessionMessage, viewerID string) *MessageView {
+	return &MessageView{
+		ID:          m.ID,
+		ClientID:    m.ClientID,
+		SenderAlias: sess.Aliases[sess.index(m.SenderID)],
+		Mine:        m.SenderID == viewerID,
+		Text:        m.Text,
+		SentAt:      m.SentAt,
+	}
+}
+
+// Messages returns the session's chat as userID sees it.
+func (s *Sessions) Messages(sessionID, userID string) ([]MessageView, error) {
+	sess, err := s.Get(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.store.Messages(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MessageView, 0, len(msgs))
+	for i := range msgs {
+		out = append(out, *s.viewMessage(sess, &msgs[i], userID))
+	}
+	return out, nil
+}
+
+// Send posts a message while the chat timer is running and delivers it to
+// both participants. Retries with the same clientId return the original.
+// Text the screener blocks comes back as a *screening.BlockedError.
+func (s *Sessions) Send(ctx context.Context, sessionID, userID, clientID, text string) (*MessageView, bool, error) {
+	sess, err := s.Get(sessionID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now().UTC()
+	if sess.State != SessionActive || !now.Before(sess.EndsAt) {
+		return nil, false, ErrChatOver
+	}
+
+	content := screening.Content{Kind: screening.KindBlindDateMessage, AuthorID: userID, ContextID: sessionID, Text: text}
+	verdict := s.screener.Check(ctx, content)
+	if verdict.Action == screening.ActionBlock {
+		return nil, false, verdict.Err()
+	}
+
+	stored, created, err := s.store.AppendMessage(SessionMessage{
+		ID:        idgen.New(),
+		SessionID: sessionID,
+		SenderID:  userID,
+		ClientID:  clientID,
+		Text:      text,
+		SentAt:    now,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		content.ID = stored.ID
+		s.screener.Review(content, verdict)
+		for _, id := range sess.UserIDs {
+			s.notifier.SendFrame(sessionEvent{
+				Type:      EventSessionMessage,
+				SessionID: sessionID,
+				Message:   s.viewMessage(sess, stored, id),
+			}, id)
+		}
+	}
+	return s.viewMessage(sess, stored, userID), created, nil
+}
+
+// Decide records userID's spark or pass. A pass ends the session at once;
+// the second spark turns it into a match. The returned session reflects
+// the outcome.
+func (s *Sessions) Decide(sessionID, userID string, spark bool) (*Session, error) {
+	sess, err := s.store.Decide(sessionID, userID, spark, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	i := sess.index(userID)
+	if !*sess.Sparks[i] {
+		s.teardown(sess, endedNoSpark)
+		sess.State = SessionEnded
+		return sess, nil
+	}
+	if !sess.mutualSpark() {
+		return sess, nil
+	}
+	if err := s.convert(sess); err != nil {
+		return nil, err
+	}
+	return s.store.Get(sessionID)
+}
+
+// convert turns a mutually sparked session into a match, carrying the
+// conversation over. Every step is idempotent, so a retry after a partial
+// failure finishes the job.
+func (s *Sessions) convert(sess *Session) error {
+	m, _, err := s.matches.Create(sess.UserIDs[0], sess.UserIDs[1], matches.SourceBlindDate)
+	if err != nil {
+		return err
+	}
+	msgs, err := s.store.Messages(sess.ID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if _, _, err := s.messages.Append(chat.Message{
+			ID:             idgen.New(),
+			ConversationID: m.ID,
+			SenderID:       msg.SenderID,
+			ClientID:       "blind-date-" + msg.ID,
+			Kind:           chat.KindText,
+			Text:           msg.Text,
+			SentAt:         msg.SentAt,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(msgs) > 0 {
+		if err := s.matches.MarkMessaged(m.ID, msgs[0].SentAt); err != nil {
+			return err
+		}
+	}
+
+	ok, err := s.store.Transition(sess.ID, []string{SessionActive, SessionDeciding}, SessionMatched, m.ID)
+	if err != nil || !ok {
+		return err
+	}
+	sess.State, sess.MatchID = SessionMatched, m.ID
+	for _, id := range sess.UserIDs {
+		s.notifier.SendFrame(sessionEvent{Type: EventSessionMatched, SessionID: sess.ID, Session: s.View(sess, id)}, id)
+	}
+	return nil
+}
+
+// teardown deletes a session and its messages and tells both participants.
+func (s *Sessions) teardown(sess *Session, reason string) {
+	deleted, err := s.store.Delete(sess.ID)
+	if err != nil {
+		s.logger.Printf("blinddate: teardown session %s: %v", sess.ID, err)
+		return
+	}
+	if deleted {
+		s.notifier.SendFrame(sessionEvent{Type: EventSessionEnded, SessionID: sess.ID, Reason: reason}, sess.UserIDs[:]...)
+	}
+}
+
+// Run enforces session deadlines until ctx is cancelled: chats close when
+// their timer runs out, and sessions still undecided at DecideBy are torn
+// down.
+func (s *Sessions) Run(ctx context.Context) {
+	ticker := time.NewTicker(sessionTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(time.Now().UTC())
+		}
+	}
+}
+
+func (s *Sessions) sweep(now time.Time) {
+	due, err := s.store.Due(now)
+	if err != nil {
+		s.logger.Printf("blinddate: list due sessions: %v", err)
+		return
+	}
+	for i := range due {
+		sess := &due[i]
+		switch {
+		case !now.Before(sess.DecideBy) && sess.mutualSpark():
+			// A conversion that failed earlier; try again.
+			if err := s.convert(sess); err != nil {
+				s.logger.Printf("blinddate: convert session %s: %v", sess.ID, err)
+			}
+		case !now.Before(sess.DecideBy):
+			s.teardown(sess, endedExpired)
+		default:
+			ok, err := s.store.Transition(sess.ID, []string{SessionActive}, SessionDeciding, "")
+			if err != nil {
+				s.logger.Printf("blinddate: close chat %s: %v", sess.ID, err)
+				continue
+			}
+			if ok {
+				sess.State = SessionDeciding
+				for _, id := range sess.UserIDs {
+					s.notifier.SendFrame(sessionEvent{Type: EventSessionTimeUp, SessionID: sess.ID, Session: s.View(sess, id)}, id)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package blinddate pairs people who opt in to a short anonymous chat.
+package blinddate
+
+import (
+	"errors"
+	"time"
+)
+
+// Entry is one person waiting in the queue, with what pairing needs to know
+// about them copied from their profile at join time.
+type Entry struct {
+	UserID           string
+	Gender           string
+	PreferredGenders []string
+	Age              int
+	MinAge           int
+	MaxAge           int
+	Lat              float64
+	Lng              float64
+	HasLocation      bool
+	// JoinedAt orders the queue. Rejoining while already waiting keeps the
+	// original place.
+	JoinedAt time.Time
+	// LastSeen is refreshed while the user's connection or long-poll is
+	// alive; entries that go quiet are dropped.
+	LastSeen time.Time
+	// Blocked holds users this entry blocked or was blocked by. Stores fill
+	// it when loading the queue; it is never persisted.
+	Blocked map[string]struct{}
+}
+
+// BlockList reports who a user blocked or was blocked by; safety.Store
+// implements it.
+type BlockList interface {
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+}
+
+// Pairing is the outcome of matchmaking.
+type Pairing struct {
+	SessionID string    `json:"sessionId"`
+	UserIDs   [2]string `json:"-"`
+	PairedAt  time.Time `json:"pairedAt"`
+}
+
+// Partner returns the other user in the pairing.
+func (p *Pairing) Partner(userID string) string {
+	if p.UserIDs[0] == userID {
+		return p.UserIDs[1]
+	}
+	return p.UserIDs[0]
+}
+
+// ErrNotQueued is returned when a user has no queue entry.
+var ErrNotQueued = errors.New("not in the blind date queue")
+
+// Store holds the queue. Implementations must make Join atomic: a waiting
+// entry is paired at most once, however many users join concurrently.
+type Store interface {
+	// Join adds e to the queue, or refreshes an existing entry, and pairs
+	// it with the longest-waiting compatible entry if there is one. When a
+	// pairing is made both entries hold it until collected.
+	Join(e Entry, now time.Time) (*Pairing, error)
+	// Rematch pairs entries that have become compatible while waiting, as
+	// their search radius widened. Older entries get first pick.
+	Rematch(now time.Time) ([]*Pairing, error)
+	// Status returns the user's pairing if one is waiting to be collected,
+	// or nil if they're still queued. ErrNotQueued if neither.
+	Status(userID string) (*Pairing, error)
+	// Touch refreshes LastSeen for the users' entries.
+	Touch(userIDs []string, now time.Time) error
+	// Leave removes the user's entry, waiting or paired.
+	Leave(userID string) error
+	// ExpireStale removes entries not seen since cutoff and returns their
+	// users.
+	ExpireStale(cutoff time.Time) ([]string, error)
+}
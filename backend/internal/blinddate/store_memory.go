@@ -0,0 +1,130 @@
+package blinddate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+type memoryEntry struct {
+	Entry
+	pairing *Pairing
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	blocks  BlockList
+}
+
+// NewInMemoryStore returns a Store for single-instance deployments. The
+// mutex makes every Join a single atomic pairing decision; blocks keeps
+// blocked pairs apart.
+func NewInMemoryStore(blocks BlockList) Store {
+	return &memoryStore{entries: make(map[string]*memoryEntry), blocks: blocks}
+}
+
+// waitingLocked returns the unpaired entries seen within StaleAfter of now,
+// with their blocks freshly loaded. Blocks are symmetric, so the waiting
+// side alone is enough to keep a blocked pair apart.
+func (s *memoryStore) waitingLocked(now time.Time) ([]*Entry, error) {
+	var out []*Entry
+	for _, e := range s.entries {
+		if e.pairing == nil && now.Sub(e.LastSeen) < StaleAfter {
+			blocked, err := s.blocks.ExcludedUserIDs(e.UserID)
+			if err != nil {
+				return nil, err
+			}
+			e.Blocked = blocked
+			out = append(out, &e.Entry)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) pairLocked(a, b string, now time.Time) *Pairing {
+	p := &Pairing{SessionID: idgen.New(), UserIDs: [2]string{a, b}, PairedAt: now}
+	s.entries[a].pairing = p
+	s.entries[b].pairing = p
+	return p
+}
+
+func (s *memoryStore) Join(e Entry, now time.Time) (*Pairing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[e.UserID]; ok {
+		if existing.pairing != nil {
+			return existing.pairing, nil
+		}
+		e.JoinedAt = existing.JoinedAt
+	}
+	e.LastSeen = now
+	s.entries[e.UserID] = &memoryEntry{Entry: e}
+
+	waiting, err := s.waitingLocked(now)
+	if err != nil {
+		return nil, err
+	}
+	if i := pick(&e, waiting, now); i >= 0 {
+		return s.pairLocked(waiting[i].UserID, e.UserID, now), nil
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) Rematch(now time.Time) ([]*Pairing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting, err := s.waitingLocked(now)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Pairing
+	for _, pair := range pairWaiting(waiting, now) {
+		out = append(out, s.pairLocked(pair[0].UserID, pair[1].UserID, now))
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Status(userID string) (*Pairing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[userID]
+	if !ok {
+		return nil, ErrNotQueued
+	}
+	return e.pairing, nil
+}
+
+func (s *memoryStore) Touch(userIDs []string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range userIDs {
+		if e, ok := s.entries[id]; ok {
+			e.LastSeen = now
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Leave(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, userID)
+	return nil
+}
+
+func (s *memoryStore) ExpireStale(cutoff time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for id, e := range s.entries {
+		if e.LastSeen.Before(cutoff) {
+			delete(s.entries, id)
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,281 @@
+package blinddate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// pgStore keeps the queue in blind_date_queue (sql/0012_blind_date.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a queue Store backed by Postgres. Pairing takes a
+// single advisory lock, so concurrent joins on any instance are serialised.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+// queueLock keys the advisory lock taken by every pairing transaction.
+const queueLock = "blind_date_queue"
+
+func (s *pgStore) lock(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, queueLock)
+	return err
+}
+
+func (s *pgStore) Join(e Entry, now time.Time) (*Pairing, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.lock(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	p, err := pairingFor(tx.QueryRowContext(ctx, `
+		SELECT session_id, partner_id, paired_at FROM blind_date_queue
+		WHERE user_id = $1 AND session_id IS NOT NULL
+	`, e.UserID), e.UserID)
+	if err == nil {
+		return p, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var lat, lng sql.NullFloat64
+	if e.HasLocation {
+		lat = sql.NullFloat64{Float64: e.Lat, Valid: true}
+		lng = sql.NullFloat64{Float64: e.Lng, Valid: true}
+	}
+	// Rejoining keeps the original place in the queue.
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO blind_date_queue (user_id, gender, preferred_genders, age, min_age, max_age, lat, lng, joined_at, last_seen)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			gender = EXCLUDED.gender,
+			preferred_genders = EXCLUDED.preferred_genders,
+			age = EXCLUDED.age,
+			min_age = EXCLUDED.min_age,
+			max_age = EXCLUDED.max_age,
+			lat = EXCLUDED.lat,
+			lng = EXCLUDED.lng,
+			last_seen = EXCLUDED.last_seen
+		RETURNING joined_at
+	`, e.UserID, e.Gender, strings.Join(e.PreferredGenders, ","), e.Age, e.MinAge, e.MaxAge,
+		lat, lng, now).Scan(&e.JoinedAt); err != nil {
+		return nil, err
+	}
+
+	waiting, err := s.waiting(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+	i := pick(&e, waiting, now)
+	if i < 0 {
+		return nil, tx.Commit()
+	}
+	p, err = s.pair(ctx, tx, waiting[i].UserID, e.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+	return p, tx.Commit()
+}
+
+func (s *pgStore) Rematch(now time.Time) ([]*Pairing, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.lock(ctx, tx); err != nil {
+		return nil, err
+	}
+	waiting, err := s.waiting(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Pairing
+	for _, pair := range pairWaiting(waiting, now) {
+		p, err := s.pair(ctx, tx, pair[0].UserID, pair[1].UserID, now)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, tx.Commit()
+}
+
+// waiting loads unpaired entries seen within StaleAfter of now, along with
+// any blocks between them (sql/0002_discovery.sql).
+func (s *pgStore) waiting(ctx context.Context, tx *sql.Tx, now time.Time) ([]*Entry, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id, gender, preferred_genders, age, min_age, max_age, lat, lng, joined_at, last_seen
+		FROM blind_date_queue
+		WHERE session_id IS NULL AND last_seen > $1
+		ORDER BY joined_at, user_id
+	`, now.Add(-StaleAfter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Entry
+	for rows.Next() {
+		var (
+			e        Entry
+			prefs    string
+			lat, lng sql.NullFloat64
+		)
+		if err := rows.Scan(&e.UserID, &e.Gender, &prefs, &e.Age, &e.MinAge, &e.MaxAge,
+			&lat, &lng, &e.JoinedAt, &e.LastSeen); err != nil {
+			return nil, err
+		}
+		e.PreferredGenders = onboarding.SplitGenders(prefs)
+		e.HasLocation = lat.Valid && lng.Valid
+		e.Lat, e.Lng = lat.Float64, lng.Float64
+		out = append(out, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return out, s.loadBlocks(ctx, tx, out)
+}
+
+// loadBlocks fills Blocked on each entry with the other waiting users it has
+// a block with, in either direction.
+func (s *pgStore) loadBlocks(ctx context.Context, tx *sql.Tx, entries []*Entry) error {
+	if len(entries) < 2 {
+		return nil
+	}
+	byID := make(map[string]*Entry, len(entries))
+	args := make([]any, 0, len(entries))
+	for _, e := range entries {
+		byID[e.UserID] = e
+		args = append(args, e.UserID)
+	}
+	in := placeholders(1, len(entries))
+	rows, err := tx.QueryContext(ctx, `
+		SELECT blocker_id, blocked_id FROM blocks
+		WHERE blocker_id IN (`+in+`) AND blocked_id IN (`+in+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a, b string
+		if err := rows.Scan(&a, &b); err != nil {
+			return err
+		}
+		for _, pair := range [][2]string{{a, b}, {b, a}} {
+			e := byID[pair[0]]
+			if e.Blocked == nil {
+				e.Blocked = make(map[string]struct{})
+			}
+			e.Blocked[pair[1]] = struct{}{}
+		}
+	}
+	return rows.Err()
+}
+
+// pair records a pairing on both rows.
+func (s *pgStore) pair(ctx context.Context, tx *sql.Tx, a, b string, now time.Time) (*Pairing, error) {
+	p := &Pairing{SessionID: idgen.New(), UserIDs: [2]string{a, b}, PairedAt: now}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE blind_date_queue
+		SET session_id = $1, paired_at = $2,
+			partner_id = CASE WHEN user_id = $3 THEN $4 ELSE $3 END
+		WHERE user_id IN ($3, $4)
+	`, p.SessionID, now, a, b)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func pairingFor(row *sql.Row, userID string) (*Pairing, error) {
+	var p Pairing
+	var partner string
+	if err := row.Scan(&p.SessionID, &partner, &p.PairedAt); err != nil {
+		return nil, err
+	}
+	p.UserIDs = [2]string{userID, partner}
+	return &p, nil
+}
+
+func (s *pgStore) Status(userID string) (*Pairing, error) {
+	var (
+		sessionID, partner sql.NullString
+		pairedAt           sql.NullTime
+	)
+	err := s.db.QueryRowContext(context.Background(), `
+		SELECT session_id, partner_id, paired_at FROM blind_date_queue WHERE user_id = $1
+	`, userID).Scan(&sessionID, &partner, &pairedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotQueued
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !sessionID.Valid {
+		return nil, nil
+	}
+	return &Pairing{
+		SessionID: sessionID.String,
+		UserIDs:   [2]string{userID, partner.String},
+		PairedAt:  pairedAt.Time,
+	}, nil
+}
+
+func (s *pgStore) Touch(userIDs []string, now time.Time) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(userIDs)+1)
+	args = append(args, now)
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+	_, err := s.db.ExecContext(context.Background(), `
+		UPDATE blind_date_queue SET last_seen = $1 WHERE user_id IN (`+placeholders(2, len(userIDs))+`)
+	`, args...)
+	return err
+}
+
+func (s *pgStore) Leave(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `DELETE FROM blind_date_queue WHERE user_id = $1`, userID)
+	return err
+}
+
+func (s *pgStore) ExpireStale(cutoff time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		DELETE FROM blind_date_queue WHERE last_seen < $1 RETURNING user_id
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
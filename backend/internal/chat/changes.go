@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// changeWindow is how long after sending a message its sender may edit it
+// or delete it for everyone.
+const changeWindow = 15 * time.Minute
+
+// maxEmojiRunes allows multi-codepoint emoji (skin tones, ZWJ sequences,
+// flags) while keeping reactions to a single symbol.
+const maxEmojiRunes = 8
+
+var errMessageDeleted = errors.New("message was deleted")
+
+// validateEmoji accepts a single emoji. Letters, digits, punctuation and
+// whitespace are rejected so reactions can't carry text.
+func validateEmoji(emoji string) error {
+	n := utf8.RuneCountInString(emoji)
+	if n == 0 {
+		return errors.New("emoji is required")
+	}
+	if n > maxEmojiRunes {
+		return errors.New("reaction must be a single emoji")
+	}
+	for _, r := range emoji {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) ||
+			unicode.IsPunct(r) || unicode.IsControl(r) || r < 0x80 {
+			return errors.New("reaction must be a single emoji")
+		}
+	}
+	return nil
+}
+
+// loadMessage resolves the conversation and message for a REST request,
+// writing an error response when either is missing.
+func (h *Handler) loadMessage(w http.ResponseWriter, r *http.Request, userID string) (*matches.Match, *Message, bool) {
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return nil, nil, false
+	}
+	msg, err := h.messages.Get(m.ID, r.PathValue("messageId"))
+	if err != nil {
+		if errors.Is(err, ErrMessageNotFound) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return nil, nil, false
+		}
+		h.logger.Printf("chat: load message: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load message"))
+		return nil, nil, false
+	}
+	return m, msg, true
+}
+
+// checkChangeable enforces that only the sender changes a live message, and
+// only within changeWindow.
+func checkChangeable(msg *Message, userID string, now time.Time) (int, error) {
+	if msg.SenderID != userID {
+		return http.StatusForbidden, errors.New("only the sender can change a message")
+	}
+	if msg.DeletedAt != nil {
+		return http.StatusConflict, errMessageDeleted
+	}
+	if now.Sub(msg.SentAt) > changeWindow {
+		return http.StatusForbidden, errors.New("messages can only be changed for 15 minutes after sending")
+	}
+	return 0, nil
+}
+
+type editMessageRequest struct {
+	Text string `json:"text"`
+}
+
+// MessageItem handles /v1/conversations/{id}/messages/{messageId}
+//
+//	PATCH  {"text":"..."}  edit a text message
+//	DELETE                 delete it for everyone
+//
+// Both are limited to the sender, within changeWindow of sending.
+func (h *Handler) MessageItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch && r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var text string
+	if r.Method == http.MethodPatch {
+		var req editMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+		if text, err = validateText(req.Text, ""); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	m, msg, ok := h.loadMessage(w, r, userID)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	if status, err := checkChangeable(msg, userID, now); err != nil {
+		httpjson.Error(w, status, err)
+		return
+	}
+
+	if r.Method == http.MethodPatch {
+		if msg.Kind != KindText {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("only text messages can be edited"))
+			return
+		}
+		// An edit can't be hidden after the fact, so anything the screener
+		// would soft-hide is refused instead.
+		edited := *msg
+		edited.Text = text
+		verdict := h.screener.Check(r.Context(), h.screeningContent(&edited))
+		if verdict.Action == screening.ActionBlock || verdict.Action == screening.ActionSoftHide {
+			httpjson.Error(w, http.StatusUnprocessableEntity, verdict.Err())
+			return
+		}
+		updated, err := h.messages.Edit(msg.ConversationID, msg.ID, text, now)
+		if errors.Is(err, ErrMessageNotFound) {
+			// Deleted since we loaded it.
+			httpjson.Error(w, http.StatusConflict, errMessageDeleted)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("EditMessage error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("could not edit message"))
+			return
+		}
+		h.screener.Review(h.screeningContent(updated), verdict)
+		h.deliverAs(EventMessageEdited, updated, userID, m.Other(userID))
+		httpjson.Write(w, http.StatusOK, map[string]any{"message": updated})
+		return
+	}
+
+	tombstone, err := h.messages.Delete(msg.ConversationID, msg.ID, now)
+	if err != nil {
+		h.logger.Printf("DeleteMessage error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not delete message"))
+		return
+	}
+	if msg.Attachment != nil {
+		for _, key := range msg.Attachment.blobKeys() {
+			if err := h.blobs.Delete(key); err != nil {
+				h.logger.Printf("DeleteMessage blob error: %v", err)
+			}
+		}
+	}
+	h.deliverAs(EventMessageDeleted, tombstone, userID, m.Other(userID))
+	httpjson.Write(w, http.StatusOK, map[string]any{"message": tombstone})
+}
+
+type reactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// Reaction handles /v1/conversations/{id}/messages/{messageId}/reaction
+//
+//	PUT    {"emoji":"🔥"}  set the caller's reaction, replacing any other
+//	DELETE                remove it
+func (h *Handler) Reaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var emoji string
+	if r.Method == http.MethodPut {
+		var req reactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+		if err := validateEmoji(req.Emoji); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		emoji = req.Emoji
+	}
+
+	m, msg, ok := h.loadMessage(w, r, userID)
+	if !ok {
+		return
+	}
+	if msg.DeletedAt != nil {
+		httpjson.Error(w, http.StatusConflict, errMessageDeleted)
+		return
+	}
+
+	if err := h.messages.SetReaction(m.ID, msg.ID, userID, emoji, time.Now().UTC()); err != nil {
+		h.logger.Printf("Reaction error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not update reaction"))
+		return
+	}
+	h.hub.SendToUsers(Event{
+		Type:           EventReaction,
+		ConversationID: m.ID,
+		MessageID:      msg.ID,
+		UserID:         userID,
+		Emoji:          &emoji,
+	}, userID, m.Other(userID))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,146 @@
+package chat
+
+import (
+	"time"
+)
+
+// Event types sent from the server to clients.
+const (
+	EventMessageNew = "message.new"
+	EventTyping     = "typing"
+	EventRead       = "read"
+	EventPresence   = "presence"
+	// EventMessageEdited carries the full updated message.
+	EventMessageEdited = "message.edited"
+	// EventMessageDeleted carries the message's tombstone.
+	EventMessageDeleted = "message.deleted"
+	// EventReaction carries one user's reaction; an empty emoji means it
+	// was removed.
+	EventReaction = "reaction"
+	// EventMessageRevealed tells the sender a blurred photo was opened.
+	EventMessageRevealed = "message.revealed"
+	EventError           = "error"
+)
+
+// Event types clients send over the socket.
+const (
+	clientMessageSend = "message.send"
+	clientTyping      = "typing"
+	clientRead        = "read"
+)
+
+// Message is a chat message in a conversation. A conversation's ID is the ID
+// of the match it belongs to.
+type Message struct {
+	ID             string      `json:"id"`
+	Seq            int64       `json:"-"`
+	ConversationID string      `json:"conversationId"`
+	SenderID       string      `json:"senderId"`
+	ClientID       string      `json:"clientId,omitempty"`
+	Kind           string      `json:"kind"`
+	Text           string      `json:"text,omitempty"`
+	Attachment     *Attachment `json:"attachment,omitempty"`
+	ReplyToID      string      `json:"replyToId,omitempty"`
+	// ReplyTo quotes the message being replied to.
+	ReplyTo   *ReplyPreview `json:"replyTo,omitempty"`
+	Reactions []Reaction    `json:"reactions,omitempty"`
+	SentAt    time.Time     `json:"sentAt"`
+	EditedAt  *time.Time    `json:"editedAt,omitempty"`
+	// DeletedAt is set when the sender deleted the message for everyone;
+	// its text and attachment are gone and only this tombstone remains.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// RevealedAt is when the recipient opened a blurred photo.
+	RevealedAt *time.Time `json:"revealedAt,omitempty"`
+	// HiddenReason is set when screening soft-hid the message (see
+	// screening.Result.Label); clients show it collapsed until tapped.
+	HiddenReason string `json:"hiddenReason,omitempty"`
+}
+
+// ReplyPreview is the quoted part of a reply.
+type ReplyPreview struct {
+	ID       string `json:"id"`
+	SenderID string `json:"senderId"`
+	Kind     string `json:"kind"`
+	Text     string `json:"text,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	// Hidden quotes a soft-hidden message without its text.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Reaction is one user's emoji on a message. Each user has at most one
+// reaction per message.
+type Reaction struct {
+	UserID    string    `json:"userId"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Attachment is the media behind a non-text message. URL is signed for the
+// viewer each time a message is handed out and is never stored.
+type Attachment struct {
+	Key         string    `json:"-"`
+	URL         string    `json:"url,omitempty"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	DurationMs  int       `json:"durationMs,omitempty"`
+	Peaks       []float64 `json:"peaks,omitempty"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	// Blurred photos are shown to the recipient as PreviewURL until they
+	// reveal them; URL stays empty for them until then.
+	Blurred    bool   `json:"blurred,omitempty"`
+	PreviewURL string `json:"previewUrl,omitempty"`
+}
+
+// previewKey is where a blurred photo's preview is stored.
+func previewKey(key string) string {
+	return key + "-preview"
+}
+
+// blobKeys lists every blob behind an attachment.
+func (a *Attachment) blobKeys() []string {
+	if a.Blurred {
+		return []string{a.Key, previewKey(a.Key)}
+	}
+	return []string{a.Key}
+}
+
+// Message kinds.
+const (
+	KindText  = "text"
+	KindAudio = "audio"
+	KindImage = "image"
+)
+
+// ConversationSettings are one participant's preferences for a
+// conversation.
+type ConversationSettings struct {
+	// MinTextMessagesForImages refuses photos until both participants have
+	// sent at least this many text messages. Zero accepts photos at once.
+	MinTextMessagesForImages int `json:"minTextMessagesForImages"`
+}
+
+// Event is the envelope for everything the server pushes to a client.
+type Event struct {
+	Type           string   `json:"type"`
+	ConversationID string   `json:"conversationId,omitempty"`
+	Message        *Message `json:"message,omitempty"`
+	UserID         string   `json:"userId,omitempty"`
+	IsTyping       *bool    `json:"isTyping,omitempty"`
+	Online         *bool    `json:"online,omitempty"`
+	MessageID      string   `json:"messageId,omitempty"`
+	Emoji          *string  `json:"emoji,omitempty"`
+	ClientID       string   `json:"clientId,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// inboundEvent is a frame received from a client.
+type inboundEvent struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversationId"`
+	ClientID       string `json:"clientId"`
+	Text           string `json:"text"`
+	IsTyping       bool   `json:"isTyping"`
+	MessageID      string `json:"messageId"`
+	ReplyToID      string `json:"replyToId"`
+}
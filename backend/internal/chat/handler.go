@@ -0,0 +1,568 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/notifications"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// maxTextLength caps a single text message, in characters.
+const maxTextLength = 4000
+
+// History page sizes.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+// maxClientIDLength bounds client-generated message IDs.
+const maxClientIDLength = 64
+
+var (
+	errConversationNotFound = errors.New("conversation not found")
+	errReplyNotFound        = errors.New("replyToId does not match a message in this conversation")
+)
+
+// BlockChecker reports whether either of two users has blocked the other;
+// safety.Store implements it.
+type BlockChecker interface {
+	Blocked(a, b string) (bool, error)
+}
+
+// Handler serves the chat WebSocket endpoint and the conversation REST API,
+// and routes client events between matched users.
+type Handler struct {
+	logger   *log.Logger
+	hub      *Hub
+	matches  matches.Store
+	messages MessageStore
+	blocks   BlockChecker
+	blobs    media.BlobStore
+	signer   *media.Signer
+	screener *screening.Screener
+	push     *notifications.Service
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(logger *log.Logger, hub *Hub, matchStore matches.Store, messages MessageStore, blocks BlockChecker,
+	blobs media.BlobStore, signer *media.Signer, screener *screening.Screener, push *notifications.Service) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		hub:      hub,
+		matches:  matchStore,
+		messages: messages,
+		blocks:   blocks,
+		blobs:    blobs,
+		signer:   signer,
+		screener: screener,
+		push:     push,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			// Mobile clients don't send an Origin header; tokens, not
+			// cookies, authenticate the socket, so cross-origin is fine.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// --- Helpers ---
+
+// activeMatch loads the match behind a conversation and checks that userID
+// is allowed to use it.
+func (h *Handler) activeMatch(conversationID, userID string) (*matches.Match, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversationId is required")
+	}
+	m, err := h.matches.Get(conversationID)
+	if err != nil {
+		if errors.Is(err, matches.ErrNotFound) {
+			return nil, errConversationNotFound
+		}
+		return nil, err
+	}
+	if !m.ActiveFor(userID) {
+		return nil, errConversationNotFound
+	}
+	// Blocking ends the match, but check anyway in case that failed.
+	blocked, err := h.blocks.Blocked(m.UserAID, m.UserBID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errConversationNotFound
+	}
+	return m, nil
+}
+
+// validateText normalises an outgoing text message and its client ID.
+func validateText(text, clientID string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("text is required")
+	}
+	if utf8.RuneCountInString(text) > maxTextLength {
+		return "", errors.New("message is too long")
+	}
+	if len(clientID) > maxClientIDLength {
+		return "", errors.New("clientId is too long")
+	}
+	return text, nil
+}
+
+// post screens and stores a validated message and delivers it to everyone
+// in the conversation. Retries with the same clientId return the original
+// message before it is screened again, and aren't delivered again. Text the
+// screener blocks comes back as a *screening.BlockedError.
+func (h *Handler) post(ctx context.Context, m *matches.Match, msg Message) (*Message, bool, error) {
+	if msg.ClientID != "" {
+		existing, err := h.messages.GetByClientID(m.ID, msg.SenderID, msg.ClientID)
+		if err == nil {
+			return existing, false, nil
+		}
+		if !errors.Is(err, ErrMessageNotFound) {
+			return nil, false, err
+		}
+	}
+
+	msg.ID = idgen.New()
+	msg.ConversationID = m.ID
+	msg.SentAt = time.Now().UTC()
+
+	var verdict screening.Result
+	if msg.Kind == KindText {
+		verdict = h.screener.Check(ctx, h.screeningContent(&msg))
+		switch verdict.Action {
+		case screening.ActionBlock:
+			return nil, false, verdict.Err()
+		case screening.ActionSoftHide:
+			msg.HiddenReason = verdict.Label()
+		}
+	}
+
+	stored, created, err := h.messages.Append(msg)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		return stored, false, nil
+	}
+	h.screener.Review(h.screeningContent(stored), verdict)
+
+	if err := h.matches.MarkMessaged(m.ID, stored.SentAt); err != nil {
+		h.logger.Printf("chat: mark messaged: %v", err)
+	}
+	// The sender's other devices need the message too.
+	h.deliver(stored, msg.SenderID, m.Other(msg.SenderID))
+	h.push.Notify(notifications.Notification{
+		Kind:      notifications.KindMessage,
+		UserID:    m.Other(msg.SenderID),
+		ActorID:   msg.SenderID,
+		SubjectID: m.ID,
+		Text:      pushPreview(stored),
+	})
+	return stored, true, nil
+}
+
+// pushPreview is what a push notification shows of msg. Soft-hidden text
+// stays hidden.
+func pushPreview(msg *Message) string {
+	switch {
+	case msg.Kind == KindImage:
+		return "Sent a photo"
+	case msg.Kind == KindAudio:
+		return "Sent a voice note"
+	case msg.HiddenReason != "":
+		return "Sent a message"
+	}
+	return msg.Text
+}
+
+// screeningContent describes a text message to the screener.
+func (h *Handler) screeningContent(msg *Message) screening.Content {
+	return screening.Content{
+		Kind:      screening.KindChatMessage,
+		AuthorID:  msg.SenderID,
+		ContextID: msg.ConversationID,
+		ID:        msg.ID,
+		Text:      msg.Text,
+	}
+}
+
+// deliver sends message.new to the given users.
+func (h *Handler) deliver(msg *Message, userIDs ...string) {
+	h.deliverAs(EventMessageNew, msg, userIDs...)
+}
+
+// deliverAs sends an event carrying msg to the given users. Media URLs are
+// signed per viewer, so messages with attachments go out one user at a time.
+func (h *Handler) deliverAs(eventType string, msg *Message, userIDs ...string) {
+	if msg.Attachment == nil {
+		h.hub.SendToUsers(Event{Type: eventType, ConversationID: msg.ConversationID, Message: msg}, userIDs...)
+		return
+	}
+	for _, id := range userIDs {
+		h.hub.SendToUsers(Event{Type: eventType, ConversationID: msg.ConversationID, Message: h.present(msg, id)}, id)
+	}
+}
+
+// present returns msg as viewerID should see it, with signed media URLs.
+func (h *Handler) present(msg *Message, viewerID string) *Message {
+	if msg.Attachment == nil {
+		return msg
+	}
+	out := *msg
+	a := *msg.Attachment
+	if a.Blurred {
+		a.PreviewURL = h.signer.URL(previewKey(a.Key), viewerID)
+	}
+	// The recipient only gets the full photo once they've chosen to see it.
+	if !a.Blurred || viewerID == msg.SenderID || msg.RevealedAt != nil {
+		a.URL = h.signer.URL(a.Key, viewerID)
+	}
+	out.Attachment = &a
+	return &out
+}
+
+// CanAccessMedia is the media.AccessFunc for the "chat" namespace: only
+// members of an active match may fetch its conversation's media.
+func (h *Handler) CanAccessMedia(viewerID, key string) (bool, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return false, nil
+	}
+	_, err := h.activeMatch(parts[1], viewerID)
+	if errors.Is(err, errConversationNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *Handler) sendError(c *client, ev inboundEvent, err error) {
+	c.sendEvent(Event{
+		Type:           EventError,
+		ConversationID: ev.ConversationID,
+		ClientID:       ev.ClientID,
+		Error:          err.Error(),
+	})
+}
+
+// --- Handlers ---
+
+// ServeWS handles GET /v1/ws
+//
+// The connection is authenticated by JWTUserContextMiddleware before the
+// upgrade. Once connected, clients send JSON frames:
+//
+//	{"type":"message.send","conversationId":"...","clientId":"...","text":"hi","replyToId":"..."}
+//	{"type":"typing","conversationId":"...","isTyping":true}
+//	{"type":"read","conversationId":"...","messageId":"..."}
+//
+// and receive message.new, message.edited, message.deleted, reaction,
+// typing, read, presence and error events, plus blinddate.* events sent by
+// other packages through Hub.SendFrame. On
+// connect the client is sent a presence event for each match that is
+// currently online.
+func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote an HTTP error response.
+		h.logger.Printf("chat: upgrade error: %v", err)
+		return
+	}
+
+	c, ok := h.hub.register(userID, conn)
+	if !ok {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "server shutting down")
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+		_ = conn.Close()
+		return
+	}
+
+	go c.writePump()
+	h.sendPresenceSnapshot(c)
+	c.readPump(h.handleFrame)
+}
+
+// sendPresenceSnapshot tells a new connection which of its matches are
+// online; later changes arrive as they happen.
+func (h *Handler) sendPresenceSnapshot(c *client) {
+	active, err := h.matches.ListActive(c.userID)
+	if err != nil {
+		h.logger.Printf("chat: presence snapshot: %v", err)
+		return
+	}
+	online := true
+	for _, m := range active {
+		other := m.Other(c.userID)
+		if h.hub.Online(other) {
+			c.sendEvent(Event{Type: EventPresence, UserID: other, Online: &online})
+		}
+	}
+}
+
+// handleFrame dispatches one client frame.
+func (h *Handler) handleFrame(c *client, data []byte) {
+	var ev inboundEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		h.sendError(c, ev, errors.New("invalid frame"))
+		return
+	}
+
+	m, err := h.activeMatch(ev.ConversationID, c.userID)
+	if err != nil {
+		h.sendError(c, ev, err)
+		return
+	}
+	other := m.Other(c.userID)
+
+	switch ev.Type {
+	case clientMessageSend:
+		text, err := validateText(ev.Text, ev.ClientID)
+		if err != nil {
+			h.sendError(c, ev, err)
+			return
+		}
+		msg, created, err := h.post(context.Background(), m, Message{
+			SenderID:  c.userID,
+			ClientID:  ev.ClientID,
+			Kind:      KindText,
+			Text:      text,
+			ReplyToID: ev.ReplyToID,
+		})
+		if errors.Is(err, ErrMessageNotFound) {
+			h.sendError(c, ev, errReplyNotFound)
+			return
+		}
+		if screening.IsBlocked(err) {
+			h.sendError(c, ev, err)
+			return
+		}
+		if err != nil {
+			h.logger.Printf("chat: store message: %v", err)
+			h.sendError(c, ev, errors.New("could not send message"))
+			return
+		}
+		if !created {
+			// A retry: echo the stored message back to this socket only.
+			c.sendEvent(Event{Type: EventMessageNew, ConversationID: m.ID, Message: h.present(msg, c.userID)})
+		}
+
+	case clientTyping:
+		typing := ev.IsTyping
+		h.hub.SendToUsers(Event{
+			Type:           EventTyping,
+			ConversationID: m.ID,
+			UserID:         c.userID,
+			IsTyping:       &typing,
+		}, other)
+
+	case clientRead:
+		if ev.MessageID == "" {
+			h.sendError(c, ev, errors.New("messageId is required"))
+			return
+		}
+		if err := h.messages.MarkRead(m.ID, c.userID, ev.MessageID); err != nil {
+			if errors.Is(err, ErrMessageNotFound) {
+				h.sendError(c, ev, err)
+				return
+			}
+			h.logger.Printf("chat: mark read: %v", err)
+		}
+		h.hub.SendToUsers(Event{
+			Type:           EventRead,
+			ConversationID: m.ID,
+			UserID:         c.userID,
+			MessageID:      ev.MessageID,
+		}, other)
+
+	default:
+		h.sendError(c, ev, errors.New("unknown event type"))
+	}
+}
+
+// ListMessages handles GET /v1/conversations/{id}/messages
+//
+// Messages are returned newest first. Pass the returned nextCursor as
+// ?before= to fetch older messages; it is empty once history is exhausted.
+func (h *Handler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+
+	msgs, more, err := h.messages.List(m.ID, r.URL.Query().Get("before"), limit)
+	if err != nil {
+		if errors.Is(err, ErrMessageNotFound) {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("invalid cursor"))
+			return
+		}
+		h.logger.Printf("ListMessages error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load messages"))
+		return
+	}
+	out := make([]*Message, len(msgs))
+	for i := range msgs {
+		out[i] = h.present(&msgs[i], userID)
+	}
+
+	var next string
+	if more && len(msgs) > 0 {
+		next = msgs[len(msgs)-1].ID
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{
+		"messages":   out,
+		"nextCursor": next,
+	})
+}
+
+type sendMessageRequest struct {
+	ClientID  string `json:"clientId"`
+	Text      string `json:"text"`
+	ReplyToID string `json:"replyToId"`
+}
+
+// SendMessage handles POST /v1/conversations/{id}/messages
+//
+// clientId is required so that retries are idempotent: resending the same
+// clientId returns the original message with 200 instead of 201.
+func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req sendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+		return
+	}
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if req.ClientID == "" {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("clientId is required"))
+		return
+	}
+	text, err := validateText(req.Text, req.ClientID)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+
+	msg, created, err := h.post(r.Context(), m, Message{
+		SenderID:  userID,
+		ClientID:  req.ClientID,
+		Kind:      KindText,
+		Text:      text,
+		ReplyToID: req.ReplyToID,
+	})
+	if errors.Is(err, ErrMessageNotFound) {
+		httpjson.Error(w, http.StatusBadRequest, errReplyNotFound)
+		return
+	}
+	if screening.IsBlocked(err) {
+		httpjson.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("SendMessage error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not send message"))
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	httpjson.Write(w, status, map[string]any{"message": h.present(msg, userID)})
+}
+
+// Conversation handles /v1/conversations/{id}/messages.
+func (h *Handler) Conversation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListMessages(w, r)
+	case http.MethodPost:
+		h.SendMessage(w, r)
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// loadConversation resolves the conversation for a REST request, writing an
+// error response when the caller isn't an active member.
+func (h *Handler) loadConversation(w http.ResponseWriter, id, userID string) (*matches.Match, bool) {
+	m, err := h.activeMatch(id, userID)
+	if err == nil {
+		return m, true
+	}
+	if errors.Is(err, errConversationNotFound) {
+		httpjson.Error(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if id == "" {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	h.logger.Printf("chat: load conversation: %v", err)
+	httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load conversation"))
+	return nil, false
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/notifications"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/pubsub"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// flaggingClassifier flags everything and counts what it was asked about.
+type flaggingClassifier struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *flaggingClassifier) Classify(context.Context, screening.Content) (screening.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return screening.Result{Action: screening.ActionFlag, Category: "test"}, nil
+}
+
+type countingQueue struct {
+	mu    sync.Mutex
+	flags int
+}
+
+func (q *countingQueue) Flag(screening.Flag) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.flags++
+	return nil
+}
+
+type noBlocks struct{}
+
+func (noBlocks) Blocked(string, string) (bool, error) { return false, nil }
+
+func TestPostRetryIsNotScreenedAgain(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	matchStore := matches.NewInMemoryStore()
+	m, _, err := matchStore.Create("u1", "u2", "like")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := NewInMemoryMessageStore()
+	classifier := &flaggingClassifier{}
+	queue := &countingQueue{}
+	hub := NewHub(logger, pubsub.NewInProcess(), matchStore)
+	t.Cleanup(hub.Close)
+	push := notifications.NewService(logger, notifications.NewInMemoryStore(), onboarding.NewInMemoryStore(),
+		notifications.NewFake(logger))
+	h := NewHandler(logger, hub, matchStore, messages, noBlocks{}, media.NewInMemoryBlobStore(),
+		media.NewSigner([]byte("k"), "", 0), screening.NewScreener(logger, classifier, queue), push)
+
+	msg := Message{SenderID: "u1", ClientID: "c-1", Kind: KindText, Text: "hello"}
+	first, created, err := h.post(context.Background(), m, msg)
+	if err != nil || !created {
+		t.Fatalf("first post: created=%v, %v", created, err)
+	}
+	for range 3 {
+		again, created, err := h.post(context.Background(), m, msg)
+		if err != nil {
+			t.Fatalf("retry: %v", err)
+		}
+		if created || again.ID != first.ID {
+			t.Errorf("retry created=%v id=%s, want the original %s", created, again.ID, first.ID)
+		}
+	}
+
+	if classifier.calls != 1 {
+		t.Errorf("screened %d times, want 1", classifier.calls)
+	}
+	if queue.flags != 1 {
+		t.Errorf("filed %d flags, want 1", queue.flags)
+	}
+	if msgs, _, _ := messages.List(m.ID, "", 10); len(msgs) != 1 {
+		t.Errorf("stored %d messages, want 1", len(msgs))
+	}
+}
@@ -0,0 +1,357 @@
+package chat
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/pubsub"
+)
+
+const (
+	// writeWait bounds how long a single frame write may take.
+	writeWait = 10 * time.Second
+	// pongWait is how long we wait for a pong before dropping the client.
+	pongWait = 60 * time.Second
+	// pingPeriod must be shorter than pongWait.
+	pingPeriod = pongWait * 9 / 10
+	// maxFrameSize caps inbound frames; chat text is small.
+	maxFrameSize = 16 << 10
+	// sendBuffer is how many outbound events may queue per connection. A
+	// client that falls this far behind is disconnected rather than allowed
+	// to stall delivery to everyone else; it resyncs on reconnect.
+	sendBuffer = 64
+)
+
+// Bus topics used by the hub.
+const (
+	topicEvents     = "chat.events"
+	topicPresence   = "chat.presence"
+	topicDisconnect = "chat.disconnect"
+)
+
+// Hub tracks live WebSocket connections per user and fans events out to
+// them. A user may be connected from several devices at once, possibly to
+// different API instances: events go through the bus so that every
+// instance delivers to the connections it holds.
+type Hub struct {
+	logger  *log.Logger
+	bus     pubsub.Bus
+	matches matches.Store
+	nodeID  string
+
+	mu      sync.Mutex
+	clients map[string]map[*client]struct{}
+	// seen records, per user, which instances reported them online and
+	// when; see presence.go.
+	seen   map[string]map[string]time.Time
+	closed bool
+	wg     sync.WaitGroup
+
+	unsubscribe []func()
+}
+
+// NewHub returns an empty hub subscribed to bus. matchStore decides who is
+// told about a user's presence changes.
+func NewHub(logger *log.Logger, bus pubsub.Bus, matchStore matches.Store) *Hub {
+	if logger == nil {
+		logger = log.Default()
+	}
+	h := &Hub{
+		logger:  logger,
+		bus:     bus,
+		matches: matchStore,
+		nodeID:  idgen.New(),
+		clients: make(map[string]map[*client]struct{}),
+		seen:    make(map[string]map[string]time.Time),
+	}
+	h.unsubscribe = []func(){
+		bus.Subscribe(topicEvents, h.receiveEvent),
+		bus.Subscribe(topicPresence, h.receivePresence),
+		bus.Subscribe(topicDisconnect, h.receiveDisconnect),
+	}
+	return h
+}
+
+// envelope carries an event and its recipients across the bus.
+type envelope struct {
+	UserIDs []string        `json:"userIds"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// client is one WebSocket connection.
+type client struct {
+	hub    *Hub
+	userID string
+	conn   *websocket.Conn
+	send   chan []byte
+
+	closeOnce sync.Once
+	done      chan struct{}
+	// closeMsg is the close frame writePump sends once done is closed; nil
+	// means close without one. It is set before done is closed.
+	closeMsg []byte
+}
+
+// register adds a connection. It returns false if the hub is shutting down.
+func (h *Hub) register(userID string, conn *websocket.Conn) (*client, bool) {
+	c := &client{
+		hub:    h,
+		userID: userID,
+		conn:   conn,
+		send:   make(chan []byte, sendBuffer),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, false
+	}
+	set, ok := h.clients[userID]
+	if !ok {
+		set = make(map[*client]struct{})
+		h.clients[userID] = set
+	}
+	set[c] = struct{}{}
+	h.wg.Add(1)
+	first := len(set) == 1
+	h.mu.Unlock()
+
+	if first {
+		h.announce(userID, true)
+	}
+	return c, true
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	set, ok := h.clients[c.userID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+	if _, ok := set[c]; !ok {
+		h.mu.Unlock()
+		return
+	}
+	delete(set, c)
+	last := len(set) == 0
+	if last {
+		delete(h.clients, c.userID)
+	}
+	closed := h.closed
+	h.mu.Unlock()
+
+	// During shutdown other instances learn we're gone from the missing
+	// heartbeats instead of one announcement per user.
+	if last && !closed {
+		h.announce(c.userID, false)
+	}
+	h.wg.Done()
+}
+
+// SendToUsers publishes ev for every connection of the given users, on any
+// instance. Delivery never blocks: connections whose buffers are full are
+// closed instead.
+func (h *Hub) SendToUsers(ev Event, userIDs ...string) {
+	h.SendFrame(ev, userIDs...)
+}
+
+// SendFrame is SendToUsers for frames defined outside this package, such as
+// blind date events. v must marshal to a JSON object with a "type" field.
+func (h *Hub) SendFrame(v any, userIDs ...string) {
+	frame, err := json.Marshal(v)
+	if err != nil {
+		h.logger.Printf("chat: marshal event: %v", err)
+		return
+	}
+	payload, err := json.Marshal(envelope{UserIDs: userIDs, Event: frame})
+	if err != nil {
+		h.logger.Printf("chat: marshal envelope: %v", err)
+		return
+	}
+	if err := h.bus.Publish(topicEvents, payload); err != nil {
+		// Users connected here can still be reached.
+		h.logger.Printf("chat: publish event: %v", err)
+		h.deliverLocal(frame, userIDs)
+	}
+}
+
+func (h *Hub) receiveEvent(payload []byte) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		h.logger.Printf("chat: bad event envelope: %v", err)
+		return
+	}
+	h.deliverLocal(env.Event, env.UserIDs)
+}
+
+// deliverLocal queues a marshalled event for the given users' connections
+// on this instance.
+func (h *Hub) deliverLocal(frame []byte, userIDs []string) {
+	h.mu.Lock()
+	var targets []*client
+	for _, id := range userIDs {
+		for c := range h.clients[id] {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range targets {
+		c.enqueue(frame)
+	}
+}
+
+// Disconnect closes every connection userID holds, on any instance, such as
+// when their sessions are revoked. Reconnecting is up to the auth
+// middleware.
+func (h *Hub) Disconnect(userID string) {
+	if err := h.bus.Publish(topicDisconnect, []byte(userID)); err != nil {
+		h.logger.Printf("chat: publish disconnect: %v", err)
+		h.disconnectLocal(userID)
+	}
+}
+
+func (h *Hub) receiveDisconnect(payload []byte) {
+	h.disconnectLocal(string(payload))
+}
+
+func (h *Hub) disconnectLocal(userID string) {
+	h.mu.Lock()
+	var targets []*client
+	for c := range h.clients[userID] {
+		targets = append(targets, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range targets {
+		c.closeWith(websocket.ClosePolicyViolation, "session revoked")
+	}
+}
+
+// Close disconnects every client with a "going away" close frame and waits
+// for their goroutines to finish. New connections are refused afterwards.
+func (h *Hub) Close() {
+	for _, unsubscribe := range h.unsubscribe {
+		unsubscribe()
+	}
+
+	h.mu.Lock()
+	h.closed = true
+	var all []*client
+	for _, set := range h.clients {
+		for c := range set {
+			all = append(all, c)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range all {
+		c.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.wg.Wait()
+}
+
+// sendEvent queues ev for this connection only.
+func (c *client) sendEvent(ev Event) {
+	frame, err := json.Marshal(ev)
+	if err != nil {
+		c.hub.logger.Printf("chat: marshal event: %v", err)
+		return
+	}
+	c.enqueue(frame)
+}
+
+func (c *client) enqueue(frame []byte) {
+	select {
+	case c.send <- frame:
+	case <-c.done:
+	default:
+		c.hub.logger.Printf("chat: dropping slow client for user %s", c.userID)
+		c.closeWith(websocket.ClosePolicyViolation, "too slow")
+	}
+}
+
+// closeWith asks writePump to send a best-effort close frame and tear the
+// connection down. It never writes itself, so it returns at once even for a
+// stalled connection: callers include the goroutines fanning events out to
+// everyone. Safe to call from any goroutine, any number of times.
+func (c *client) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMsg = websocket.FormatCloseMessage(code, reason)
+		close(c.done)
+	})
+}
+
+// abort tears the connection down without a close frame, for when a write
+// has already failed and another would only time out too.
+func (c *client) abort() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// readPump reads frames until the connection fails, passing each one to
+// handle. Pongs extend the read deadline.
+func (c *client) readPump(handle func(*client, []byte)) {
+	defer func() {
+		c.closeWith(websocket.CloseNormalClosure, "")
+		c.hub.unregister(c)
+	}()
+
+	c.conn.SetReadLimit(maxFrameSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.hub.logger.Printf("chat: read error for user %s: %v", c.userID, err)
+			}
+			return
+		}
+		handle(c, data)
+	}
+}
+
+// writePump drains the send queue and keeps the connection alive with pings.
+// It is the only writer: once the client is closed it sends the close frame,
+// if any, and closes the connection, which also ends readPump.
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			if c.closeMsg != nil {
+				_ = c.conn.WriteControl(websocket.CloseMessage, c.closeMsg, time.Now().Add(writeWait))
+			}
+			return
+		case frame := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				c.abort()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.abort()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/pubsub"
+)
+
+// hubServer serves a bare hub connection for ?user=ID, without auth.
+func hubServer(t *testing.T) (*Hub, string) {
+	t.Helper()
+	hub := NewHub(log.New(io.Discard, "", 0), pubsub.NewInProcess(), matches.NewInMemoryStore())
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c, ok := hub.register(r.URL.Query().Get("user"), conn)
+		if !ok {
+			_ = conn.Close()
+			return
+		}
+		go c.writePump()
+		c.readPump(func(*client, []byte) {})
+	}))
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url, userID string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?user="+userID, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", userID, err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// waitConnected waits until the hub holds a connection for userID.
+func waitConnected(t *testing.T, hub *Hub, userID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		hub.mu.Lock()
+		n := len(hub.clients[userID])
+		hub.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s never connected", userID)
+}
+
+func TestSlowClientDoesNotStallDelivery(t *testing.T) {
+	hub, url := hubServer(t)
+	_ = dial(t, url, "slow") // never reads
+	fast := dial(t, url, "fast")
+	waitConnected(t, hub, "slow")
+	waitConnected(t, hub, "fast")
+
+	hub.mu.Lock()
+	var slowClient *client
+	for c := range hub.clients["slow"] {
+		slowClient = c
+	}
+	hub.mu.Unlock()
+
+	// Big frames fill the slow client's socket buffers until its writePump
+	// blocks mid-write and its queue backs up.
+	big := []byte(`{"type":"x","pad":"` + strings.Repeat("a", 1<<20) + `"}`)
+	for i := 0; len(slowClient.send) < sendBuffer; i++ {
+		if i == 10000 {
+			t.Fatal("the slow client's queue never filled")
+		}
+		hub.deliverLocal(big, []string{"slow"})
+		time.Sleep(time.Millisecond)
+	}
+
+	// The next frame overflows the queue and drops the client.
+	start := time.Now()
+	hub.deliverLocal(big, []string{"slow"})
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("dropping a stalled client blocked delivery for %v", took)
+	}
+	select {
+	case <-slowClient.done:
+	default:
+		t.Fatal("the slow client wasn't dropped")
+	}
+
+	hub.deliverLocal([]byte(`{"type":"ping"}`), []string{"fast"})
+	_ = fast.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, data, err := fast.ReadMessage(); err != nil || string(data) != `{"type":"ping"}` {
+		t.Fatalf("fast client read %q, %v", data, err)
+	}
+}
+
+func TestCloseFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		close  func(hub *Hub)
+		code   int
+		reason string
+	}{
+		{"disconnect", func(hub *Hub) { hub.Disconnect("u1") }, websocket.ClosePolicyViolation, "session revoked"},
+		{"shutdown", func(hub *Hub) { hub.Close() }, websocket.CloseGoingAway, "server shutting down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, url := hubServer(t)
+			conn := dial(t, url, "u1")
+			waitConnected(t, hub, "u1")
+
+			tt.close(hub)
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			var err error
+			for err == nil {
+				_, _, err = conn.ReadMessage()
+			}
+			var ce *websocket.CloseError
+			if !errors.As(err, &ce) {
+				t.Fatalf("read error = %v, want a close frame", err)
+			}
+			if ce.Code != tt.code || ce.Text != tt.reason {
+				t.Errorf("close frame %d %q, want %d %q", ce.Code, ce.Text, tt.code, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/media"
+)
+
+// maxImageBytes caps photo uploads.
+const maxImageBytes = 10 << 20
+
+// maxMinTextMessages bounds the photo gate a participant can set.
+const maxMinTextMessages = 100
+
+// imagesAllowed reports whether senderID may send photos in m yet, given
+// the recipient's settings. When not, it returns the threshold in force.
+func (h *Handler) imagesAllowed(m *matches.Match, senderID string) (bool, int, error) {
+	settings, err := h.messages.Settings(m.ID, m.Other(senderID))
+	if err != nil {
+		return false, 0, err
+	}
+	need := settings.MinTextMessagesForImages
+	if need <= 0 {
+		return true, 0, nil
+	}
+	counts, err := h.messages.CountTextMessages(m.ID)
+	if err != nil {
+		return false, 0, err
+	}
+	return counts[m.UserAID] >= need && counts[m.UserBID] >= need, need, nil
+}
+
+// SendImage handles POST /v1/conversations/{id}/images?clientId=...[&blurred=true][&replyToId=...]
+//
+// The request body is the raw JPEG or PNG. With blurred=true the recipient
+// first receives only a tiny preview and must reveal the photo to load it.
+// If the recipient has set minTextMessagesForImages and it hasn't been met,
+// the upload is refused with 403.
+func (h *Handler) SendImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	clientID, err := uploadClientID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if media.NormalizeImageType(r.Header.Get("Content-Type")) == "" {
+		httpjson.Error(w, http.StatusUnsupportedMediaType, errors.New("photos must be image/jpeg or image/png"))
+		return
+	}
+	blurred, _ := strconv.ParseBool(r.URL.Query().Get("blurred"))
+
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+
+	// Check consent before accepting the bytes.
+	allowed, need, err := h.imagesAllowed(m, userID)
+	if err != nil {
+		h.logger.Printf("SendImage error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not send photo"))
+		return
+	}
+	if !allowed {
+		noun := "messages"
+		if need == 1 {
+			noun = "message"
+		}
+		httpjson.Error(w, http.StatusForbidden,
+			fmt.Errorf("photos are accepted here once you've each sent %d text %s", need, noun))
+		return
+	}
+
+	data, ok := readUpload(w, r, maxImageBytes, "photo")
+	if !ok {
+		return
+	}
+	info, err := media.ProbeImage(data)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("could not read photo: "+err.Error()))
+		return
+	}
+
+	att := &Attachment{
+		Key:         "chat/" + m.ID + "/" + idgen.New(),
+		ContentType: info.ContentType,
+		Size:        int64(len(data)),
+		Width:       info.Width,
+		Height:      info.Height,
+		Blurred:     blurred,
+	}
+	var written []string
+	if blurred {
+		preview, err := media.BlurPreview(data)
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("could not read photo: "+err.Error()))
+			return
+		}
+		if err := h.blobs.Put(previewKey(att.Key), media.ImageJPEG, preview); err != nil {
+			h.logger.Printf("SendImage upload error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("could not store photo"))
+			return
+		}
+		written = append(written, previewKey(att.Key))
+	}
+	if err := h.blobs.Put(att.Key, info.ContentType, data); err != nil {
+		h.logger.Printf("SendImage upload error: %v", err)
+		for _, key := range written {
+			_ = h.blobs.Delete(key)
+		}
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not store photo"))
+		return
+	}
+
+	h.postMedia(w, r, m, Message{
+		SenderID:   userID,
+		ClientID:   clientID,
+		Kind:       KindImage,
+		ReplyToID:  r.URL.Query().Get("replyToId"),
+		Attachment: att,
+	}, att.blobKeys()...)
+}
+
+// Reveal handles POST /v1/conversations/{id}/messages/{messageId}/reveal
+//
+// The recipient of a blurred photo calls this when they tap it. The
+// response carries the full photo URL, and the sender is told it was seen.
+func (h *Handler) Reveal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	m, msg, ok := h.loadMessage(w, r, userID)
+	if !ok {
+		return
+	}
+	if msg.DeletedAt != nil {
+		httpjson.Error(w, http.StatusConflict, errMessageDeleted)
+		return
+	}
+	if msg.Attachment == nil || !msg.Attachment.Blurred {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("message is not a blurred photo"))
+		return
+	}
+	if msg.SenderID == userID {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("only the recipient can reveal a photo"))
+		return
+	}
+
+	first := msg.RevealedAt == nil
+	revealed, err := h.messages.Reveal(m.ID, msg.ID, time.Now().UTC())
+	if err != nil {
+		h.logger.Printf("Reveal error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not reveal photo"))
+		return
+	}
+	if first {
+		h.deliverAs(EventMessageRevealed, revealed, msg.SenderID, userID)
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"message": h.present(revealed, userID)})
+}
+
+// Settings handles /v1/conversations/{id}/settings
+//
+//	GET                                   the caller's settings
+//	PUT {"minTextMessagesForImages": 5}   replace them
+func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req ConversationSettings
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+		if req.MinTextMessagesForImages < 0 || req.MinTextMessagesForImages > maxMinTextMessages {
+			httpjson.Error(w, http.StatusBadRequest,
+				fmt.Errorf("minTextMessagesForImages must be between 0 and %d", maxMinTextMessages))
+			return
+		}
+	}
+
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if err := h.messages.UpdateSettings(m.ID, userID, req); err != nil {
+			h.logger.Printf("UpdateSettings error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("could not save settings"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"settings": req})
+		return
+	}
+
+	settings, err := h.messages.Settings(m.ID, userID)
+	if err != nil {
+		h.logger.Printf("Settings error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load settings"))
+		return
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"settings": settings})
+}
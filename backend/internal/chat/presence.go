@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Presence heartbeats. Every instance periodically publishes the users it
+// holds connections for; an instance that stops (or crashes) stops sending
+// them, and its users are considered offline once presenceTTL passes.
+const (
+	PresenceHeartbeat = 30 * time.Second
+	presenceTTL       = 3 * PresenceHeartbeat
+)
+
+const (
+	presenceChange    = "change"
+	presenceHeartbeat = "heartbeat"
+)
+
+// presenceMessage is what instances exchange on topicPresence.
+type presenceMessage struct {
+	Kind   string `json:"kind"`
+	NodeID string `json:"nodeId"`
+
+	// change
+	UserID string   `json:"userId,omitempty"`
+	Online bool     `json:"online,omitempty"`
+	Notify []string `json:"notify,omitempty"`
+
+	// heartbeat
+	Users []string `json:"users,omitempty"`
+}
+
+// announce tells every instance that userID came online or went offline
+// here, and who should hear about it.
+func (h *Hub) announce(userID string, online bool) {
+	var notify []string
+	active, err := h.matches.ListActive(userID)
+	if err != nil {
+		h.logger.Printf("chat: presence audience for %s: %v", userID, err)
+	}
+	for _, m := range active {
+		notify = append(notify, m.Other(userID))
+	}
+
+	h.publishPresence(presenceMessage{
+		Kind:   presenceChange,
+		NodeID: h.nodeID,
+		UserID: userID,
+		Online: online,
+		Notify: notify,
+	})
+}
+
+func (h *Hub) publishPresence(msg presenceMessage) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Printf("chat: marshal presence: %v", err)
+		return
+	}
+	if err := h.bus.Publish(topicPresence, payload); err != nil {
+		// Keep this instance's own view correct.
+		h.logger.Printf("chat: publish presence: %v", err)
+		h.receivePresence(payload)
+	}
+}
+
+func (h *Hub) receivePresence(payload []byte) {
+	var msg presenceMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		h.logger.Printf("chat: bad presence message: %v", err)
+		return
+	}
+	now := time.Now()
+
+	switch msg.Kind {
+	case presenceHeartbeat:
+		h.mu.Lock()
+		for _, id := range msg.Users {
+			h.markSeenLocked(id, msg.NodeID, now)
+		}
+		h.mu.Unlock()
+
+	case presenceChange:
+		h.mu.Lock()
+		// Transitions are judged on what the instances reported, not on our
+		// own connections: those already changed before the announcement.
+		before := h.seenLocked(msg.UserID, now)
+		if msg.Online {
+			h.markSeenLocked(msg.UserID, msg.NodeID, now)
+		} else if nodes := h.seen[msg.UserID]; nodes != nil {
+			delete(nodes, msg.NodeID)
+			if len(nodes) == 0 {
+				delete(h.seen, msg.UserID)
+			}
+		}
+		after := h.seenLocked(msg.UserID, now)
+		h.mu.Unlock()
+
+		// A second device connecting, or one of several disconnecting,
+		// isn't news.
+		if before == after {
+			return
+		}
+		online := after
+		frame, err := json.Marshal(Event{Type: EventPresence, UserID: msg.UserID, Online: &online})
+		if err != nil {
+			h.logger.Printf("chat: marshal event: %v", err)
+			return
+		}
+		h.deliverLocal(frame, msg.Notify)
+	}
+}
+
+func (h *Hub) markSeenLocked(userID, nodeID string, at time.Time) {
+	nodes, ok := h.seen[userID]
+	if !ok {
+		nodes = make(map[string]time.Time)
+		h.seen[userID] = nodes
+	}
+	nodes[nodeID] = at
+}
+
+// seenLocked reports whether any instance recently said userID is online.
+func (h *Hub) seenLocked(userID string, now time.Time) bool {
+	for _, at := range h.seen[userID] {
+		if now.Sub(at) < presenceTTL {
+			return true
+		}
+	}
+	return false
+}
+
+// Online reports whether userID has a live connection on any instance.
+func (h *Hub) Online(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients[userID]) > 0 || h.seenLocked(userID, time.Now())
+}
+
+// RunPresence publishes heartbeats for this instance's users and forgets
+// instances that stopped sending them, until ctx is cancelled.
+func (h *Hub) RunPresence(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		h.mu.Lock()
+		users := make([]string, 0, len(h.clients))
+		for id := range h.clients {
+			users = append(users, id)
+		}
+		for id, nodes := range h.seen {
+			for node, at := range nodes {
+				if now.Sub(at) >= presenceTTL {
+					delete(nodes, node)
+				}
+			}
+			if len(nodes) == 0 {
+				delete(h.seen, id)
+			}
+		}
+		h.mu.Unlock()
+
+		if len(users) > 0 {
+			h.publishPresence(presenceMessage{Kind: presenceHeartbeat, NodeID: h.nodeID, Users: users})
+		}
+	}
+}
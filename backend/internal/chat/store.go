@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/matches"
+)
+
+// MessageStore persists chat history.
+type MessageStore interface {
+	// Append stores msg, assigning its sequence number. When msg.ClientID is
+	// set, a retry with the same sender and client ID returns the original
+	// message with created=false instead of storing a duplicate. A
+	// ReplyToID must name a message in the same conversation, otherwise
+	// ErrMessageNotFound is returned.
+	Append(msg Message) (stored *Message, created bool, err error)
+	// Get returns one message, or ErrMessageNotFound.
+	Get(conversationID, messageID string) (*Message, error)
+	// GetByClientID returns the message senderID stored with clientID, or
+	// ErrMessageNotFound.
+	GetByClientID(conversationID, senderID, clientID string) (*Message, error)
+	// List returns up to limit messages from a conversation, newest first.
+	// When before is a message ID, only older messages are returned. more
+	// reports whether older messages remain.
+	List(conversationID, before string, limit int) (msgs []Message, more bool, err error)
+	// Edit replaces a message's text and stamps EditedAt. Only live text
+	// messages can be edited: it returns ErrMessageNotFound for a message
+	// that was deleted, even concurrently, or isn't text.
+	Edit(conversationID, messageID, text string, at time.Time) (*Message, error)
+	// Delete turns a message into a tombstone: text and attachment are
+	// cleared, reactions removed and DeletedAt set.
+	Delete(conversationID, messageID string, at time.Time) (*Message, error)
+	// SetReaction sets userID's reaction on a message, replacing any earlier
+	// one. An empty emoji removes it.
+	SetReaction(conversationID, messageID, userID, emoji string, at time.Time) error
+	// Reveal records that the recipient opened a blurred photo. Revealing
+	// twice keeps the first time.
+	Reveal(conversationID, messageID string, at time.Time) (*Message, error)
+	// CountTextMessages returns how many live text messages each
+	// participant has sent.
+	CountTextMessages(conversationID string) (map[string]int, error)
+	// Settings returns userID's settings for a conversation, or the zero
+	// value if they never changed them.
+	Settings(conversationID, userID string) (ConversationSettings, error)
+	UpdateSettings(conversationID, userID string, settings ConversationSettings) error
+	// MarkRead records that userID has read up to and including messageID.
+	// Read positions only move forward.
+	MarkRead(conversationID, userID, messageID string) error
+	// Summaries returns the last message and userID's unread count for each
+	// conversation; it satisfies matches.ConversationSummarizer.
+	Summaries(userID string, conversationIDs []string) (map[string]matches.ConversationSummary, error)
+	// DeleteConversation removes a conversation's messages, reactions,
+	// read positions and settings. Attachments stay in the BlobStore.
+	DeleteConversation(conversationID string) error
+}
+
+// ErrMessageNotFound is returned when a cursor or read receipt refers to a
+// message that isn't in the conversation.
+var ErrMessageNotFound = errors.New("message not found")
+
+// previewLength is how much text a list preview carries.
+const previewLength = 80
+
+// previewText shortens a message's text for a preview. Soft-hidden
+// messages have none.
+func previewText(m *Message) string {
+	if m.HiddenReason != "" {
+		return ""
+	}
+	text := m.Text
+	if r := []rune(text); len(r) > previewLength {
+		text = string(r[:previewLength]) + "…"
+	}
+	return text
+}
+
+func toReplyPreview(m *Message) *ReplyPreview {
+	return &ReplyPreview{
+		ID:       m.ID,
+		SenderID: m.SenderID,
+		Kind:     m.Kind,
+		Text:     previewText(m),
+		Deleted:  m.DeletedAt != nil,
+		Hidden:   m.HiddenReason != "",
+	}
+}
+
+func toPreview(m *Message) *matches.MessagePreview {
+	return &matches.MessagePreview{
+		ID:       m.ID,
+		SenderID: m.SenderID,
+		Kind:     m.Kind,
+		Text:     previewText(m),
+		Hidden:   m.HiddenReason != "",
+		SentAt:   m.SentAt,
+	}
+}
@@ -0,0 +1,287 @@
+package chat
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/matches"
+)
+
+type memoryMessageStore struct {
+	mu        sync.Mutex
+	seq       int64
+	convs     map[string][]*Message          // conversation -> messages, oldest first
+	byClient  map[[3]string]*Message         // (conversation, sender, clientID)
+	reads     map[[2]string]int64            // (conversation, user) -> last read seq
+	reactions map[string]map[string]Reaction // message -> user -> reaction
+	settings  map[[2]string]ConversationSettings
+}
+
+// NewInMemoryMessageStore returns an in-memory MessageStore.
+func NewInMemoryMessageStore() MessageStore {
+	return &memoryMessageStore{
+		convs:     make(map[string][]*Message),
+		byClient:  make(map[[3]string]*Message),
+		reads:     make(map[[2]string]int64),
+		reactions: make(map[string]map[string]Reaction),
+		settings:  make(map[[2]string]ConversationSettings),
+	}
+}
+
+// view returns a copy of m with its reply preview and reactions filled in.
+// Callers hold s.mu.
+func (s *memoryMessageStore) view(m *Message) *Message {
+	out := *m
+	if out.ReplyToID != "" {
+		if i := s.indexOf(m.ConversationID, out.ReplyToID); i >= 0 {
+			out.ReplyTo = toReplyPreview(s.convs[m.ConversationID][i])
+		}
+	}
+	out.Reactions = nil
+	for _, r := range s.reactions[m.ID] {
+		out.Reactions = append(out.Reactions, r)
+	}
+	sort.Slice(out.Reactions, func(i, j int) bool {
+		return out.Reactions[i].CreatedAt.Before(out.Reactions[j].CreatedAt)
+	})
+	return &out
+}
+
+func (s *memoryMessageStore) Append(msg Message) (*Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [3]string{msg.ConversationID, msg.SenderID, msg.ClientID}
+	if msg.ClientID != "" {
+		if existing, ok := s.byClient[key]; ok {
+			return s.view(existing), false, nil
+		}
+	}
+	if msg.ReplyToID != "" && s.indexOf(msg.ConversationID, msg.ReplyToID) < 0 {
+		return nil, false, ErrMessageNotFound
+	}
+
+	s.seq++
+	stored := msg
+	stored.Seq = s.seq
+	stored.ReplyTo = nil
+	stored.Reactions = nil
+	s.convs[msg.ConversationID] = append(s.convs[msg.ConversationID], &stored)
+	if msg.ClientID != "" {
+		s.byClient[key] = &stored
+	}
+	return s.view(&stored), true, nil
+}
+
+func (s *memoryMessageStore) GetByClientID(conversationID, senderID, clientID string) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.byClient[[3]string{conversationID, senderID, clientID}]
+	if !ok || clientID == "" {
+		return nil, ErrMessageNotFound
+	}
+	return s.view(m), nil
+}
+
+// indexOf finds a message's position in a conversation.
+func (s *memoryMessageStore) indexOf(conversationID, messageID string) int {
+	for i, m := range s.convs[conversationID] {
+		if m.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *memoryMessageStore) find(conversationID, messageID string) (*Message, error) {
+	i := s.indexOf(conversationID, messageID)
+	if i < 0 {
+		return nil, ErrMessageNotFound
+	}
+	return s.convs[conversationID][i], nil
+}
+
+func (s *memoryMessageStore) Get(conversationID, messageID string) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(m), nil
+}
+
+func (s *memoryMessageStore) List(conversationID, before string, limit int) ([]Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := s.convs[conversationID]
+	end := len(msgs)
+	if before != "" {
+		end = s.indexOf(conversationID, before)
+		if end < 0 {
+			return nil, false, ErrMessageNotFound
+		}
+	}
+
+	var out []Message
+	for i := end - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, *s.view(msgs[i]))
+	}
+	more := end-len(out) > 0
+	return out, more, nil
+}
+
+func (s *memoryMessageStore) Edit(conversationID, messageID, text string, at time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if m.DeletedAt != nil || m.Kind != KindText {
+		return nil, ErrMessageNotFound
+	}
+	m.Text = text
+	m.EditedAt = &at
+	return s.view(m), nil
+}
+
+func (s *memoryMessageStore) Delete(conversationID, messageID string, at time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	m.Text = ""
+	m.Attachment = nil
+	m.DeletedAt = &at
+	delete(s.reactions, m.ID)
+	return s.view(m), nil
+}
+
+func (s *memoryMessageStore) SetReaction(conversationID, messageID, userID, emoji string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(conversationID, messageID)
+	if err != nil {
+		return err
+	}
+	if emoji == "" {
+		delete(s.reactions[m.ID], userID)
+		return nil
+	}
+	set, ok := s.reactions[m.ID]
+	if !ok {
+		set = make(map[string]Reaction)
+		s.reactions[m.ID] = set
+	}
+	set[userID] = Reaction{UserID: userID, Emoji: emoji, CreatedAt: at}
+	return nil
+}
+
+func (s *memoryMessageStore) Reveal(conversationID, messageID string, at time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if m.RevealedAt == nil {
+		m.RevealedAt = &at
+	}
+	return s.view(m), nil
+}
+
+func (s *memoryMessageStore) CountTextMessages(conversationID string) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, m := range s.convs[conversationID] {
+		if m.Kind == KindText && m.DeletedAt == nil {
+			counts[m.SenderID]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *memoryMessageStore) Settings(conversationID, userID string) (ConversationSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[[2]string{conversationID, userID}], nil
+}
+
+func (s *memoryMessageStore) UpdateSettings(conversationID, userID string, settings ConversationSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[[2]string{conversationID, userID}] = settings
+	return nil
+}
+
+func (s *memoryMessageStore) MarkRead(conversationID, userID, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(conversationID, messageID)
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	key := [2]string{conversationID, userID}
+	if seq := s.convs[conversationID][i].Seq; seq > s.reads[key] {
+		s.reads[key] = seq
+	}
+	return nil
+}
+
+func (s *memoryMessageStore) Summaries(userID string, conversationIDs []string) (map[string]matches.ConversationSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]matches.ConversationSummary, len(conversationIDs))
+	for _, id := range conversationIDs {
+		msgs := s.convs[id]
+		if len(msgs) == 0 {
+			continue
+		}
+		readSeq := s.reads[[2]string{id, userID}]
+		unread := 0
+		for _, m := range msgs {
+			if m.SenderID != userID && m.Seq > readSeq {
+				unread++
+			}
+		}
+		out[id] = matches.ConversationSummary{
+			LastMessage: toPreview(msgs[len(msgs)-1]),
+			UnreadCount: unread,
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryMessageStore) DeleteConversation(conversationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.convs[conversationID] {
+		delete(s.reactions, m.ID)
+	}
+	delete(s.convs, conversationID)
+	for key := range s.byClient {
+		if key[0] == conversationID {
+			delete(s.byClient, key)
+		}
+	}
+	for key := range s.reads {
+		if key[0] == conversationID {
+			delete(s.reads, key)
+		}
+	}
+	for key := range s.settings {
+		if key[0] == conversationID {
+			delete(s.settings, key)
+		}
+	}
+	return nil
+}
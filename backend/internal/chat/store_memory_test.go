@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEditOnlyLiveText(t *testing.T) {
+	s := NewInMemoryMessageStore()
+	now := time.Now()
+	for _, m := range []Message{
+		{ID: "text", ConversationID: "c1", SenderID: "u1", Kind: KindText, Text: "hi", SentAt: now},
+		{ID: "deleted", ConversationID: "c1", SenderID: "u1", Kind: KindText, Text: "oops", SentAt: now},
+		{ID: "voice", ConversationID: "c1", SenderID: "u1", Kind: KindAudio, SentAt: now},
+	} {
+		if _, _, err := s.Append(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Delete("c1", "deleted", now); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   string
+		want error
+	}{
+		{"text", nil},
+		{"deleted", ErrMessageNotFound},
+		{"voice", ErrMessageNotFound},
+		{"missing", ErrMessageNotFound},
+	}
+	for _, tt := range tests {
+		m, err := s.Edit("c1", tt.id, "edited", now)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Edit(%s) error = %v, want %v", tt.id, err, tt.want)
+		}
+		if err == nil && (m.Text != "edited" || m.EditedAt == nil) {
+			t.Errorf("Edit(%s) = %+v, want edited text and EditedAt", tt.id, m)
+		}
+	}
+	if m, _ := s.Get("c1", "deleted"); m.Text != "" || m.EditedAt != nil {
+		t.Errorf("the tombstone was edited: %+v", m)
+	}
+}
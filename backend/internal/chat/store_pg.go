@@ -0,0 +1,489 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/matches"
+)
+
+// pgMessageStore persists chat history in the messages and
+// conversation_reads tables (sql/0007_messages.sql).
+type pgMessageStore struct {
+	db *sql.DB
+}
+
+// NewPGMessageStore constructs a MessageStore backed by Postgres.
+func NewPGMessageStore(db *sql.DB) MessageStore {
+	return &pgMessageStore{db: db}
+}
+
+const messageColumns = `id, seq, conversation_id, sender_id, COALESCE(client_id, ''), kind, text,
+	COALESCE(media_key, ''), attachment, COALESCE(reply_to_id, ''), sent_at, edited_at, deleted_at, revealed_at,
+	hidden_reason`
+
+// scanMessage scans messageColumns plus any extra destinations.
+func scanMessage(row interface{ Scan(...any) error }, extra ...any) (*Message, error) {
+	var (
+		m          Message
+		mediaKey   string
+		attachment []byte
+	)
+	dest := append([]any{&m.ID, &m.Seq, &m.ConversationID, &m.SenderID, &m.ClientID,
+		&m.Kind, &m.Text, &mediaKey, &attachment, &m.ReplyToID, &m.SentAt, &m.EditedAt, &m.DeletedAt, &m.RevealedAt,
+		&m.HiddenReason}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if attachment != nil {
+		m.Attachment = &Attachment{}
+		if err := json.Unmarshal(attachment, m.Attachment); err != nil {
+			return nil, err
+		}
+		m.Attachment.Key = mediaKey
+	}
+	return &m, nil
+}
+
+// attachmentArgs returns the media_key and attachment column values.
+func attachmentArgs(a *Attachment) (any, any, error) {
+	if a == nil {
+		return nil, nil, nil
+	}
+	stored := *a
+	stored.URL = ""
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.Key, b, nil
+}
+
+// hydrate fills in reply previews and reactions for msgs.
+func (s *pgMessageStore) hydrate(ctx context.Context, msgs ...*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	byID := make(map[string]*Message, len(msgs))
+	var (
+		ids      []any
+		replyIDs []any
+	)
+	for _, m := range msgs {
+		byID[m.ID] = m
+		ids = append(ids, m.ID)
+		if m.ReplyToID != "" {
+			replyIDs = append(replyIDs, m.ReplyToID)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT message_id, user_id, emoji, created_at FROM message_reactions
+		WHERE message_id IN (`+placeholders(1, len(ids))+`)
+		ORDER BY created_at
+	`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			messageID string
+			r         Reaction
+		)
+		if err := rows.Scan(&messageID, &r.UserID, &r.Emoji, &r.CreatedAt); err != nil {
+			return err
+		}
+		if m := byID[messageID]; m != nil {
+			m.Reactions = append(m.Reactions, r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(replyIDs) == 0 {
+		return nil
+	}
+	quoted := make(map[string]*ReplyPreview, len(replyIDs))
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT `+messageColumns+` FROM messages
+		WHERE id IN (`+placeholders(1, len(replyIDs))+`)
+	`, replyIDs...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		q, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		quoted[q.ID] = toReplyPreview(q)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if m.ReplyToID != "" {
+			m.ReplyTo = quoted[m.ReplyToID]
+		}
+	}
+	return nil
+}
+
+// placeholders returns "$from, $from+1, ..." for n arguments.
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = "$" + strconv.Itoa(from+i)
+	}
+	return strings.Join(ps, ", ")
+}
+
+func (s *pgMessageStore) Append(msg Message) (*Message, bool, error) {
+	ctx := context.Background()
+
+	mediaKey, attachment, err := attachmentArgs(msg.Attachment)
+	if err != nil {
+		return nil, false, err
+	}
+	if msg.ReplyToID != "" {
+		if _, err := s.seqOf(ctx, msg.ConversationID, msg.ReplyToID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	created := true
+	stored, err := scanMessage(s.db.QueryRowContext(ctx, `
+		INSERT INTO messages (id, conversation_id, sender_id, client_id, kind, text, media_key, attachment, reply_to_id, sent_at,
+			hidden_reason)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, NULLIF($9, ''), $10, $11)
+		ON CONFLICT (conversation_id, sender_id, client_id) DO NOTHING
+		RETURNING `+messageColumns,
+		msg.ID, msg.ConversationID, msg.SenderID, msg.ClientID, msg.Kind, msg.Text, mediaKey, attachment,
+		msg.ReplyToID, msg.SentAt, msg.HiddenReason))
+	if errors.Is(err, sql.ErrNoRows) {
+		// A retry of a message we already stored.
+		created = false
+		stored, err = scanMessage(s.db.QueryRowContext(ctx, `
+			SELECT `+messageColumns+` FROM messages
+			WHERE conversation_id = $1 AND sender_id = $2 AND client_id = $3
+		`, msg.ConversationID, msg.SenderID, msg.ClientID))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.hydrate(ctx, stored); err != nil {
+		return nil, false, err
+	}
+	return stored, created, nil
+}
+
+func (s *pgMessageStore) Get(conversationID, messageID string) (*Message, error) {
+	ctx := context.Background()
+	m, err := scanMessage(s.db.QueryRowContext(ctx, `
+		SELECT `+messageColumns+` FROM messages WHERE conversation_id = $1 AND id = $2
+	`, conversationID, messageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hydrate(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *pgMessageStore) GetByClientID(conversationID, senderID, clientID string) (*Message, error) {
+	ctx := context.Background()
+	m, err := scanMessage(s.db.QueryRowContext(ctx, `
+		SELECT `+messageColumns+` FROM messages
+		WHERE conversation_id = $1 AND sender_id = $2 AND client_id = $3
+	`, conversationID, senderID, clientID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hydrate(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *pgMessageStore) Edit(conversationID, messageID, text string, at time.Time) (*Message, error) {
+	res, err := s.db.ExecContext(context.Background(), `
+		UPDATE messages SET text = $3, edited_at = $4
+		WHERE conversation_id = $1 AND id = $2 AND deleted_at IS NULL AND kind = 'text'
+	`, conversationID, messageID, text, at)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return s.Get(conversationID, messageID)
+}
+
+func (s *pgMessageStore) Delete(conversationID, messageID string, at time.Time) (*Message, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE messages SET text = '', media_key = NULL, attachment = NULL, deleted_at = $3
+		WHERE conversation_id = $1 AND id = $2
+	`, conversationID, messageID, at)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrMessageNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM message_reactions WHERE message_id = $1`, messageID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(conversationID, messageID)
+}
+
+func (s *pgMessageStore) SetReaction(conversationID, messageID, userID, emoji string, at time.Time) error {
+	ctx := context.Background()
+	if _, err := s.seqOf(ctx, conversationID, messageID); err != nil {
+		return err
+	}
+	if emoji == "" {
+		_, err := s.db.ExecContext(ctx, `
+			DELETE FROM message_reactions WHERE message_id = $1 AND user_id = $2
+		`, messageID, userID)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id, user_id)
+		DO UPDATE SET emoji = EXCLUDED.emoji, created_at = EXCLUDED.created_at
+	`, messageID, userID, emoji, at)
+	return err
+}
+
+// seqOf resolves a message ID to its sequence number within a conversation.
+func (s *pgMessageStore) seqOf(ctx context.Context, conversationID, messageID string) (int64, error) {
+	var seq int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT seq FROM messages WHERE conversation_id = $1 AND id = $2
+	`, conversationID, messageID).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrMessageNotFound
+	}
+	return seq, err
+}
+
+func (s *pgMessageStore) List(conversationID, before string, limit int) ([]Message, bool, error) {
+	ctx := context.Background()
+
+	// Sequence numbers only grow, so "older than the cursor" is stable even
+	// while new messages arrive.
+	var beforeSeq int64 = 1<<63 - 1
+	if before != "" {
+		seq, err := s.seqOf(ctx, conversationID, before)
+		if err != nil {
+			return nil, false, err
+		}
+		beforeSeq = seq
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+messageColumns+` FROM messages
+		WHERE conversation_id = $1 AND seq < $2
+		ORDER BY seq DESC
+		LIMIT $3
+	`, conversationID, beforeSeq, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var out []Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(out) > limit
+	if more {
+		out = out[:limit]
+	}
+	ptrs := make([]*Message, len(out))
+	for i := range out {
+		ptrs[i] = &out[i]
+	}
+	if err := s.hydrate(ctx, ptrs...); err != nil {
+		return nil, false, err
+	}
+	return out, more, nil
+}
+
+func (s *pgMessageStore) Reveal(conversationID, messageID string, at time.Time) (*Message, error) {
+	res, err := s.db.ExecContext(context.Background(), `
+		UPDATE messages SET revealed_at = COALESCE(revealed_at, $3)
+		WHERE conversation_id = $1 AND id = $2
+	`, conversationID, messageID, at)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return s.Get(conversationID, messageID)
+}
+
+func (s *pgMessageStore) CountTextMessages(conversationID string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT sender_id, count(*) FROM messages
+		WHERE conversation_id = $1 AND kind = $2 AND deleted_at IS NULL
+		GROUP BY sender_id
+	`, conversationID, KindText)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			senderID string
+			n        int
+		)
+		if err := rows.Scan(&senderID, &n); err != nil {
+			return nil, err
+		}
+		counts[senderID] = n
+	}
+	return counts, rows.Err()
+}
+
+func (s *pgMessageStore) Settings(conversationID, userID string) (ConversationSettings, error) {
+	var settings ConversationSettings
+	err := s.db.QueryRowContext(context.Background(), `
+		SELECT min_text_messages_for_images FROM conversation_settings
+		WHERE conversation_id = $1 AND user_id = $2
+	`, conversationID, userID).Scan(&settings.MinTextMessagesForImages)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ConversationSettings{}, nil
+	}
+	return settings, err
+}
+
+func (s *pgMessageStore) UpdateSettings(conversationID, userID string, settings ConversationSettings) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO conversation_settings (conversation_id, user_id, min_text_messages_for_images)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id)
+		DO UPDATE SET min_text_messages_for_images = EXCLUDED.min_text_messages_for_images,
+		              updated_at = now()
+	`, conversationID, userID, settings.MinTextMessagesForImages)
+	return err
+}
+
+func (s *pgMessageStore) MarkRead(conversationID, userID, messageID string) error {
+	ctx := context.Background()
+
+	seq, err := s.seqOf(ctx, conversationID, messageID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO conversation_reads (conversation_id, user_id, last_read_seq)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id)
+		DO UPDATE SET last_read_seq = GREATEST(conversation_reads.last_read_seq, EXCLUDED.last_read_seq),
+		              updated_at = now()
+	`, conversationID, userID, seq)
+	return err
+}
+
+func (s *pgMessageStore) Summaries(userID string, conversationIDs []string) (map[string]matches.ConversationSummary, error) {
+	out := make(map[string]matches.ConversationSummary, len(conversationIDs))
+	if len(conversationIDs) == 0 {
+		return out, nil
+	}
+
+	args := []any{userID}
+	for _, id := range conversationIDs {
+		args = append(args, id)
+	}
+	in := placeholders(2, len(conversationIDs))
+
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+messageColumns+`,
+		       (SELECT count(*) FROM messages u
+		        WHERE u.conversation_id = last.conversation_id
+		          AND u.sender_id <> $1
+		          AND u.seq > COALESCE((SELECT r.last_read_seq FROM conversation_reads r
+		                                WHERE r.conversation_id = last.conversation_id AND r.user_id = $1), 0))
+		FROM (
+			SELECT DISTINCT ON (conversation_id) *
+			FROM messages
+			WHERE conversation_id IN (`+in+`)
+			ORDER BY conversation_id, seq DESC
+		) last
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var unread int
+		m, err := scanMessage(rows, &unread)
+		if err != nil {
+			return nil, err
+		}
+		out[m.ConversationID] = matches.ConversationSummary{
+			LastMessage: toPreview(m),
+			UnreadCount: unread,
+		}
+	}
+	return out, rows.Err()
+}
+
+// DeleteConversation deletes the messages, whose reactions go with them,
+// along with the conversation's read positions and settings.
+func (s *pgMessageStore) DeleteConversation(conversationID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM messages WHERE conversation_id = $1`,
+		`DELETE FROM conversation_reads WHERE conversation_id = $1`,
+		`DELETE FROM conversation_settings WHERE conversation_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, conversationID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
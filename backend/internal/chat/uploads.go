@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/matches"
+)
+
+// uploadClientID reads the clientId query parameter media uploads use for
+// idempotent retries.
+func uploadClientID(r *http.Request) (string, error) {
+	clientID := strings.TrimSpace(r.URL.Query().Get("clientId"))
+	if clientID == "" {
+		return "", errors.New("clientId is required")
+	}
+	if len(clientID) > maxClientIDLength {
+		return "", errors.New("clientId is too long")
+	}
+	return clientID, nil
+}
+
+// readUpload reads a raw upload body of at most limit bytes, writing an
+// error response on failure.
+func readUpload(w http.ResponseWriter, r *http.Request, limit int64, what string) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			httpjson.Error(w, http.StatusRequestEntityTooLarge, errors.New(what+" is too large"))
+			return nil, false
+		}
+		httpjson.Error(w, http.StatusBadRequest, errors.New("could not read upload"))
+		return nil, false
+	}
+	return data, true
+}
+
+// postMedia stores a message whose blobs are already uploaded and writes the
+// response. The blobs are removed again if the message isn't stored, or if
+// it turned out to be a retry of one that already was.
+func (h *Handler) postMedia(w http.ResponseWriter, r *http.Request, m *matches.Match, msg Message, blobKeys ...string) {
+	stored, created, err := h.post(r.Context(), m, msg)
+	if err != nil || !created {
+		for _, key := range blobKeys {
+			if derr := h.blobs.Delete(key); derr != nil {
+				h.logger.Printf("chat: upload cleanup error: %v", derr)
+			}
+		}
+	}
+	if errors.Is(err, ErrMessageNotFound) {
+		httpjson.Error(w, http.StatusBadRequest, errReplyNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("chat: store %s message: %v", msg.Kind, err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not send message"))
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	httpjson.Write(w, status, map[string]any{"message": h.present(stored, msg.SenderID)})
+}
@@ -0,0 +1,94 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/media"
+)
+
+// Voice note limits.
+const (
+	maxVoiceBytes    = 5 << 20
+	minVoiceDuration = 500 * time.Millisecond
+	maxVoiceDuration = 2 * time.Minute
+)
+
+// SendVoice handles POST /v1/conversations/{id}/voice?clientId=...[&replyToId=...]
+//
+// The request body is the raw recording, with Content-Type audio/mp4 (m4a)
+// or audio/wav. The server measures the duration and computes waveform
+// peaks, so every client renders the same bars. As with text messages,
+// retrying with the same clientId returns the original message with 200.
+func (h *Handler) SendVoice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	clientID, err := uploadClientID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	contentType := media.NormalizeAudioType(r.Header.Get("Content-Type"))
+	if contentType == "" {
+		httpjson.Error(w, http.StatusUnsupportedMediaType, errors.New("voice notes must be audio/mp4 or audio/wav"))
+		return
+	}
+
+	m, ok := h.loadConversation(w, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+
+	data, ok := readUpload(w, r, maxVoiceBytes, "voice note")
+	if !ok {
+		return
+	}
+
+	info, err := media.ProbeAudio(contentType, data)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("could not read audio: "+err.Error()))
+		return
+	}
+	duration := time.Duration(info.DurationMs) * time.Millisecond
+	if duration < minVoiceDuration {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("voice note is too short"))
+		return
+	}
+	if duration > maxVoiceDuration {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("voice note is too long"))
+		return
+	}
+
+	key := "chat/" + m.ID + "/" + idgen.New()
+	if err := h.blobs.Put(key, info.ContentType, data); err != nil {
+		h.logger.Printf("SendVoice upload error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not store voice note"))
+		return
+	}
+
+	h.postMedia(w, r, m, Message{
+		SenderID:  userID,
+		ClientID:  clientID,
+		Kind:      KindAudio,
+		ReplyToID: r.URL.Query().Get("replyToId"),
+		Attachment: &Attachment{
+			Key:         key,
+			ContentType: info.ContentType,
+			Size:        int64(len(data)),
+			DurationMs:  info.DurationMs,
+			Peaks:       info.Peaks,
+		},
+	}, key)
+}
@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// Preference values sent by WhoDoYouWantToMeetScreen.
+const (
+	prefMen      = "men"
+	prefWomen    = "women"
+	prefEveryone = "everyone"
+)
+
+// audienceFor maps a profile gender (WhoAreYouScreen) to the preference value
+// that opts in to seeing it. Genders without a dedicated preference are only
+// matched by "everyone".
+func audienceFor(gender string) string {
+	switch gender {
+	case "male":
+		return prefMen
+	case "female":
+		return prefWomen
+	}
+	return ""
+}
+
+// AcceptsGender reports whether a set of preferred genders includes gender.
+// Other features that pair people (e.g. blind dates) share this rule.
+func AcceptsGender(prefs []string, gender string) bool {
+	if slices.Contains(prefs, prefEveryone) {
+		return true
+	}
+	aud := audienceFor(gender)
+	return aud != "" && slices.Contains(prefs, aud)
+}
+
+// mutualGenderMatch checks preferences in both directions.
+func mutualGenderMatch(viewer, candidate *onboarding.ProfileSnapshot) bool {
+	return AcceptsGender(viewer.PreferredGenders, candidate.Gender) &&
+		AcceptsGender(candidate.PreferredGenders, viewer.Gender)
+}
+
+// birthdateBounds converts an inclusive age range into an inclusive range of
+// birthdates relative to now.
+func birthdateBounds(now time.Time, minAge, maxAge int) (earliest, latest time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	earliest = today.AddDate(-(maxAge + 1), 0, 1)
+	latest = today.AddDate(-minAge, 0, 0)
+	return earliest, latest
+}
+
+// ageOn returns the age in whole years at now for an ISO birthdate.
+func ageOn(now time.Time, birthdate time.Time) int {
+	age := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	return age
+}
+
+// Age returns a profile's age in whole years at now, if it has a birthdate.
+func Age(p *onboarding.ProfileSnapshot, now time.Time) (int, bool) {
+	bd, ok := parseBirthdate(p.Birthdate)
+	if !ok {
+		return 0, false
+	}
+	return ageOn(now, bd), true
+}
+
+func parseBirthdate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", s)
+	return t, err == nil
+}
+
+// HasLocation treats the zero coordinate as "not shared"; the memory store
+// cannot distinguish a missing location from null island.
+func HasLocation(p *onboarding.ProfileSnapshot) bool {
+	return p.Lat != 0 || p.Lng != 0
+}
+
+// cursor marks the last candidate of a page. Pages are ordered by score
+// descending, then user ID ascending, so the pair is unique. RankedAt is the
+// clock the first page was scored against; later pages reuse it so time-based
+// signals don't shift candidates across the page boundary.
+//
+// Cursors are signed and bound to the viewer they were issued to, so a client
+// can't pick its own clock or splice positions from someone else's feed, and
+// they expire after cursorTTL.
+type cursor struct {
+	RankedAt time.Time
+	Score    float64
+	UserID   string
+}
+
+// errInvalidCursor is reported for cursors that are malformed, tampered with,
+// issued to someone else or expired; clients restart from the first page.
+var errInvalidCursor = errors.New("invalid or expired cursor")
+
+func encodeCursor(key []byte, viewerID string, c cursor) string {
+	raw := c.RankedAt.UTC().Format(time.RFC3339Nano) + "|" +
+		strconv.FormatFloat(c.Score, 'g', -1, 64) + "|" + c.UserID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw)) + "." + signCursor(key, viewerID, raw)
+}
+
+func decodeCursor(key []byte, viewerID, s string, now time.Time) (cursor, error) {
+	body, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return cursor{}, errInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(signCursor(key, viewerID, string(raw)))) {
+		return cursor{}, errInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return cursor{}, errInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil || t.After(now) || now.Sub(t) > cursorTTL {
+		return cursor{}, errInvalidCursor
+	}
+	f, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	return cursor{RankedAt: t, Score: f, UserID: parts[2]}, nil
+}
+
+func signCursor(key []byte, viewerID, raw string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(viewerID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(raw))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// after reports whether a candidate sorts after the cursor position.
+func (c cursor) after(score float64, userID string) bool {
+	if score < c.Score {
+		return true
+	}
+	return score == c.Score && userID > c.UserID
+}
+
+func toCandidate(now time.Time, p *onboarding.ProfileSnapshot) Candidate {
+	c := Candidate{
+		UserID:            p.UserID,
+		DisplayName:       p.DisplayName,
+		Gender:            p.Gender,
+		Pronouns:          p.Pronouns,
+		Intent:            p.Intent,
+		ConnectionStyle:   p.ConnectionStyle,
+		HeightCm:          p.HeightCm,
+		Drinks:            p.Drinks,
+		Smokes:            p.Smokes,
+		ExerciseLevel:     p.ExerciseLevel,
+		RelationshipStyle: p.RelationshipStyle,
+		Interests:         append([]string{}, p.Interests...),
+		VerifiedAt:        p.VerifiedAt,
+	}
+	if p.LastActiveAt != nil {
+		c.lastActiveAt = *p.LastActiveAt
+	}
+	if bd, ok := parseBirthdate(p.Birthdate); ok {
+		c.Age = ageOn(now, bd)
+	}
+	return c
+}
@@ -0,0 +1,326 @@
+package discovery
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/geo"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/scoring"
+)
+
+// Store generates discovery candidates for a viewer. Implementations apply the
+// hard filters described by Query and return every candidate that passes, in
+// no particular order. Ranking and paging happen in the handler.
+type Store interface {
+	Candidates(viewer *onboarding.ProfileSnapshot, q Query) ([]Candidate, error)
+}
+
+// Query holds the hard filters for a discovery request.
+type Query struct {
+	MinAge        int
+	MaxAge        int
+	MaxDistanceKm float64
+	// Intent, when set, limits candidates to people who picked that intent.
+	Intent string
+}
+
+// page is the slice of the ranked feed a request asks for. rankedAt is the
+// clock to score against: now for a first page, the cursor's otherwise.
+type page struct {
+	after    *cursor
+	limit    int
+	rankedAt time.Time
+}
+
+// AffinitySource reports how drawn a user has been to each intent, in
+// [0, 1], or nil without enough data.
+type AffinitySource interface {
+	IntentAffinities(userID string) (map[string]float64, error)
+}
+
+// Candidate is a profile that passed every hard filter for the viewer.
+type Candidate struct {
+	UserID            string   `json:"userId"`
+	DisplayName       string   `json:"displayName"`
+	Gender            string   `json:"gender"`
+	Pronouns          string   `json:"pronouns,omitempty"`
+	Age               int      `json:"age"`
+	Intent            string   `json:"intent,omitempty"`
+	ConnectionStyle   string   `json:"connectionStyle,omitempty"`
+	HeightCm          int      `json:"heightCm,omitempty"`
+	Drinks            string   `json:"drinks,omitempty"`
+	Smokes            string   `json:"smokes,omitempty"`
+	ExerciseLevel     string   `json:"exerciseLevel,omitempty"`
+	RelationshipStyle string   `json:"relationshipStyle,omitempty"`
+	Interests         []string `json:"interests"`
+	// VerifiedAt is when the candidate passed selfie verification.
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+
+	// Distance is fuzzed; the exact value never leaves the server.
+	Distance *geo.FuzzyDistance `json:"distance,omitempty"`
+
+	// Score and Reasons explain how well the candidate fits the viewer.
+	Score   float64          `json:"score"`
+	Reasons []scoring.Reason `json:"reasons"`
+
+	distanceKm   *float64
+	lastActiveAt time.Time
+}
+
+// setDistance records the exact distance for server-side use and exposes a
+// fuzzed version that is stable for this viewer/candidate pair.
+func (c *Candidate) setDistance(viewerID string, km float64) {
+	c.distanceKm = &km
+	d := geo.Fuzz(km, viewerID+"|"+c.UserID)
+	c.Distance = &d
+}
+
+const (
+	defaultMinAge        = 18
+	defaultMaxAge        = 99
+	defaultMaxDistanceKm = 50
+	defaultLimit         = 20
+	maxLimit             = 50
+
+	// cursorTTL is how long a feed cursor stays valid. Later pages are
+	// scored against the first page's clock, so this bounds how stale a
+	// feed can get before the client has to start over.
+	cursorTTL = time.Hour
+)
+
+// Handler exposes the discovery feed over HTTP.
+type Handler struct {
+	logger     *log.Logger
+	profiles   onboarding.Store
+	store      Store
+	intents    intents.Store
+	affinities AffinitySource
+	scorer     *scoring.Scorer
+	cursorKey  []byte
+}
+
+func NewHandler(logger *log.Logger, profiles onboarding.Store, store Store, intentStore intents.Store,
+	affinities AffinitySource, scorer *scoring.Scorer, cursorKey []byte) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if scorer == nil {
+		scorer = scoring.New(scoring.DefaultWeights())
+	}
+	return &Handler{
+		logger:     logger,
+		profiles:   profiles,
+		store:      store,
+		intents:    intentStore,
+		affinities: affinities,
+		scorer:     scorer,
+		cursorKey:  cursorKey,
+	}
+}
+
+type discoverResponse struct {
+	Intent     *intents.Intent `json:"intent,omitempty"`
+	Candidates []Candidate     `json:"candidates"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// --- Helpers ---
+
+// parseQuery reads filters and paging from the query string, applying
+// defaults for anything omitted. The defaults mirror DatingPreferencesScreen.
+// Cursors must have been issued to viewerID.
+func (h *Handler) parseQuery(r *http.Request, viewerID string) (Query, page, error) {
+	q := Query{
+		MinAge:        defaultMinAge,
+		MaxAge:        defaultMaxAge,
+		MaxDistanceKm: defaultMaxDistanceKm,
+	}
+	now := time.Now().UTC()
+	p := page{limit: defaultLimit, rankedAt: now}
+
+	values := r.URL.Query()
+	if v := values.Get("minAge"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < defaultMinAge {
+			return q, p, errors.New("minAge must be a number >= 18")
+		}
+		q.MinAge = n
+	}
+	if v := values.Get("maxAge"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < q.MinAge {
+			return q, p, errors.New("maxAge must be a number >= minAge")
+		}
+		q.MaxAge = n
+	}
+	if v := values.Get("maxDistanceKm"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			return q, p, errors.New("maxDistanceKm must be a positive number")
+		}
+		q.MaxDistanceKm = f
+	}
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return q, p, errors.New("limit must be a positive number")
+		}
+		p.limit = min(n, maxLimit)
+	}
+	if v := values.Get("cursor"); v != "" {
+		c, err := decodeCursor(h.cursorKey, viewerID, v, now)
+		if err != nil {
+			return q, p, err
+		}
+		p.after = &c
+		p.rankedAt = c.RankedAt
+	}
+	return q, p, nil
+}
+
+// cut returns the requested page of ranked candidates and the position of the
+// page after it (nil when there are no more).
+func (p page) cut(ranked []Candidate) ([]Candidate, *cursor) {
+	if p.after != nil {
+		start := sort.Search(len(ranked), func(i int) bool {
+			return p.after.after(ranked[i].Score, ranked[i].UserID)
+		})
+		ranked = ranked[start:]
+	}
+	if len(ranked) <= p.limit {
+		return ranked, nil
+	}
+	last := ranked[p.limit-1]
+	return ranked[:p.limit], &cursor{RankedAt: p.rankedAt, Score: last.Score, UserID: last.UserID}
+}
+
+// rank scores every candidate and orders it best first, breaking ties by
+// user ID so the (score, user ID) cursor has a total order to page through.
+func (h *Handler) rank(viewer *onboarding.ProfileSnapshot, candidates []Candidate, at time.Time) {
+	v := scoring.Profile{
+		UserID:          viewer.UserID,
+		Intent:          viewer.Intent,
+		ConnectionStyle: viewer.ConnectionStyle,
+		Drinks:          viewer.Drinks,
+		Smokes:          viewer.Smokes,
+		ExerciseLevel:   viewer.ExerciseLevel,
+		Interests:       viewer.Interests,
+	}
+	if h.affinities != nil {
+		// Behaviour only nudges the order; rank without it if it's missing.
+		affinity, err := h.affinities.IntentAffinities(viewer.UserID)
+		if err != nil {
+			h.logger.Printf("Discover intent affinity error: %v", err)
+		}
+		v.IntentAffinity = affinity
+	}
+	for i := range candidates {
+		c := &candidates[i]
+		res := h.scorer.ScoreAt(at, v, scoring.Profile{
+			UserID:          c.UserID,
+			Intent:          c.Intent,
+			ConnectionStyle: c.ConnectionStyle,
+			Drinks:          c.Drinks,
+			Smokes:          c.Smokes,
+			ExerciseLevel:   c.ExerciseLevel,
+			Interests:       c.Interests,
+			LastActiveAt:    c.lastActiveAt,
+		}, c.distanceKm)
+		c.Score = res.Score
+		c.Reasons = res.Reasons
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.UserID < b.UserID
+	})
+}
+
+// --- Handlers ---
+
+// Discover handles GET /v1/discover
+func (h *Handler) Discover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	h.serveFeed(w, r, nil)
+}
+
+// IntentFeed handles GET /v1/intents/{id}/feed: the discovery feed narrowed
+// to people who picked that intent. It takes the same filters as Discover.
+func (h *Handler) IntentFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	intent, err := intents.Resolve(h.intents, r.PathValue("id"))
+	if errors.Is(err, intents.ErrNotFound) {
+		httpjson.Error(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("IntentFeed catalogue error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load intent"))
+		return
+	}
+	h.serveFeed(w, r, intent)
+}
+
+// serveFeed runs a discovery query for the caller, optionally limited to one
+// intent.
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, intent *intents.Intent) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	q, p, err := h.parseQuery(r, userID)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if intent != nil {
+		q.Intent = intent.ID
+	}
+
+	viewer, err := h.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) || (err == nil && viewer.OnboardedAt == nil) {
+		httpjson.Error(w, http.StatusConflict, errors.New("onboarding is not complete"))
+		return
+	}
+	if err != nil {
+		h.logger.Printf("Discover profile error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load profile"))
+		return
+	}
+
+	candidates, err := h.store.Candidates(viewer, q)
+	if err != nil {
+		h.logger.Printf("Discover error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load candidates"))
+		return
+	}
+	h.rank(viewer, candidates, p.rankedAt)
+	candidates, next := p.cut(candidates)
+	if candidates == nil {
+		candidates = []Candidate{}
+	}
+	resp := discoverResponse{Intent: intent, Candidates: candidates}
+	if next != nil {
+		resp.NextCursor = encodeCursor(h.cursorKey, userID, *next)
+	}
+
+	httpjson.Write(w, http.StatusOK, resp)
+}
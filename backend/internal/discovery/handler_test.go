@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+var testInterests = []string{"hiking", "coffee", "jazz", "films", "running"}
+
+// onboard stores a complete profile for userID, a few metres from the others.
+func onboard(t *testing.T, profiles onboarding.Store, userID, gender, pref string, interests []string) {
+	t.Helper()
+	steps := []error{
+		profiles.UpsertPreference(userID, []string{pref}),
+		profiles.UpsertWhoAreYou(userID, onboarding.WhoAreYouInput{DisplayName: userID, Gender: gender, Birthdate: "1995-05-05"}),
+		profiles.ReplaceInterests(userID, interests),
+		profiles.UpdateLocation(userID, onboarding.LocationInput{Lat: 52.5, Lng: 13.4}),
+		profiles.MarkOnboardingComplete(userID),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestHandler(profiles onboarding.Store, key string) *Handler {
+	return NewHandler(log.New(io.Discard, "", 0), profiles, NewInMemoryStore(profiles), intents.NewInMemoryStore(),
+		nil, nil, []byte(key))
+}
+
+func discover(t *testing.T, h *Handler, viewerID string, query url.Values) (int, discoverResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/discover?"+query.Encode(), nil)
+	req = req.WithContext(auth.ContextWithUserID(req.Context(), viewerID))
+	rec := httptest.NewRecorder()
+	h.Discover(rec, req)
+
+	var resp discoverResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestFeedPagesThroughEveryCandidate(t *testing.T) {
+	profiles := onboarding.NewInMemoryStore()
+	onboard(t, profiles, "viewer", "female", prefMen, testInterests[:3])
+	want := map[string]bool{}
+	for i := range 45 {
+		id := fmt.Sprintf("c%02d", i)
+		// Overlapping interest sets give both distinct and tied scores.
+		onboard(t, profiles, id, "male", prefWomen, testInterests[i%4:i%4+2])
+		want[id] = true
+	}
+	h := newTestHandler(profiles, "secret")
+
+	seen := map[string]int{}
+	query := url.Values{"limit": {"7"}}
+	for pages := 0; ; pages++ {
+		if pages == 10 {
+			t.Fatal("the feed never ended")
+		}
+		code, resp := discover(t, h, "viewer", query)
+		if code != http.StatusOK {
+			t.Fatalf("page %d: status %d", pages, code)
+		}
+		for i, c := range resp.Candidates {
+			seen[c.UserID]++
+			if i > 0 && c.Score > resp.Candidates[i-1].Score {
+				t.Errorf("page %d is not ordered by score", pages)
+			}
+		}
+		if pages == 1 {
+			// Someone joining mid-scroll must not shift the pages already
+			// handed out.
+			onboard(t, profiles, "late", "male", prefWomen, testInterests[:3])
+			want["late"] = true
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", resp.NextCursor)
+	}
+
+	for id, n := range seen {
+		if n > 1 {
+			t.Errorf("%s was shown %d times", id, n)
+		}
+		if !want[id] {
+			t.Errorf("%s shouldn't be in the feed", id)
+		}
+	}
+	for id := range want {
+		if seen[id] == 0 && id != "late" {
+			t.Errorf("%s was never shown", id)
+		}
+	}
+}
+
+func TestCursorChecks(t *testing.T) {
+	profiles := onboarding.NewInMemoryStore()
+	for _, viewer := range []string{"viewer", "other"} {
+		onboard(t, profiles, viewer, "female", prefMen, testInterests[:2])
+	}
+	for i := range 3 {
+		onboard(t, profiles, fmt.Sprintf("c%d", i), "male", prefWomen, testInterests[:2])
+	}
+	h := newTestHandler(profiles, "secret")
+
+	_, first := discover(t, h, "viewer", url.Values{"limit": {"1"}})
+	if first.NextCursor == "" {
+		t.Fatal("no cursor after the first page")
+	}
+	body, sig, _ := strings.Cut(first.NextCursor, ".")
+	key := []byte("secret")
+	edited := time.Now().UTC().Format(time.RFC3339Nano) + "|1|c0"
+	expired := time.Now().Add(-2 * cursorTTL)
+
+	tests := []struct {
+		name    string
+		handler *Handler
+		viewer  string
+		cursor  string
+		want    int
+	}{
+		{"own cursor", h, "viewer", first.NextCursor, http.StatusOK},
+		{"someone else's cursor", h, "other", first.NextCursor, http.StatusBadRequest},
+		{"other key", newTestHandler(profiles, "rotated"), "viewer", first.NextCursor, http.StatusBadRequest},
+		{"edited position", h, "viewer", base64.RawURLEncoding.EncodeToString([]byte(edited)) + "." + sig, http.StatusBadRequest},
+		{"expired", h, "viewer", encodeCursor(key, "viewer", cursor{RankedAt: expired, Score: 1, UserID: "c0"}), http.StatusBadRequest},
+		{"unsigned", h, "viewer", body, http.StatusBadRequest},
+		{"garbage", h, "viewer", "!!!", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := discover(t, tt.handler, tt.viewer, url.Values{"limit": {"1"}, "cursor": {tt.cursor}})
+			if code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
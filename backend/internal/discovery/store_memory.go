@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/geo"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// ExclusionSource reports users a viewer must never see in discovery, such as
+// people they already liked, passed on or blocked. The in-memory store asks
+// every registered source; the Postgres store filters in SQL instead.
+type ExclusionSource interface {
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+}
+
+type memoryStore struct {
+	profiles   onboarding.Store
+	exclusions []ExclusionSource
+}
+
+// NewInMemoryStore returns a discovery store that scans the profiles held by
+// an in-memory onboarding store, skipping anyone reported by exclusions.
+func NewInMemoryStore(profiles onboarding.Store, exclusions ...ExclusionSource) Store {
+	return &memoryStore{
+		profiles:   profiles,
+		exclusions: exclusions,
+	}
+}
+
+func (s *memoryStore) excluded(userID string) (map[string]struct{}, error) {
+	out := map[string]struct{}{userID: {}}
+	for _, src := range s.exclusions {
+		ids, err := src.ExcludedUserIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+		for id := range ids {
+			out[id] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Candidates(viewer *onboarding.ProfileSnapshot, q Query) ([]Candidate, error) {
+	excluded, err := s.excluded(viewer.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	useDistance := HasLocation(viewer)
+	var all []onboarding.ProfileSnapshot
+	if useDistance {
+		all, err = s.profiles.ListProfilesNear(viewer.Lat, viewer.Lng, q.MaxDistanceKm)
+	} else {
+		all, err = s.profiles.ListProfiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	earliest, latest := birthdateBounds(now, q.MinAge, q.MaxAge)
+
+	type match struct {
+		profile    onboarding.ProfileSnapshot
+		distanceKm float64
+	}
+	var matches []match
+	for i := range all {
+		p := &all[i]
+		if _, skip := excluded[p.UserID]; skip {
+			continue
+		}
+		if p.OnboardedAt == nil || p.Snoozed(now) {
+			continue
+		}
+		if q.Intent != "" && p.Intent != q.Intent {
+			continue
+		}
+		if !mutualGenderMatch(viewer, p) {
+			continue
+		}
+		bd, ok := parseBirthdate(p.Birthdate)
+		if !ok || bd.Before(earliest) || bd.After(latest) {
+			continue
+		}
+		var dist float64
+		if useDistance {
+			if !HasLocation(p) {
+				continue
+			}
+			dist = geo.HaversineKm(viewer.Lat, viewer.Lng, p.Lat, p.Lng)
+			if dist > q.MaxDistanceKm {
+				continue
+			}
+		}
+		matches = append(matches, match{profile: *p, distanceKm: dist})
+	}
+
+	out := make([]Candidate, 0, len(matches))
+	for _, m := range matches {
+		c := toCandidate(now, &m.profile)
+		if useDistance {
+			c.setDistance(viewer.UserID, m.distanceKm)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
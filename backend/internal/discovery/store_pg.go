@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/geo"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// pgStore generates candidates straight from the profiles table.
+//
+// It relies on the onboarding schema plus the interaction tables from
+// sql/0002_discovery.sql (likes, passes, blocks), which are used to exclude
+// people the viewer has already acted on, the location_geohash column from
+// sql/0003_geo.sql for radius queries, sparks from sql/0005_sparks.sql and
+// last_active_at from sql/0025_last_active.sql for ranking.
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a discovery Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+// distanceExpr is the haversine distance in km between the candidate row and
+// the point bound to the given placeholders.
+func distanceExpr(lat, lng string) string {
+	return `(2 * 6371 * asin(sqrt(
+		power(sin(radians(p.location_lat - ` + lat + `) / 2), 2) +
+		cos(radians(` + lat + `)) * cos(radians(p.location_lat)) *
+		power(sin(radians(p.location_lng - ` + lng + `) / 2), 2)
+	)))`
+}
+
+func (s *pgStore) Candidates(viewer *onboarding.ProfileSnapshot, q Query) ([]Candidate, error) {
+	ctx := context.Background()
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	now := time.Now().UTC()
+	earliest, latest := birthdateBounds(now, q.MinAge, q.MaxAge)
+
+	viewerID := arg(viewer.UserID)
+	where := []string{
+		"p.user_id <> " + viewerID,
+		"p.onboarded_at IS NOT NULL",
+		"(p.snoozed_at IS NULL OR p.snoozed_until <= " + arg(now) + ")",
+		"p.birthdate BETWEEN " + arg(earliest) + " AND " + arg(latest),
+		`NOT EXISTS (SELECT 1 FROM likes l WHERE l.from_user_id = ` + viewerID + ` AND l.to_user_id = p.user_id)`,
+		`NOT EXISTS (SELECT 1 FROM passes x WHERE x.from_user_id = ` + viewerID + ` AND x.to_user_id = p.user_id)`,
+		`NOT EXISTS (SELECT 1 FROM sparks sp WHERE sp.from_user_id = ` + viewerID + ` AND sp.to_user_id = p.user_id)`,
+		`NOT EXISTS (SELECT 1 FROM blocks b
+			WHERE (b.blocker_id = ` + viewerID + ` AND b.blocked_id = p.user_id)
+			   OR (b.blocker_id = p.user_id AND b.blocked_id = ` + viewerID + `))`,
+	}
+
+	if q.Intent != "" {
+		where = append(where, "p.intent = "+arg(q.Intent))
+	}
+
+	// The viewer must want to see the candidate's gender...
+	if !slices.Contains(viewer.PreferredGenders, prefEveryone) {
+		var genders []string
+		for _, g := range []string{"male", "female"} {
+			if AcceptsGender(viewer.PreferredGenders, g) {
+				genders = append(genders, arg(g))
+			}
+		}
+		if len(genders) == 0 {
+			return nil, nil
+		}
+		where = append(where, "p.gender IN ("+strings.Join(genders, ", ")+")")
+	}
+
+	// ...and the candidate must want to see the viewer's.
+	prefs := `(',' || COALESCE(p.preferred_genders, '') || ',')`
+	accepts := prefs + ` LIKE '%,` + prefEveryone + `,%'`
+	if aud := audienceFor(viewer.Gender); aud != "" {
+		accepts += " OR " + prefs + " LIKE " + arg("%,"+aud+",%")
+	}
+	where = append(where, "("+accepts+")")
+
+	distance := "NULL::double precision"
+	useDistance := HasLocation(viewer)
+	if useDistance {
+		lat, lng := arg(viewer.Lat), arg(viewer.Lng)
+		distance = distanceExpr(lat, lng)
+
+		// Geohash prefixes first so the index can discard far rows cheaply.
+		if cells := geo.Cover(viewer.Lat, viewer.Lng, q.MaxDistanceKm); cells != nil {
+			var prefixes []string
+			for _, cell := range cells {
+				prefixes = append(prefixes, "p.location_geohash LIKE "+arg(cell+"%"))
+			}
+			where = append(where, "("+strings.Join(prefixes, " OR ")+")")
+		}
+		where = append(where, distance+" <= "+arg(q.MaxDistanceKm))
+	}
+
+	query := `
+		SELECT p.user_id, COALESCE(p.display_name, ''), COALESCE(p.gender, ''), COALESCE(p.pronouns, ''),
+		       p.birthdate, COALESCE(p.intent, ''), COALESCE(p.connection_style, ''),
+		       COALESCE(p.height_cm, 0), COALESCE(p.drinks, ''), COALESCE(p.smokes, ''),
+		       COALESCE(p.exercise_level, ''), COALESCE(p.relationship_style, ''),
+		       COALESCE((SELECT string_agg(ui.interest_key, ',' ORDER BY ui.interest_key)
+		                 FROM user_interests ui WHERE ui.user_id = p.user_id), ''),
+		       ` + distance + `, p.last_active_at, p.verified_at
+		FROM profiles p
+		WHERE ` + strings.Join(where, "\n\t\t  AND ")
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Candidate
+	for rows.Next() {
+		var (
+			c            Candidate
+			birthdate    time.Time
+			interests    string
+			distanceKm   sql.NullFloat64
+			lastActiveAt sql.NullTime
+			verifiedAt   sql.NullTime
+		)
+		if err := rows.Scan(
+			&c.UserID, &c.DisplayName, &c.Gender, &c.Pronouns,
+			&birthdate, &c.Intent, &c.ConnectionStyle,
+			&c.HeightCm, &c.Drinks, &c.Smokes,
+			&c.ExerciseLevel, &c.RelationshipStyle,
+			&interests, &distanceKm, &lastActiveAt, &verifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		c.Age = ageOn(now, birthdate)
+		c.Interests = []string{}
+		if interests != "" {
+			c.Interests = strings.Split(interests, ",")
+		}
+		if distanceKm.Valid {
+			c.setDistance(viewer.UserID, distanceKm.Float64)
+		}
+		if lastActiveAt.Valid {
+			c.lastActiveAt = lastActiveAt.Time
+		}
+		if verifiedAt.Valid {
+			t := verifiedAt.Time
+			c.VerifiedAt = &t
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
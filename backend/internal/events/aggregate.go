@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	aggregateTick  = 15 * time.Second
+	aggregateBatch = 500
+)
+
+// Aggregator periodically folds newly ingested events into intent stats.
+type Aggregator struct {
+	logger *log.Logger
+	store  Store
+}
+
+func NewAggregator(logger *log.Logger, store Store) *Aggregator {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Aggregator{logger: logger, store: store}
+}
+
+// Run aggregates until ctx is cancelled.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(aggregateTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.drain(ctx)
+		}
+	}
+}
+
+// drain aggregates batch after batch until it catches up.
+func (a *Aggregator) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := a.store.Aggregate(aggregateBatch)
+		if err != nil {
+			a.logger.Printf("events: aggregate: %v", err)
+			return
+		}
+		if n < aggregateBatch {
+			return
+		}
+	}
+}
+
+// Scores reads the aggregated intent scores for ranking.
+type Scores struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewScores(store Store) *Scores {
+	return &Scores{store: store, now: time.Now}
+}
+
+// IntentScores returns userID's raw score per intent they've interacted
+// with. Scores are comparable within one user only.
+func (s *Scores) IntentScores(userID string) (map[string]float64, error) {
+	stats, err := s.store.IntentStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	out := make(map[string]float64, len(stats))
+	for i := range stats {
+		out[stats[i].IntentID] = stats[i].Score(now)
+	}
+	return out, nil
+}
+
+// IntentAffinities scales IntentScores into [0, 1] relative to the user's
+// favourite intent, with negative scores clamped to 0. It returns nil when
+// the user has no positive signal yet, so callers can tell "no data" from
+// "not interested".
+func (s *Scores) IntentAffinities(userID string) (map[string]float64, error) {
+	scores, err := s.IntentScores(userID)
+	if err != nil {
+		return nil, err
+	}
+	var top float64
+	for _, v := range scores {
+		top = max(top, v)
+	}
+	if top <= 0 {
+		return nil, nil
+	}
+	out := make(map[string]float64, len(scores))
+	for id, v := range scores {
+		out[id] = max(0, v) / top
+	}
+	return out, nil
+}
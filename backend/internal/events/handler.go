@@ -0,0 +1,258 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/intents"
+)
+
+const (
+	maxBatchSize  = 100
+	maxBodyBytes  = 256 << 10
+	maxIDLength   = 64
+	maxDurationMs = int64(time.Hour / time.Millisecond)
+	maxEventAge   = 7 * 24 * time.Hour
+	maxFutureSkew = 5 * time.Minute
+)
+
+// Handler accepts behaviour events from the app.
+type Handler struct {
+	logger  *log.Logger
+	store   Store
+	intents intents.Store
+}
+
+func NewHandler(logger *log.Logger, store Store, intentStore intents.Store) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:  logger,
+		store:   store,
+		intents: intentStore,
+	}
+}
+
+// --- Request payloads ---
+
+type batchRequest struct {
+	Events []json.RawMessage `json:"events"`
+}
+
+// eventInput is one event as sent. Pointers tell an omitted field from a
+// zero one, so each type's schema can require or forbid fields.
+type eventInput struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	IntentID   *string    `json:"intentId"`
+	ProfileID  *string    `json:"profileId"`
+	Reaction   *string    `json:"reaction"`
+	DurationMs *int64     `json:"durationMs"`
+	Depth      *float64   `json:"depth"`
+	OccurredAt *time.Time `json:"occurredAt"`
+}
+
+type rejectedEvent struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+type batchResponse struct {
+	Accepted   int             `json:"accepted"`
+	Duplicates int             `json:"duplicates"`
+	Rejected   []rejectedEvent `json:"rejected"`
+}
+
+// field says whether an event type requires, allows or forbids a field.
+type field int
+
+const (
+	forbidden field = iota
+	optional
+	required
+)
+
+type schema struct {
+	intentID, profileID, reaction, durationMs, depth field
+}
+
+var schemas = map[string]schema{
+	TypeIntentView:     {intentID: required},
+	TypeIntentTime:     {intentID: required, durationMs: required},
+	TypeIntentScroll:   {intentID: required, depth: required},
+	TypeIntentReaction: {intentID: required, reaction: required},
+	TypeProfileView:    {profileID: required, durationMs: optional},
+}
+
+// --- Helpers ---
+
+func checkField(name string, rule field, present bool) error {
+	switch {
+	case rule == required && !present:
+		return fmt.Errorf("%s is required", name)
+	case rule == forbidden && present:
+		return fmt.Errorf("%s is not allowed for this type", name)
+	}
+	return nil
+}
+
+// parseEvent decodes and validates one event against its type's schema.
+func (h *Handler) parseEvent(raw json.RawMessage, userID string, now time.Time) (Event, error) {
+	var in eventInput
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return Event{}, err
+	}
+
+	e := Event{ID: strings.TrimSpace(in.ID), UserID: userID, Type: in.Type, ReceivedAt: now}
+	if e.ID == "" {
+		return e, errors.New("id is required")
+	}
+	if len(e.ID) > maxIDLength {
+		return e, errors.New("id is too long")
+	}
+	sc, ok := schemas[in.Type]
+	if !ok {
+		return e, errors.New("unknown event type")
+	}
+	for _, err := range []error{
+		checkField("intentId", sc.intentID, in.IntentID != nil),
+		checkField("profileId", sc.profileID, in.ProfileID != nil),
+		checkField("reaction", sc.reaction, in.Reaction != nil),
+		checkField("durationMs", sc.durationMs, in.DurationMs != nil),
+		checkField("depth", sc.depth, in.Depth != nil),
+	} {
+		if err != nil {
+			return e, err
+		}
+	}
+
+	if in.OccurredAt == nil {
+		return e, errors.New("occurredAt is required")
+	}
+	e.OccurredAt = in.OccurredAt.UTC()
+	if e.OccurredAt.After(now.Add(maxFutureSkew)) {
+		return e, errors.New("occurredAt is in the future")
+	}
+	if e.OccurredAt.Before(now.Add(-maxEventAge)) {
+		return e, errors.New("occurredAt is too old")
+	}
+
+	if in.IntentID != nil {
+		intent, err := intents.Resolve(h.intents, *in.IntentID)
+		if errors.Is(err, intents.ErrNotFound) {
+			return e, errors.New("unknown intent")
+		}
+		if err != nil {
+			return e, err
+		}
+		e.IntentID = intent.ID
+	}
+	if in.ProfileID != nil {
+		e.ProfileID = strings.TrimSpace(*in.ProfileID)
+		if e.ProfileID == "" || len(e.ProfileID) > maxIDLength {
+			return e, errors.New("invalid profileId")
+		}
+	}
+	if in.Reaction != nil {
+		switch *in.Reaction {
+		case ReactionFeelsRight, ReactionMaybeLater, ReactionNotVibe:
+			e.Reaction = *in.Reaction
+		default:
+			return e, errors.New("reaction must be feels-right, maybe-later or not-vibe")
+		}
+	}
+	if in.DurationMs != nil {
+		if *in.DurationMs <= 0 || *in.DurationMs > maxDurationMs {
+			return e, errors.New("durationMs must be between 1 and 3600000")
+		}
+		e.DurationMs = *in.DurationMs
+	}
+	if in.Depth != nil {
+		if *in.Depth < 0 || *in.Depth > 1 {
+			return e, errors.New("depth must be between 0 and 1")
+		}
+		e.Depth = *in.Depth
+	}
+	return e, nil
+}
+
+// --- Handlers ---
+
+// Ingest handles POST /v1/events
+//
+// The body is {"events": [...]}, up to 100 per batch. Valid events are
+// stored even when others in the batch are rejected; each rejection is
+// reported by index so the client can drop it rather than retry. Event IDs
+// are deduplicated per user, so resending a batch is safe.
+func (h *Handler) Ingest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		httpjson.Error(w, http.StatusRequestEntityTooLarge, errors.New("batch is too large"))
+		return
+	}
+	var req batchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Events) == 0 {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("events is required"))
+		return
+	}
+	if len(req.Events) > maxBatchSize {
+		httpjson.Error(w, http.StatusBadRequest, fmt.Errorf("at most %d events per batch", maxBatchSize))
+		return
+	}
+
+	now := time.Now().UTC()
+	resp := batchResponse{Rejected: []rejectedEvent{}}
+	var valid []Event
+	for i, raw := range req.Events {
+		e, err := h.parseEvent(raw, userID, now)
+		if err != nil {
+			id := e.ID
+			if len(id) > maxIDLength {
+				id = ""
+			}
+			resp.Rejected = append(resp.Rejected, rejectedEvent{Index: i, ID: id, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, e)
+	}
+
+	if len(valid) > 0 {
+		n, err := h.store.Append(valid)
+		if err != nil {
+			h.logger.Printf("Ingest events error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save events"))
+			return
+		}
+		resp.Accepted = n
+		resp.Duplicates = len(valid) - n
+	}
+
+	httpjson.Write(w, http.StatusOK, resp)
+}
@@ -0,0 +1,120 @@
+// Package events ingests behaviour events from the app (intent views, time
+// spent, scroll depth, reactions) and folds them into per-user intent scores
+// that ranking can read.
+package events
+
+import (
+	"math"
+	"time"
+)
+
+// Event types, mirroring what behaviorTracker.js records.
+const (
+	TypeIntentView     = "intent_view"
+	TypeIntentTime     = "intent_time_spent"
+	TypeIntentScroll   = "intent_scroll_depth"
+	TypeIntentReaction = "intent_reaction"
+	TypeProfileView    = "profile_view"
+)
+
+// Reactions on an intent feed, matching IntentFeedsScreen.
+const (
+	ReactionFeelsRight = "feels-right"
+	ReactionMaybeLater = "maybe-later"
+	ReactionNotVibe    = "not-vibe"
+)
+
+// Event is one behaviour event. ID is chosen by the client and is unique per
+// user, so a retried batch is stored once.
+type Event struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Type       string    `json:"type"`
+	IntentID   string    `json:"intentId,omitempty"`
+	ProfileID  string    `json:"profileId,omitempty"`
+	Reaction   string    `json:"reaction,omitempty"`
+	DurationMs int64     `json:"durationMs,omitempty"`
+	Depth      float64   `json:"depth,omitempty"` // fraction of the feed scrolled, 0..1
+	OccurredAt time.Time `json:"occurredAt"`
+	ReceivedAt time.Time `json:"-"`
+}
+
+// IntentStats is everything aggregated so far about one user and one intent.
+type IntentStats struct {
+	UserID         string
+	IntentID       string
+	Views          int
+	TimeSpentMs    int64
+	MaxScrollDepth float64
+	FeelsRight     int
+	MaybeLater     int
+	NotVibe        int
+	LastViewedAt   *time.Time
+	UpdatedAt      time.Time
+}
+
+// drawn reports whether the stats show more interest than rejection.
+func (st *IntentStats) drawn() bool {
+	return st.Views+st.FeelsRight > st.NotVibe
+}
+
+// apply folds e into st. Only intent events contribute.
+func (st *IntentStats) apply(e *Event) {
+	switch e.Type {
+	case TypeIntentView:
+		st.Views++
+		if st.LastViewedAt == nil || e.OccurredAt.After(*st.LastViewedAt) {
+			t := e.OccurredAt
+			st.LastViewedAt = &t
+		}
+	case TypeIntentTime:
+		st.TimeSpentMs += e.DurationMs
+	case TypeIntentScroll:
+		st.MaxScrollDepth = math.Max(st.MaxScrollDepth, e.Depth)
+	case TypeIntentReaction:
+		switch e.Reaction {
+		case ReactionFeelsRight:
+			st.FeelsRight++
+		case ReactionMaybeLater:
+			st.MaybeLater++
+		case ReactionNotVibe:
+			st.NotVibe++
+		}
+	}
+}
+
+// Score weighs the stats the way behaviorTracker.getIntentScore did on the
+// device: views, capped time spent, scroll depth, reactions and a recency
+// bonus that fades over a month. It can be negative for intents the user
+// keeps rejecting.
+func (st *IntentStats) Score(now time.Time) float64 {
+	score := float64(st.Views)*10 +
+		math.Min(float64(st.TimeSpentMs)/1000, 50) +
+		st.MaxScrollDepth*20 +
+		float64(st.FeelsRight)*20 - float64(st.NotVibe)*10 + float64(st.MaybeLater)*5
+	if st.LastViewedAt != nil {
+		days := now.Sub(*st.LastViewedAt).Hours() / 24
+		score += math.Max(0, 30-days)
+	}
+	return score
+}
+
+// Store persists events append-only and keeps the aggregates derived from
+// them.
+type Store interface {
+	// Append stores events, skipping any whose (UserID, ID) was stored
+	// before, and reports how many were new.
+	Append(events []Event) (int, error)
+	// Aggregate folds up to limit events that haven't been aggregated yet
+	// into IntentStats, oldest first, and reports how many it consumed.
+	Aggregate(limit int) (int, error)
+	// IntentStats returns userID's aggregates, one per intent they touched.
+	IntentStats(userID string) ([]IntentStats, error)
+	// IntentPopularity counts, per intent, the users drawn to it whose
+	// stats changed at or after since.
+	IntentPopularity(since time.Time) (map[string]int, error)
+	// List returns every event userID sent, oldest first.
+	List(userID string) ([]Event, error)
+	// DeleteUser removes userID's events and aggregates.
+	DeleteUser(userID string) error
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	events []Event
+	seen   map[string]map[string]struct{} // userID -> event IDs
+	// aggregated is how many of events have been folded into stats.
+	aggregated int
+	stats      map[string]map[string]*IntentStats // userID -> intentID
+}
+
+// NewInMemoryStore returns an in-memory event Store.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		seen:  make(map[string]map[string]struct{}),
+		stats: make(map[string]map[string]*IntentStats),
+	}
+}
+
+func (s *memoryStore) Append(events []Event) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, e := range events {
+		ids := s.seen[e.UserID]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			s.seen[e.UserID] = ids
+		}
+		if _, dup := ids[e.ID]; dup {
+			continue
+		}
+		ids[e.ID] = struct{}{}
+		s.events = append(s.events, e)
+		n++
+	}
+	return n, nil
+}
+
+func (s *memoryStore) Aggregate(limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end := min(len(s.events), s.aggregated+limit)
+	now := time.Now().UTC()
+	for i := s.aggregated; i < end; i++ {
+		e := &s.events[i]
+		if e.IntentID == "" {
+			continue
+		}
+		byIntent := s.stats[e.UserID]
+		if byIntent == nil {
+			byIntent = make(map[string]*IntentStats)
+			s.stats[e.UserID] = byIntent
+		}
+		st := byIntent[e.IntentID]
+		if st == nil {
+			st = &IntentStats{UserID: e.UserID, IntentID: e.IntentID}
+			byIntent[e.IntentID] = st
+		}
+		st.apply(e)
+		st.UpdatedAt = now
+	}
+	n := end - s.aggregated
+	s.aggregated = end
+	return n, nil
+}
+
+func (s *memoryStore) IntentStats(userID string) ([]IntentStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]IntentStats, 0, len(s.stats[userID]))
+	for _, st := range s.stats[userID] {
+		c := *st
+		if st.LastViewedAt != nil {
+			t := *st.LastViewedAt
+			c.LastViewedAt = &t
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (s *memoryStore) IntentPopularity(since time.Time) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int)
+	for _, byIntent := range s.stats {
+		for id, st := range byIntent {
+			if !st.UpdatedAt.Before(since) && st.drawn() {
+				out[id]++
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) List(userID string) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Event
+	for _, e := range s.events {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].OccurredAt.Before(out[j].OccurredAt) })
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.events[:0]
+	aggregated := 0
+	for i, e := range s.events {
+		if e.UserID == userID {
+			continue
+		}
+		if i < s.aggregated {
+			aggregated++
+		}
+		kept = append(kept, e)
+	}
+	clear(s.events[len(kept):])
+	s.events = kept
+	s.aggregated = aggregated
+	delete(s.seen, userID)
+	delete(s.stats, userID)
+	return nil
+}
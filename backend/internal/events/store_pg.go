@@ -0,0 +1,239 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// pgStore keeps events in behavior_events and aggregates in
+// user_intent_stats (sql/0016_behavior_events.sql). Events are never
+// updated; aggregation reads them in seq order behind a cursor row.
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs an event Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+// aggregateCursor names the behavior_event_cursors row tracking how far
+// intent aggregation has read.
+const aggregateCursor = "intent_stats"
+
+// settleDelay keeps aggregation behind recent inserts. seq values are handed
+// out before commit, so a slow transaction can commit a lower seq after a
+// higher one has been read; waiting for inserts to settle keeps the cursor
+// from skipping it.
+const settleDelay = 10 * time.Second
+
+func (s *pgStore) Append(events []Event) (int, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n := 0
+	for _, e := range events {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO behavior_events (user_id, id, type, intent_id, profile_id, reaction, duration_ms, depth, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (user_id, id) DO NOTHING
+		`, e.UserID, e.ID, e.Type, e.IntentID, e.ProfileID, e.Reaction, e.DurationMs, e.Depth, e.OccurredAt)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		n += int(affected)
+	}
+	return n, tx.Commit()
+}
+
+func (s *pgStore) Aggregate(limit int) (int, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Locking the cursor row serialises aggregation across instances.
+	var lastSeq int64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT last_seq FROM behavior_event_cursors WHERE name = $1 FOR UPDATE
+	`, aggregateCursor).Scan(&lastSeq); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT seq, user_id, type, intent_id, reaction, duration_ms, depth, occurred_at
+		FROM behavior_events
+		WHERE seq > $1 AND received_at < now() - make_interval(secs => $2)
+		ORDER BY seq
+		LIMIT $3
+	`, lastSeq, settleDelay.Seconds(), limit)
+	if err != nil {
+		return 0, err
+	}
+	type key struct{ userID, intentID string }
+	var (
+		deltas = make(map[key]*IntentStats)
+		order  []key
+		n      int
+	)
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&lastSeq, &e.UserID, &e.Type, &e.IntentID, &e.Reaction,
+			&e.DurationMs, &e.Depth, &e.OccurredAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		n++
+		if e.IntentID == "" {
+			continue
+		}
+		k := key{e.UserID, e.IntentID}
+		st := deltas[k]
+		if st == nil {
+			st = &IntentStats{UserID: e.UserID, IntentID: e.IntentID}
+			deltas[k] = st
+			order = append(order, k)
+		}
+		st.apply(&e)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	for _, k := range order {
+		st := deltas[k]
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_intent_stats
+				(user_id, intent_id, views, time_spent_ms, max_scroll_depth, feels_right, maybe_later, not_vibe, last_viewed_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+			ON CONFLICT (user_id, intent_id) DO UPDATE SET
+				views = user_intent_stats.views + EXCLUDED.views,
+				time_spent_ms = user_intent_stats.time_spent_ms + EXCLUDED.time_spent_ms,
+				max_scroll_depth = GREATEST(user_intent_stats.max_scroll_depth, EXCLUDED.max_scroll_depth),
+				feels_right = user_intent_stats.feels_right + EXCLUDED.feels_right,
+				maybe_later = user_intent_stats.maybe_later + EXCLUDED.maybe_later,
+				not_vibe = user_intent_stats.not_vibe + EXCLUDED.not_vibe,
+				last_viewed_at = GREATEST(user_intent_stats.last_viewed_at, EXCLUDED.last_viewed_at),
+				updated_at = now()
+		`, st.UserID, st.IntentID, st.Views, st.TimeSpentMs, st.MaxScrollDepth,
+			st.FeelsRight, st.MaybeLater, st.NotVibe, st.LastViewedAt); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE behavior_event_cursors SET last_seq = $2, updated_at = now() WHERE name = $1
+	`, aggregateCursor, lastSeq); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func (s *pgStore) IntentStats(userID string) ([]IntentStats, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT intent_id, views, time_spent_ms, max_scroll_depth, feels_right, maybe_later, not_vibe, last_viewed_at, updated_at
+		FROM user_intent_stats
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []IntentStats
+	for rows.Next() {
+		st := IntentStats{UserID: userID}
+		var lastViewed sql.NullTime
+		if err := rows.Scan(&st.IntentID, &st.Views, &st.TimeSpentMs, &st.MaxScrollDepth,
+			&st.FeelsRight, &st.MaybeLater, &st.NotVibe, &lastViewed, &st.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if lastViewed.Valid {
+			t := lastViewed.Time
+			st.LastViewedAt = &t
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) IntentPopularity(since time.Time) (map[string]int, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT intent_id, count(*)
+		FROM user_intent_stats
+		WHERE updated_at >= $1 AND views + feels_right > not_vibe
+		GROUP BY intent_id
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]int)
+	for rows.Next() {
+		var (
+			id string
+			n  int
+		)
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) List(userID string) ([]Event, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT id, type, intent_id, profile_id, reaction, duration_ms, depth, occurred_at, received_at
+		FROM behavior_events
+		WHERE user_id = $1
+		ORDER BY occurred_at, seq
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		e := Event{UserID: userID}
+		if err := rows.Scan(&e.ID, &e.Type, &e.IntentID, &e.ProfileID, &e.Reaction,
+			&e.DurationMs, &e.Depth, &e.OccurredAt, &e.ReceivedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM behavior_events WHERE user_id = $1`,
+		`DELETE FROM user_intent_stats WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
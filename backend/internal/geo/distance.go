@@ -0,0 +1,56 @@
+package geo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	kmPerDegree   = math.Pi * earthRadiusKm / 180
+)
+
+// HaversineKm returns the great-circle distance between two points.
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// FuzzyDistance is what other users are allowed to see about how far away
+// someone is.
+type FuzzyDistance struct {
+	Km    int    `json:"km"`
+	Label string `json:"label"`
+}
+
+// Fuzz turns an exact distance into a coarse, display-only value. A stable
+// per-pair jitter (seeded by the two user IDs) shifts the bucket boundaries
+// so the rounded value doesn't say where in its bucket the true distance
+// lies. It only blurs a single reading: the jitter is fixed, so a viewer who
+// moves around and watches the value flip can still narrow down someone's
+// position.
+func Fuzz(exactKm float64, seed string) FuzzyDistance {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(seed))
+	jitter := float64(h.Sum32()%1000)/1000 - 0.5 // [-0.5, 0.5)
+
+	var step float64
+	switch {
+	case exactKm < 2:
+		return FuzzyDistance{Km: 2, Label: "< 2 km away"}
+	case exactKm < 10:
+		step = 1
+	case exactKm < 50:
+		step = 5
+	default:
+		step = 10
+	}
+
+	km := int(math.Max(step, math.Round((exactKm+jitter*step)/step)*step))
+	return FuzzyDistance{Km: km, Label: fmt.Sprintf("~%d km away", km)}
+}
@@ -0,0 +1,115 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// Geohashes let us index locations with a plain text column: every character
+// narrows the cell, so "everything near X" becomes a handful of prefix
+// lookups that a btree index can answer without PostGIS.
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// IndexPrecision is the geohash length stored per profile (~5m x 5m cells).
+const IndexPrecision = 9
+
+// Encode returns the geohash of a point at the given precision.
+func Encode(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+
+	var sb strings.Builder
+	sb.Grow(precision)
+
+	bit, ch, even := 0, 0, true
+	for sb.Len() < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				lngLo = mid
+			} else {
+				ch <<= 1
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// cellSizeDeg returns the height and width of a cell at precision, in degrees.
+func cellSizeDeg(precision int) (latDeg, lngDeg float64) {
+	bits := precision * 5
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// cellSizeKm approximates a cell's height and width in km at latitude lat.
+func cellSizeKm(precision int, lat float64) (heightKm, widthKm float64) {
+	latDeg, lngDeg := cellSizeDeg(precision)
+	heightKm = latDeg * kmPerDegree
+	widthKm = lngDeg * kmPerDegree * math.Cos(lat*math.Pi/180)
+	return heightKm, widthKm
+}
+
+// Cover returns geohash prefixes whose cells together contain every point
+// within radiusKm of (lat, lng): the cell holding the point plus its eight
+// neighbours, at the finest precision whose cells are at least radiusKm
+// across. It returns nil when the radius is too large for prefixes to help,
+// in which case callers should fall back to a plain distance check.
+func Cover(lat, lng, radiusKm float64) []string {
+	precision := 0
+	for p := IndexPrecision; p >= 1; p-- {
+		h, w := cellSizeKm(p, lat)
+		if h >= radiusKm && w >= radiusKm {
+			precision = p
+			break
+		}
+	}
+	if precision == 0 {
+		return nil
+	}
+
+	latDeg, lngDeg := cellSizeDeg(precision)
+	seen := make(map[string]struct{}, 9)
+	var cells []string
+	for _, dLat := range []float64{-latDeg, 0, latDeg} {
+		for _, dLng := range []float64{-lngDeg, 0, lngDeg} {
+			nLat := math.Max(-90, math.Min(90, lat+dLat))
+			nLng := wrapLng(lng + dLng)
+			cell := Encode(nLat, nLng, precision)
+			if _, ok := seen[cell]; ok {
+				continue
+			}
+			seen[cell] = struct{}{}
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+func wrapLng(lng float64) float64 {
+	for lng < -180 {
+		lng += 360
+	}
+	for lng >= 180 {
+		lng -= 360
+	}
+	return lng
+}
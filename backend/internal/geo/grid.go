@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"strings"
+	"sync"
+)
+
+// gridPrecision is the cell size used by Grid (~5km x 5km).
+const gridPrecision = 5
+
+// Grid is an in-memory spatial index mapping geohash cells to the IDs of the
+// points inside them. It is safe for concurrent use.
+type Grid struct {
+	mu     sync.RWMutex
+	cells  map[string]map[string]struct{}
+	member map[string]string // id -> cell
+}
+
+// NewGrid returns an empty grid index.
+func NewGrid() *Grid {
+	return &Grid{
+		cells:  make(map[string]map[string]struct{}),
+		member: make(map[string]string),
+	}
+}
+
+// Set places id at (lat, lng), moving it if it was already indexed.
+func (g *Grid) Set(id string, lat, lng float64) {
+	cell := Encode(lat, lng, gridPrecision)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removeLocked(id)
+	ids, ok := g.cells[cell]
+	if !ok {
+		ids = make(map[string]struct{})
+		g.cells[cell] = ids
+	}
+	ids[id] = struct{}{}
+	g.member[id] = cell
+}
+
+// Remove drops id from the index.
+func (g *Grid) Remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removeLocked(id)
+}
+
+func (g *Grid) removeLocked(id string) {
+	cell, ok := g.member[id]
+	if !ok {
+		return
+	}
+	delete(g.cells[cell], id)
+	if len(g.cells[cell]) == 0 {
+		delete(g.cells, cell)
+	}
+	delete(g.member, id)
+}
+
+// Near returns the IDs in cells that may contain points within radiusKm of
+// (lat, lng). Results are a superset; callers still check exact distance.
+func (g *Grid) Near(lat, lng, radiusKm float64) []string {
+	prefixes := Cover(lat, lng, radiusKm)
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var out []string
+	visited := make(map[string]struct{})
+	collect := func(cell string) {
+		if _, ok := visited[cell]; ok {
+			return
+		}
+		visited[cell] = struct{}{}
+		for id := range g.cells[cell] {
+			out = append(out, id)
+		}
+	}
+
+	if prefixes == nil {
+		for cell := range g.cells {
+			collect(cell)
+		}
+		return out
+	}
+	for _, prefix := range prefixes {
+		if len(prefix) >= gridPrecision {
+			collect(prefix[:gridPrecision])
+			continue
+		}
+		for cell := range g.cells {
+			if strings.HasPrefix(cell, prefix) {
+				collect(cell)
+			}
+		}
+	}
+	return out
+}
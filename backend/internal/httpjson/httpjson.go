@@ -0,0 +1,19 @@
+// Package httpjson writes the JSON responses every API handler shares.
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Write sends v as a JSON response with the given status.
+func Write(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error sends {"error": err.Error()} with the given status.
+func Error(w http.ResponseWriter, status int, err error) {
+	Write(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,17 @@
+// Package idgen generates opaque identifiers for server-created records.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New returns a random 128-bit identifier encoded as 32 hex characters.
+func New() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken.
+		panic("idgen: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,104 @@
+package intents
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// ScoreSource reports a user's score for each intent they've interacted
+// with; higher means more interest. Scores compare within one user only.
+type ScoreSource interface {
+	IntentScores(userID string) (map[string]float64, error)
+}
+
+// Handler exposes the intent catalogue over HTTP. Per-intent feeds live in
+// discovery (Handler.IntentFeed) so they share its filters and ranking.
+type Handler struct {
+	logger *log.Logger
+	store  Store
+	scores ScoreSource
+}
+
+func NewHandler(logger *log.Logger, store Store, scores ScoreSource) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger: logger,
+		store:  store,
+		scores: scores,
+	}
+}
+
+// --- Helpers ---
+
+// userID returns the caller if one is identified. The catalogue itself is
+// public, so a missing user isn't an error.
+func userID(r *http.Request) string {
+	uid, _ := auth.UserID(r)
+	return uid
+}
+
+// --- Handlers ---
+
+// personalise reorders list by the user's intent scores, best first.
+// Intents without a score keep catalogue order after those with a positive
+// one and before those the user has been turning away from.
+func (h *Handler) personalise(userID string, list []Intent) {
+	if h.scores == nil || userID == "" {
+		return
+	}
+	scores, err := h.scores.IntentScores(userID)
+	if err != nil {
+		h.logger.Printf("List intents scores error: %v", err)
+		return
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return scores[list[i].ID] > scores[list[j].ID]
+	})
+}
+
+// List handles GET /v1/intents, returning active intents in display order,
+// personalised for the caller when they're known.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	list, err := h.store.List(false)
+	if err != nil {
+		h.logger.Printf("List intents error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load intents"))
+		return
+	}
+	if list == nil {
+		list = []Intent{}
+	}
+	h.personalise(userID(r), list)
+	httpjson.Write(w, http.StatusOK, map[string]any{"intents": list})
+}
+
+// Get handles GET /v1/intents/{id}. Retired intents are still returned so
+// old references can render; clients check the active flag.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	in, err := h.store.Get(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		httpjson.Error(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("Get intent error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load intent"))
+		return
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"intent": in})
+}
@@ -0,0 +1,141 @@
+// Package intents holds the catalogue of dating intents: what people say
+// they're looking for, shown as feeds and used to group rooms.
+package intents
+
+import (
+	"errors"
+	"slices"
+)
+
+// Intent is one catalogue entry.
+type Intent struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Icon        string `json:"icon,omitempty"`
+	// VibeTags describe the intent for matching against what people are
+	// into; AmbientVibes are the softer words shown alongside it.
+	VibeTags     []string `json:"vibeTags"`
+	AmbientVibes []string `json:"ambientVibes"`
+	// Aliases are older values that resolve to this intent, such as the
+	// answers WhatBringsYouScreen sent before the catalogue existed.
+	Aliases   []string `json:"-"`
+	SortOrder int      `json:"-"`
+	Active    bool     `json:"active"`
+}
+
+// OpenToConnection is the intent for people who haven't settled on what
+// they want; scoring treats it as partly aligned with everything.
+const OpenToConnection = "open-to-connection"
+
+// ErrNotFound is returned for IDs that aren't in the catalogue.
+var ErrNotFound = errors.New("intent not found")
+
+// Store serves the catalogue.
+type Store interface {
+	// List returns intents in display order; inactive ones only when
+	// includeInactive is set.
+	List(includeInactive bool) ([]Intent, error)
+	// Get returns one intent, active or not, or ErrNotFound.
+	Get(id string) (*Intent, error)
+}
+
+// Resolve returns the active intent a user-supplied value refers to, by ID
+// or alias. Retired intents are treated as unknown.
+func Resolve(s Store, value string) (*Intent, error) {
+	in, err := s.Get(value)
+	if errors.Is(err, ErrNotFound) {
+		all, err := s.List(false)
+		if err != nil {
+			return nil, err
+		}
+		for i := range all {
+			if slices.Contains(all[i].Aliases, value) {
+				return &all[i], nil
+			}
+		}
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !in.Active {
+		return nil, ErrNotFound
+	}
+	return in, nil
+}
+
+// Defaults is the launch catalogue, previously hard-coded in the app's
+// IntentFeedsScreen and personalizationEngine.js. The Postgres catalogue is
+// seeded with the same entries (sql/0015_intents.sql).
+func Defaults() []Intent {
+	return []Intent{
+		{
+			ID:           "slow-intentional",
+			Title:        "Slow & Intentional",
+			Description:  "Meet people who take things at a meaningful pace.",
+			Icon:         "🕊️",
+			VibeTags:     []string{"thoughtful", "reflective", "intentional", "meaningful"},
+			AmbientVibes: []string{"Thoughtful", "Reflective", "Honest", "Warm"},
+			Aliases:      []string{"slow"},
+		},
+		{
+			ID:           "meaningful-connection",
+			Title:        "Meaningful Connection",
+			Description:  "For those who want something real and lasting.",
+			Icon:         "✨",
+			VibeTags:     []string{"deep", "authentic", "genuine", "lasting"},
+			AmbientVibes: []string{"Deep", "Authentic", "Genuine", "Lasting"},
+			Aliases:      []string{"lasting"},
+		},
+		{
+			ID:           "deep-conversations",
+			Title:        "Deep Conversations",
+			Description:  "People who value emotional depth and honesty.",
+			Icon:         "💬",
+			VibeTags:     []string{"philosophical", "emotional", "vulnerable", "honest"},
+			AmbientVibes: []string{"Philosophical", "Emotional", "Vulnerable", "Real"},
+		},
+		{
+			ID:           "creative-energy",
+			Title:        "Creative Energy",
+			Description:  "For artists, creators, and expressive personalities.",
+			Icon:         "🎨",
+			VibeTags:     []string{"artistic", "expressive", "imaginative", "inspiring"},
+			AmbientVibes: []string{"Artistic", "Expressive", "Imaginative", "Inspiring"},
+		},
+		{
+			ID:           "outdoors-active",
+			Title:        "Outdoors & Active",
+			Description:  "Hiking, adventures, and nature lovers.",
+			Icon:         "🌿",
+			VibeTags:     []string{"adventurous", "energetic", "nature-loving", "active"},
+			AmbientVibes: []string{"Adventurous", "Energetic", "Nature-loving", "Active"},
+		},
+		{
+			ID:           "food-coffee",
+			Title:        "Food & Coffee Dates",
+			Description:  "For the chill, cozy, café-date vibe.",
+			Icon:         "☕",
+			VibeTags:     []string{"cozy", "relaxed", "social", "comfortable"},
+			AmbientVibes: []string{"Cozy", "Relaxed", "Social", "Comfortable"},
+		},
+		{
+			ID:           "spiritual-calm",
+			Title:        "Spiritual & Calm",
+			Description:  "Mindful, compassionate, peaceful energy.",
+			Icon:         "🌙",
+			VibeTags:     []string{"mindful", "peaceful", "compassionate", "grounded"},
+			AmbientVibes: []string{"Mindful", "Peaceful", "Compassionate", "Grounded"},
+		},
+		{
+			ID:           OpenToConnection,
+			Title:        "Open to Connection",
+			Description:  "Open to whatever feels right, no pressure.",
+			Icon:         "🌱",
+			VibeTags:     []string{"open", "curious", "easygoing", "spontaneous"},
+			AmbientVibes: []string{"Open", "Curious", "Easygoing", "Spontaneous"},
+			Aliases:      []string{"right", "unsure"},
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package intents
+
+import "slices"
+
+type memoryStore struct {
+	intents []Intent
+}
+
+// NewInMemoryStore returns a read-only catalogue holding Defaults, all
+// active.
+func NewInMemoryStore() Store {
+	defaults := Defaults()
+	for i := range defaults {
+		defaults[i].SortOrder = i + 1
+		defaults[i].Active = true
+	}
+	return &memoryStore{intents: defaults}
+}
+
+func clone(in Intent) Intent {
+	in.VibeTags = slices.Clone(in.VibeTags)
+	in.AmbientVibes = slices.Clone(in.AmbientVibes)
+	in.Aliases = slices.Clone(in.Aliases)
+	return in
+}
+
+func (s *memoryStore) List(includeInactive bool) ([]Intent, error) {
+	var out []Intent
+	for _, in := range s.intents {
+		if in.Active || includeInactive {
+			out = append(out, clone(in))
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Get(id string) (*Intent, error) {
+	for _, in := range s.intents {
+		if in.ID == id {
+			c := clone(in)
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
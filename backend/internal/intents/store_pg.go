@@ -0,0 +1,76 @@
+package intents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// pgStore reads the catalogue from the intents table
+// (sql/0015_intents.sql). Entries are managed in the database; retiring an
+// intent means clearing its active flag, which keeps existing references
+// valid.
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a catalogue Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const intentColumns = `id, title, description, icon, vibe_tags, ambient_vibes, aliases, sort_order, active`
+
+func scanIntent(row interface{ Scan(...any) error }) (*Intent, error) {
+	var (
+		in                             Intent
+		vibeTags, ambientVibe, aliases string
+	)
+	if err := row.Scan(&in.ID, &in.Title, &in.Description, &in.Icon, &vibeTags, &ambientVibe,
+		&aliases, &in.SortOrder, &in.Active); err != nil {
+		return nil, err
+	}
+	in.VibeTags = splitList(vibeTags)
+	in.AmbientVibes = splitList(ambientVibe)
+	in.Aliases = splitList(aliases)
+	return &in, nil
+}
+
+// splitList parses the comma-separated tag columns.
+func splitList(joined string) []string {
+	if joined == "" {
+		return []string{}
+	}
+	return strings.Split(joined, ",")
+}
+
+func (s *pgStore) List(includeInactive bool) ([]Intent, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+intentColumns+` FROM intents
+		WHERE active OR $1
+		ORDER BY sort_order, id
+	`, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Intent
+	for rows.Next() {
+		in, err := scanIntent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *in)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Get(id string) (*Intent, error) {
+	in, err := scanIntent(s.db.QueryRowContext(context.Background(),
+		`SELECT `+intentColumns+` FROM intents WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return in, err
+}
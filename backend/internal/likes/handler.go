@@ -0,0 +1,300 @@
+package likes
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/notifications"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// Store persists likes and passes.
+type Store interface {
+	// Like records that in.FromUserID likes in.ToUserID. Liking the same
+	// person twice is a no-op that returns the original like. mutual reports
+	// whether the target already likes the sender back.
+	Like(in LikeInput) (like *Like, mutual bool, err error)
+	// Pass records that fromUserID is not interested in toUserID. Idempotent.
+	Pass(fromUserID, toUserID string) error
+	// Received lists likes sent to userID that they haven't answered with a
+	// like or pass yet, newest first.
+	Received(userID string, limit int) ([]Like, error)
+	// Sent lists the likes userID has sent, newest first.
+	Sent(userID string, limit int) ([]Like, error)
+	// ExcludedUserIDs returns everyone userID has liked or passed on, so the
+	// store can act as a discovery exclusion source.
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+	// DeleteUser removes every like and pass to or from userID.
+	DeleteUser(userID string) error
+}
+
+// Item types a like can target, matching LikesScreen's likedType.
+const (
+	ItemPhoto  = "photo"
+	ItemPrompt = "prompt"
+)
+
+// Like is one user's interest in another, optionally on a specific item.
+type Like struct {
+	FromUserID string    `json:"fromUserId"`
+	ToUserID   string    `json:"toUserId"`
+	ItemType   string    `json:"itemType,omitempty"`
+	ItemID     string    `json:"itemId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// LikeInput is the data needed to record a like.
+type LikeInput struct {
+	FromUserID string
+	ToUserID   string
+	ItemType   string
+	ItemID     string
+}
+
+const receivedLimit = 100
+
+// BlockList reports blocks between users in either direction;
+// safety.Store implements it.
+type BlockList interface {
+	Blocked(a, b string) (bool, error)
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+}
+
+// Handler exposes likes and passes over HTTP.
+type Handler struct {
+	logger   *log.Logger
+	store    Store
+	matches  matches.Store
+	profiles onboarding.Store
+	blocks   BlockList
+	push     *notifications.Service
+}
+
+func NewHandler(logger *log.Logger, store Store, matchStore matches.Store, profiles onboarding.Store, blocks BlockList,
+	push *notifications.Service) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		store:    store,
+		matches:  matchStore,
+		profiles: profiles,
+		blocks:   blocks,
+		push:     push,
+	}
+}
+
+// --- Request payloads ---
+
+type likeRequest struct {
+	TargetUserID string `json:"targetUserId"`
+	ItemType     string `json:"itemType"`
+	ItemID       string `json:"itemId"`
+}
+
+type passRequest struct {
+	TargetUserID string `json:"targetUserId"`
+}
+
+type likeResponse struct {
+	Like  *Like          `json:"like"`
+	Match *matches.Match `json:"match,omitempty"`
+}
+
+type userSummary struct {
+	UserID      string     `json:"userId"`
+	DisplayName string     `json:"displayName"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+}
+
+type receivedLike struct {
+	Like
+	From userSummary `json:"from"`
+}
+
+// --- Helpers ---
+
+// checkTarget validates that a like/pass target is someone else who exists.
+// Blocked users look like they don't exist, whichever side blocked.
+func (h *Handler) checkTarget(userID, targetID string) (int, error) {
+	if targetID == "" {
+		return http.StatusBadRequest, errors.New("targetUserId is required")
+	}
+	if targetID == userID {
+		return http.StatusBadRequest, errors.New("cannot target yourself")
+	}
+	if _, err := h.profiles.GetProfile(targetID); err != nil {
+		if errors.Is(err, onboarding.ErrProfileNotFound) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		h.logger.Printf("checkTarget profile error: %v", err)
+		return http.StatusInternalServerError, errors.New("failed to load user")
+	}
+	blocked, err := h.blocks.Blocked(userID, targetID)
+	if err != nil {
+		h.logger.Printf("checkTarget block error: %v", err)
+		return http.StatusInternalServerError, errors.New("failed to load user")
+	}
+	if blocked {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+	return 0, nil
+}
+
+// --- Handlers ---
+
+// Like handles POST /v1/likes
+func (h *Handler) Like(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req likeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	switch req.ItemType {
+	case "":
+		if req.ItemID != "" {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("itemType is required with itemId"))
+			return
+		}
+	case ItemPhoto, ItemPrompt:
+		if req.ItemID == "" {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("itemId is required with itemType"))
+			return
+		}
+	default:
+		httpjson.Error(w, http.StatusBadRequest, errors.New("itemType must be photo or prompt"))
+		return
+	}
+	if status, err := h.checkTarget(userID, req.TargetUserID); err != nil {
+		httpjson.Error(w, status, err)
+		return
+	}
+
+	like, mutual, err := h.store.Like(LikeInput{
+		FromUserID: userID,
+		ToUserID:   req.TargetUserID,
+		ItemType:   req.ItemType,
+		ItemID:     req.ItemID,
+	})
+	if err != nil {
+		h.logger.Printf("Like error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save like"))
+		return
+	}
+
+	resp := likeResponse{Like: like}
+	if mutual {
+		// Match creation is idempotent, so a retried like that lost the
+		// response still ends up with exactly one match.
+		m, created, err := h.matches.Create(userID, req.TargetUserID, matches.SourceLike)
+		if err != nil {
+			h.logger.Printf("Like match error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to create match"))
+			return
+		}
+		if m.State == matches.StateActive {
+			// An unmatched pair stays unmatched; don't resurface it.
+			resp.Match = m
+		}
+		if created {
+			// The caller learns of the match from the response.
+			h.push.Notify(notifications.Notification{
+				Kind:      notifications.KindMatch,
+				UserID:    req.TargetUserID,
+				ActorID:   userID,
+				SubjectID: m.ID,
+			})
+		}
+	}
+
+	httpjson.Write(w, http.StatusOK, resp)
+}
+
+// Pass handles POST /v1/passes
+func (h *Handler) Pass(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req passRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if status, err := h.checkTarget(userID, req.TargetUserID); err != nil {
+		httpjson.Error(w, status, err)
+		return
+	}
+
+	if err := h.store.Pass(userID, req.TargetUserID); err != nil {
+		h.logger.Printf("Pass error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save pass"))
+		return
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// Received handles GET /v1/likes/received
+func (h *Handler) Received(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	received, err := h.store.Received(userID, receivedLimit)
+	if err != nil {
+		h.logger.Printf("Received likes error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load likes"))
+		return
+	}
+	blocked, err := h.blocks.ExcludedUserIDs(userID)
+	if err != nil {
+		h.logger.Printf("Received likes block error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load likes"))
+		return
+	}
+
+	out := make([]receivedLike, 0, len(received))
+	for _, l := range received {
+		if _, skip := blocked[l.FromUserID]; skip {
+			continue
+		}
+		item := receivedLike{Like: l, From: userSummary{UserID: l.FromUserID}}
+		if p, err := h.profiles.GetProfile(l.FromUserID); err == nil {
+			item.From.DisplayName = p.DisplayName
+			item.From.VerifiedAt = p.VerifiedAt
+		}
+		out = append(out, item)
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"likes": out})
+}
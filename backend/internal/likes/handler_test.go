@@ -0,0 +1,93 @@
+package likes
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/notifications"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/safety"
+)
+
+func like(h *Handler, fromID, toID string) (int, likeResponse) {
+	body := strings.NewReader(`{"targetUserId":"` + toID + `"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/likes", body)
+	req = req.WithContext(auth.ContextWithUserID(req.Context(), fromID))
+	rec := httptest.NewRecorder()
+	h.Like(rec, req)
+
+	var resp likeResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+// Two people liking each other at the same moment must end up with one
+// match, shown to both, and exactly one push about it.
+func TestSimultaneousMutualLikes(t *testing.T) {
+	for round := range 50 {
+		logger := log.New(io.Discard, "", 0)
+		profiles := onboarding.NewInMemoryStore()
+		for _, id := range []string{"u1", "u2"} {
+			if err := profiles.MarkOnboardingComplete(id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		matchStore := matches.NewInMemoryStore()
+		outbox := notifications.NewInMemoryStore()
+		push := notifications.NewService(logger, outbox, profiles, notifications.NewFake(logger))
+		h := NewHandler(logger, NewInMemoryStore(), matchStore, profiles, safety.NewInMemoryStore(), push)
+
+		var wg sync.WaitGroup
+		responses := make([]likeResponse, 2)
+		for i, pair := range [][2]string{{"u1", "u2"}, {"u2", "u1"}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, resp := like(h, pair[0], pair[1])
+				if code != http.StatusOK {
+					t.Errorf("round %d: %s likes %s: status %d", round, pair[0], pair[1], code)
+				}
+				responses[i] = resp
+			}()
+		}
+		wg.Wait()
+
+		ms, err := matchStore.List("u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != 1 {
+			t.Fatalf("round %d: %d matches, want 1", round, len(ms))
+		}
+		// Whichever like landed second learns of the match; the first may
+		// too if it read the other like after it was stored.
+		shown := 0
+		for _, resp := range responses {
+			if resp.Match != nil {
+				shown++
+				if resp.Match.ID != ms[0].ID {
+					t.Errorf("round %d: response shows match %s, want %s", round, resp.Match.ID, ms[0].ID)
+				}
+			}
+		}
+		if shown == 0 {
+			t.Errorf("round %d: neither side was shown the match", round)
+		}
+		pushes, err := outbox.Claim(time.Now().Add(time.Hour), 10, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pushes) != 1 {
+			t.Errorf("round %d: %d match pushes, want 1", round, len(pushes))
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package likes
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	likes  map[[2]string]*Like // (from, to) -> like
+	passes map[[2]string]time.Time
+}
+
+// NewInMemoryStore returns an in-memory likes store. A single mutex covers
+// both directions of a pair, so concurrent mutual likes always see each other.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		likes:  make(map[[2]string]*Like),
+		passes: make(map[[2]string]time.Time),
+	}
+}
+
+func (s *memoryStore) Like(in LikeInput) (*Like, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [2]string{in.FromUserID, in.ToUserID}
+	l, ok := s.likes[key]
+	if !ok {
+		l = &Like{
+			FromUserID: in.FromUserID,
+			ToUserID:   in.ToUserID,
+			ItemType:   in.ItemType,
+			ItemID:     in.ItemID,
+			CreatedAt:  time.Now(),
+		}
+		s.likes[key] = l
+	}
+	_, mutual := s.likes[[2]string{in.ToUserID, in.FromUserID}]
+	cp := *l
+	return &cp, mutual, nil
+}
+
+func (s *memoryStore) Pass(fromUserID, toUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]string{fromUserID, toUserID}
+	if _, ok := s.passes[key]; !ok {
+		s.passes[key] = time.Now()
+	}
+	return nil
+}
+
+func (s *memoryStore) Received(userID string, limit int) ([]Like, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Like
+	for key, l := range s.likes {
+		if key[1] != userID {
+			continue
+		}
+		answered := [2]string{userID, key[0]}
+		if _, ok := s.likes[answered]; ok {
+			continue
+		}
+		if _, ok := s.passes[answered]; ok {
+			continue
+		}
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Sent(userID string, limit int) ([]Like, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Like
+	for key, l := range s.likes {
+		if key[0] == userID {
+			out = append(out, *l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]struct{})
+	for key := range s.likes {
+		if key[0] == userID {
+			out[key[1]] = struct{}{}
+		}
+	}
+	for key := range s.passes {
+		if key[0] == userID {
+			out[key[1]] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.likes {
+		if key[0] == userID || key[1] == userID {
+			delete(s.likes, key)
+		}
+	}
+	for key := range s.passes {
+		if key[0] == userID || key[1] == userID {
+			delete(s.passes, key)
+		}
+	}
+	return nil
+}
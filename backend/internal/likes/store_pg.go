@@ -0,0 +1,176 @@
+package likes
+
+import (
+	"context"
+	"database/sql"
+)
+
+// pgStore persists likes and passes in the tables from sql/0002_discovery.sql
+// and sql/0004_likes.sql.
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a likes Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+// Like runs in a transaction holding an advisory lock on the user pair. When
+// two people like each other at the same moment, the second transaction waits
+// for the first to commit and therefore sees its like, so at least one of
+// them reports the mutual like and the match gets created.
+func (s *pgStore) Like(in LikeInput) (*Like, bool, error) {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPair(ctx, tx, in.FromUserID, in.ToUserID); err != nil {
+		return nil, false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO likes (from_user_id, to_user_id, item_type, item_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (from_user_id, to_user_id) DO NOTHING
+	`, in.FromUserID, in.ToUserID, in.ItemType, in.ItemID); err != nil {
+		return nil, false, err
+	}
+
+	l := Like{FromUserID: in.FromUserID, ToUserID: in.ToUserID}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(item_type, ''), COALESCE(item_id, ''), created_at
+		FROM likes WHERE from_user_id = $1 AND to_user_id = $2
+	`, in.FromUserID, in.ToUserID).Scan(&l.ItemType, &l.ItemID, &l.CreatedAt); err != nil {
+		return nil, false, err
+	}
+
+	var mutual bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM likes WHERE from_user_id = $1 AND to_user_id = $2)
+	`, in.ToUserID, in.FromUserID).Scan(&mutual); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return &l, mutual, nil
+}
+
+// lockPair takes a transaction-scoped advisory lock keyed on the unordered
+// user pair.
+func lockPair(ctx context.Context, tx *sql.Tx, a, b string) error {
+	if a > b {
+		a, b = b, a
+	}
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, a+"|"+b)
+	return err
+}
+
+func (s *pgStore) Pass(fromUserID, toUserID string) error {
+	ctx := context.Background()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO passes (from_user_id, to_user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (from_user_id, to_user_id) DO NOTHING
+	`, fromUserID, toUserID)
+	return err
+}
+
+func (s *pgStore) Received(userID string, limit int) ([]Like, error) {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.from_user_id, l.to_user_id, COALESCE(l.item_type, ''), COALESCE(l.item_id, ''), l.created_at
+		FROM likes l
+		WHERE l.to_user_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM likes r WHERE r.from_user_id = $1 AND r.to_user_id = l.from_user_id)
+		  AND NOT EXISTS (SELECT 1 FROM passes p WHERE p.from_user_id = $1 AND p.to_user_id = l.from_user_id)
+		ORDER BY l.created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Like
+	for rows.Next() {
+		var l Like
+		if err := rows.Scan(&l.FromUserID, &l.ToUserID, &l.ItemType, &l.ItemID, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Sent(userID string, limit int) ([]Like, error) {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT from_user_id, to_user_id, COALESCE(item_type, ''), COALESCE(item_id, ''), created_at
+		FROM likes
+		WHERE from_user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Like
+	for rows.Next() {
+		var l Like
+		if err := rows.Scan(&l.FromUserID, &l.ToUserID, &l.ItemType, &l.ItemID, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT to_user_id FROM likes WHERE from_user_id = $1
+		UNION
+		SELECT to_user_id FROM passes WHERE from_user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = struct{}{}
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM likes WHERE from_user_id = $1 OR to_user_id = $1`,
+		`DELETE FROM passes WHERE from_user_id = $1 OR to_user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,35 @@
+package matches
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultExpiry is how long a match may sit without a first message.
+const DefaultExpiry = 7 * 24 * time.Hour
+
+// RunExpiry periodically expires matches that nobody messaged within ttl.
+// It blocks until ctx is cancelled.
+func RunExpiry(ctx context.Context, logger *log.Logger, store Store, ttl, every time.Duration) {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		expired, err := store.ExpireStale(time.Now().Add(-ttl))
+		if err != nil {
+			logger.Printf("match expiry error: %v", err)
+		} else if len(expired) > 0 {
+			logger.Printf("expired %d unmessaged matches", len(expired))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package matches
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// ConversationSummarizer supplies chat previews for the matches list. The
+// conversation for a match shares the match's ID.
+type ConversationSummarizer interface {
+	Summaries(userID string, matchIDs []string) (map[string]ConversationSummary, error)
+}
+
+// ConversationSummary is the chat state shown next to a match.
+type ConversationSummary struct {
+	LastMessage *MessagePreview `json:"lastMessage,omitempty"`
+	UnreadCount int             `json:"unreadCount"`
+}
+
+// MessagePreview is a trimmed-down message for list views.
+type MessagePreview struct {
+	ID       string    `json:"id"`
+	SenderID string    `json:"senderId"`
+	Kind     string    `json:"kind"`
+	Text     string    `json:"text,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+	// Hidden is set for soft-hidden messages; their text is left out.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Handler exposes matches over HTTP.
+type Handler struct {
+	logger     *log.Logger
+	store      Store
+	profiles   onboarding.Store
+	summarizer ConversationSummarizer
+}
+
+// NewHandler builds a matches handler. summarizer may be nil, in which case
+// matches are listed without chat previews.
+func NewHandler(logger *log.Logger, store Store, profiles onboarding.Store, summarizer ConversationSummarizer) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:     logger,
+		store:      store,
+		profiles:   profiles,
+		summarizer: summarizer,
+	}
+}
+
+type userSummary struct {
+	UserID      string     `json:"userId"`
+	DisplayName string     `json:"displayName"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+}
+
+type matchItem struct {
+	ID        string      `json:"id"`
+	User      userSummary `json:"user"`
+	Source    string      `json:"source"`
+	State     string      `json:"state"`
+	CreatedAt time.Time   `json:"createdAt"`
+	ConversationSummary
+}
+
+// --- Helpers ---
+
+// lastActivity orders the list like a messaging inbox: most recent message
+// first, falling back to when the match was made.
+func (m matchItem) lastActivity() time.Time {
+	if m.LastMessage != nil {
+		return m.LastMessage.SentAt
+	}
+	return m.CreatedAt
+}
+
+// --- Handlers ---
+
+// List handles GET /v1/matches
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	list, err := h.store.ListActive(userID)
+	if err != nil {
+		h.logger.Printf("List matches error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load matches"))
+		return
+	}
+
+	var summaries map[string]ConversationSummary
+	if h.summarizer != nil && len(list) > 0 {
+		ids := make([]string, 0, len(list))
+		for _, m := range list {
+			ids = append(ids, m.ID)
+		}
+		summaries, err = h.summarizer.Summaries(userID, ids)
+		if err != nil {
+			h.logger.Printf("List matches summaries error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load matches"))
+			return
+		}
+	}
+
+	out := make([]matchItem, 0, len(list))
+	for _, m := range list {
+		other := m.Other(userID)
+		item := matchItem{
+			ID:                  m.ID,
+			User:                userSummary{UserID: other},
+			Source:              m.Source,
+			State:               m.State,
+			CreatedAt:           m.CreatedAt,
+			ConversationSummary: summaries[m.ID],
+		}
+		if p, err := h.profiles.GetProfile(other); err == nil {
+			item.User.DisplayName = p.DisplayName
+			item.User.VerifiedAt = p.VerifiedAt
+		}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].lastActivity().After(out[j].lastActivity())
+	})
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"matches": out})
+}
+
+// Unmatch handles DELETE /v1/matches/{id}
+//
+// The match moves to the unmatched state, which hides its conversation from
+// both participants; messages are kept for moderation but can no longer be
+// read or sent by either side.
+func (h *Handler) Unmatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if _, err := h.store.Unmatch(r.PathValue("id"), userID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpjson.Error(w, http.StatusNotFound, err)
+			return
+		}
+		h.logger.Printf("Unmatch error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to unmatch"))
+		return
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
+}
@@ -0,0 +1,90 @@
+package matches
+
+import (
+	"errors"
+	"time"
+)
+
+// Match links two users who expressed mutual interest. UserAID always sorts
+// before UserBID so each pair has exactly one match record.
+type Match struct {
+	ID             string     `json:"id"`
+	UserAID        string     `json:"userAId"`
+	UserBID        string     `json:"userBId"`
+	Source         string     `json:"source"`
+	State          string     `json:"state"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	FirstMessageAt *time.Time `json:"firstMessageAt,omitempty"`
+	EndedAt        *time.Time `json:"endedAt,omitempty"`
+	EndedBy        string     `json:"-"`
+}
+
+// Sources record how a match came about.
+const (
+	SourceLike      = "like"
+	SourceSpark     = "spark"
+	SourceBlindDate = "blind_date"
+)
+
+// Lifecycle states. Only active matches can chat; unmatched and expired are
+// terminal and hide the conversation from both participants.
+const (
+	StateActive    = "active"
+	StateUnmatched = "unmatched"
+	StateExpired   = "expired"
+)
+
+var ErrNotFound = errors.New("match not found")
+
+// Store persists matches.
+type Store interface {
+	// Create records a match between two users. It is idempotent: if the pair
+	// already has a match, the existing record is returned with created=false.
+	Create(userA, userB, source string) (m *Match, created bool, err error)
+	// Get returns a match by ID, or ErrNotFound.
+	Get(id string) (*Match, error)
+	// Find returns the match between two users in any state, or ErrNotFound.
+	Find(userA, userB string) (*Match, error)
+	// ListActive returns userID's active matches, newest first.
+	ListActive(userID string) ([]Match, error)
+	// List returns every match userID was part of, in any state, newest
+	// first.
+	List(userID string) ([]Match, error)
+	// Unmatch ends an active match on behalf of one participant. Unmatching
+	// twice is a no-op; non-participants get ErrNotFound.
+	Unmatch(id, userID string) (*Match, error)
+	// MarkMessaged records the time of the first message in a match, which
+	// stops it from expiring. Later calls keep the original time.
+	MarkMessaged(id string, at time.Time) error
+	// ExpireStale moves active matches created before cutoff that never
+	// exchanged a message to StateExpired and returns them.
+	ExpireStale(cutoff time.Time) ([]Match, error)
+	// DeleteUser removes every match userID was part of.
+	DeleteUser(userID string) error
+}
+
+// OrderPair returns the two user IDs in canonical (sorted) order.
+func OrderPair(a, b string) (string, string) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// Other returns the participant that isn't userID.
+func (m *Match) Other(userID string) string {
+	if m.UserAID == userID {
+		return m.UserBID
+	}
+	return m.UserAID
+}
+
+// Has reports whether userID is one of the two participants.
+func (m *Match) Has(userID string) bool {
+	return m.UserAID == userID || m.UserBID == userID
+}
+
+// ActiveFor reports whether userID may use the match's conversation.
+func (m *Match) ActiveFor(userID string) bool {
+	return m.State == StateActive && m.Has(userID)
+}
@@ -0,0 +1,167 @@
+package matches
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	byID   map[string]*Match
+	byPair map[[2]string]*Match
+}
+
+// NewInMemoryStore returns an in-memory match store.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		byID:   make(map[string]*Match),
+		byPair: make(map[[2]string]*Match),
+	}
+}
+
+func cloneMatch(m *Match) *Match {
+	cp := *m
+	if m.FirstMessageAt != nil {
+		t := *m.FirstMessageAt
+		cp.FirstMessageAt = &t
+	}
+	if m.EndedAt != nil {
+		t := *m.EndedAt
+		cp.EndedAt = &t
+	}
+	return &cp
+}
+
+func (s *memoryStore) Create(userA, userB, source string) (*Match, bool, error) {
+	a, b := OrderPair(userA, userB)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.byPair[[2]string{a, b}]; ok {
+		return cloneMatch(m), false, nil
+	}
+	m := &Match{
+		ID:        idgen.New(),
+		UserAID:   a,
+		UserBID:   b,
+		Source:    source,
+		State:     StateActive,
+		CreatedAt: time.Now(),
+	}
+	s.byID[m.ID] = m
+	s.byPair[[2]string{a, b}] = m
+	return cloneMatch(m), true, nil
+}
+
+func (s *memoryStore) Get(id string) (*Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneMatch(m), nil
+}
+
+func (s *memoryStore) Find(userA, userB string) (*Match, error) {
+	a, b := OrderPair(userA, userB)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.byPair[[2]string{a, b}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneMatch(m), nil
+}
+
+func (s *memoryStore) ListActive(userID string) ([]Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Match
+	for _, m := range s.byID {
+		if m.ActiveFor(userID) {
+			out = append(out, *cloneMatch(m))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) List(userID string) ([]Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Match
+	for _, m := range s.byID {
+		if m.Has(userID) {
+			out = append(out, *cloneMatch(m))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) Unmatch(id, userID string) (*Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.byID[id]
+	if !ok || !m.Has(userID) {
+		return nil, ErrNotFound
+	}
+	if m.State == StateActive {
+		now := time.Now()
+		m.State = StateUnmatched
+		m.EndedAt = &now
+		m.EndedBy = userID
+	}
+	return cloneMatch(m), nil
+}
+
+func (s *memoryStore) MarkMessaged(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if m.FirstMessageAt == nil {
+		m.FirstMessageAt = &at
+	}
+	return nil
+}
+
+func (s *memoryStore) ExpireStale(cutoff time.Time) ([]Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Match
+	now := time.Now()
+	for _, m := range s.byID {
+		if m.State == StateActive && m.FirstMessageAt == nil && m.CreatedAt.Before(cutoff) {
+			m.State = StateExpired
+			m.EndedAt = &now
+			out = append(out, *cloneMatch(m))
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, m := range s.byID {
+		if m.Has(userID) {
+			delete(s.byID, id)
+			delete(s.byPair, [2]string{m.UserAID, m.UserBID})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+// pgStore persists matches in the matches table (sql/0004_likes.sql, with
+// lifecycle columns from sql/0006_matches.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a match Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const matchColumns = `id, user_a_id, user_b_id, source, state, created_at, first_message_at, ended_at, COALESCE(ended_by, '')`
+
+func scanMatch(row interface{ Scan(...any) error }) (*Match, error) {
+	var (
+		m                       Match
+		firstMessageAt, endedAt sql.NullTime
+	)
+	if err := row.Scan(&m.ID, &m.UserAID, &m.UserBID, &m.Source, &m.State,
+		&m.CreatedAt, &firstMessageAt, &endedAt, &m.EndedBy); err != nil {
+		return nil, err
+	}
+	if firstMessageAt.Valid {
+		t := firstMessageAt.Time
+		m.FirstMessageAt = &t
+	}
+	if endedAt.Valid {
+		t := endedAt.Time
+		m.EndedAt = &t
+	}
+	return &m, nil
+}
+
+func (s *pgStore) scanAll(rows *sql.Rows) ([]Match, error) {
+	defer rows.Close()
+	var out []Match
+	for rows.Next() {
+		m, err := scanMatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Create(userA, userB, source string) (*Match, bool, error) {
+	ctx := context.Background()
+	a, b := OrderPair(userA, userB)
+
+	m, err := scanMatch(s.db.QueryRowContext(ctx, `
+		INSERT INTO matches (id, user_a_id, user_b_id, source)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_a_id, user_b_id) DO NOTHING
+		RETURNING `+matchColumns, idgen.New(), a, b, source))
+	if err == nil {
+		return m, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	// The pair already matched; return the existing record.
+	m, err = scanMatch(s.db.QueryRowContext(ctx, `
+		SELECT `+matchColumns+` FROM matches WHERE user_a_id = $1 AND user_b_id = $2
+	`, a, b))
+	if err != nil {
+		return nil, false, err
+	}
+	return m, false, nil
+}
+
+func (s *pgStore) Get(id string) (*Match, error) {
+	m, err := scanMatch(s.db.QueryRowContext(context.Background(), `
+		SELECT `+matchColumns+` FROM matches WHERE id = $1
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+func (s *pgStore) Find(userA, userB string) (*Match, error) {
+	a, b := OrderPair(userA, userB)
+	m, err := scanMatch(s.db.QueryRowContext(context.Background(), `
+		SELECT `+matchColumns+` FROM matches WHERE user_a_id = $1 AND user_b_id = $2
+	`, a, b))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+func (s *pgStore) ListActive(userID string) ([]Match, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+matchColumns+` FROM matches
+		WHERE (user_a_id = $1 OR user_b_id = $1) AND state = 'active'
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanAll(rows)
+}
+
+func (s *pgStore) List(userID string) ([]Match, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+matchColumns+` FROM matches
+		WHERE user_a_id = $1 OR user_b_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanAll(rows)
+}
+
+func (s *pgStore) Unmatch(id, userID string) (*Match, error) {
+	ctx := context.Background()
+	m, err := scanMatch(s.db.QueryRowContext(ctx, `
+		UPDATE matches SET state = 'unmatched', ended_at = now(), ended_by = $2
+		WHERE id = $1 AND (user_a_id = $2 OR user_b_id = $2) AND state = 'active'
+		RETURNING `+matchColumns, id, userID))
+	if err == nil {
+		return m, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Already ended, or not a participant.
+	m, err = s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Has(userID) {
+		return nil, ErrNotFound
+	}
+	return m, nil
+}
+
+func (s *pgStore) MarkMessaged(id string, at time.Time) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		UPDATE matches SET first_message_at = $2 WHERE id = $1 AND first_message_at IS NULL
+	`, id, at)
+	return err
+}
+
+func (s *pgStore) ExpireStale(cutoff time.Time) ([]Match, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		UPDATE matches SET state = 'expired', ended_at = now()
+		WHERE state = 'active' AND first_message_at IS NULL AND created_at < $1
+		RETURNING `+matchColumns, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanAll(rows)
+}
+
+// DeleteUser also takes the matches' messages along (ON DELETE CASCADE).
+func (s *pgStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM matches WHERE user_a_id = $1 OR user_b_id = $1
+	`, userID)
+	return err
+}
@@ -0,0 +1,343 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+)
+
+// PeakCount is how many waveform bars ProbeAudio returns.
+const PeakCount = 48
+
+// Audio content types accepted for upload, normalised.
+const (
+	AudioMP4 = "audio/mp4"
+	AudioWAV = "audio/wav"
+)
+
+// AudioInfo is what the server derives from an uploaded clip.
+type AudioInfo struct {
+	ContentType string
+	DurationMs  int
+	// Peaks are PeakCount values in [0,1], one per waveform bar.
+	Peaks []float64
+}
+
+var (
+	ErrUnsupportedAudio = errors.New("unsupported audio format")
+	ErrMalformedAudio   = errors.New("malformed audio file")
+)
+
+// NormalizeAudioType maps the content types clients send to AudioMP4 or
+// AudioWAV. It returns "" for anything else.
+func NormalizeAudioType(contentType string) string {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(ct) {
+	case "audio/mp4", "audio/m4a", "audio/x-m4a", "audio/aac", "audio/mp4a-latm":
+		return AudioMP4
+	case "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return AudioWAV
+	}
+	return ""
+}
+
+// ProbeAudio reads the duration of a clip and computes its waveform peaks.
+//
+// WAV peaks are true sample amplitudes. For MP4/AAC, decoding would need a
+// codec, so peaks are estimated from the size of each encoded frame: AAC
+// spends more bits on loud, busy passages and very few on silence, which is
+// close enough for a waveform and identical on every client.
+func ProbeAudio(contentType string, data []byte) (*AudioInfo, error) {
+	switch NormalizeAudioType(contentType) {
+	case AudioWAV:
+		return probeWAV(data)
+	case AudioMP4:
+		return probeMP4(data)
+	}
+	return nil, ErrUnsupportedAudio
+}
+
+// --- WAV ---
+
+func probeWAV(data []byte) (*AudioInfo, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, ErrMalformedAudio
+	}
+
+	var (
+		format, channels, blockAlign, bits uint16
+		sampleRate                         uint32
+		pcm                                []byte
+		haveFmt                            bool
+	)
+	for p := 12; p+8 <= len(data); {
+		id := string(data[p : p+4])
+		size := int(binary.LittleEndian.Uint32(data[p+4 : p+8]))
+		body := data[p+8:]
+		if size > len(body) {
+			// Some recorders leave the data size unset; take what's there.
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, ErrMalformedAudio
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = binary.LittleEndian.Uint16(body[2:4])
+			sampleRate = binary.LittleEndian.Uint32(body[4:8])
+			blockAlign = binary.LittleEndian.Uint16(body[12:14])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			haveFmt = true
+		case "data":
+			pcm = body
+		}
+		p += 8 + size + size%2
+	}
+
+	// 1 is integer PCM; 0xFFFE (extensible) is accepted for the common
+	// case where it wraps integer PCM.
+	if !haveFmt || pcm == nil || (format != 1 && format != 0xFFFE) {
+		return nil, ErrUnsupportedAudio
+	}
+	bytesPerSample := int(bits) / 8
+	if channels == 0 || sampleRate == 0 || bytesPerSample < 1 || bytesPerSample > 4 ||
+		int(blockAlign) != bytesPerSample*int(channels) {
+		return nil, ErrMalformedAudio
+	}
+
+	frames := len(pcm) / int(blockAlign)
+	info := &AudioInfo{
+		ContentType: AudioWAV,
+		DurationMs:  int(int64(frames) * 1000 / int64(sampleRate)),
+	}
+
+	// Peak absolute amplitude of the first channel per bar.
+	raw := make([]float64, PeakCount)
+	for bar := range raw {
+		start, end := bar*frames/PeakCount, (bar+1)*frames/PeakCount
+		for f := start; f < end; f++ {
+			off := f * int(blockAlign)
+			if v := math.Abs(pcmSample(pcm[off:off+bytesPerSample], bytesPerSample)); v > raw[bar] {
+				raw[bar] = v
+			}
+		}
+	}
+	info.Peaks = scalePeaks(raw, 0)
+	return info, nil
+}
+
+// pcmSample decodes one little-endian sample to [-1,1]. 8-bit WAV is
+// unsigned; wider samples are signed.
+func pcmSample(b []byte, width int) float64 {
+	switch width {
+	case 1:
+		return (float64(b[0]) - 128) / 128
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// --- MP4 ---
+
+// mp4Track is what we need from the first sound track.
+type mp4Track struct {
+	handler     string
+	timescale   uint32
+	duration    uint64
+	sampleSizes []uint32
+}
+
+func probeMP4(data []byte) (*AudioInfo, error) {
+	var (
+		track *mp4Track
+		found bool
+	)
+	err := walkBoxes(data, func(path, typ string, body []byte) (bool, error) {
+		if found {
+			return false, nil
+		}
+		switch path + typ {
+		case "moov/trak":
+			track = &mp4Track{}
+		case "moov/trak/mdia/hdlr":
+			if track != nil && len(body) >= 12 {
+				track.handler = string(body[8:12])
+			}
+		case "moov/trak/mdia/mdhd":
+			if track != nil {
+				track.timescale, track.duration = parseMediaHeader(body)
+			}
+		case "moov/trak/mdia/minf/stbl/stsz":
+			if track != nil {
+				track.sampleSizes = parseSampleSizes(body)
+				if track.handler == "soun" {
+					found = true
+				}
+			}
+		}
+		// Descend into containers on the path to stsz.
+		switch typ {
+		case "moov", "trak", "mdia", "minf", "stbl":
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found || track.timescale == 0 {
+		return nil, ErrMalformedAudio
+	}
+
+	info := &AudioInfo{
+		ContentType: AudioMP4,
+		DurationMs:  int(track.duration * 1000 / uint64(track.timescale)),
+	}
+
+	n := len(track.sampleSizes)
+	raw := make([]float64, PeakCount)
+	if n > 0 {
+		for bar := range raw {
+			start, end := bar*n/PeakCount, (bar+1)*n/PeakCount
+			if end == start {
+				end = start + 1
+			}
+			var sum float64
+			for _, size := range track.sampleSizes[start:min(end, n)] {
+				sum += float64(size)
+			}
+			raw[bar] = sum / float64(end-start)
+		}
+	}
+	// Frame sizes never reach zero, so measure from the quietest bar.
+	info.Peaks = scalePeaks(raw, minOf(raw))
+	return info, nil
+}
+
+// walkBoxes visits ISO BMFF boxes depth-first. visit receives the parent
+// path ("moov/trak/") and the box type, and returns whether to descend.
+func walkBoxes(data []byte, visit func(path, typ string, body []byte) (bool, error)) error {
+	var walk func(data []byte, path string, depth int) error
+	walk = func(data []byte, path string, depth int) error {
+		if depth > 8 {
+			return ErrMalformedAudio
+		}
+		for p := 0; p < len(data); {
+			if len(data)-p < 8 {
+				return ErrMalformedAudio
+			}
+			size := uint64(binary.BigEndian.Uint32(data[p : p+4]))
+			typ := string(data[p+4 : p+8])
+			header := uint64(8)
+			switch size {
+			case 0:
+				size = uint64(len(data) - p)
+			case 1:
+				if len(data)-p < 16 {
+					return ErrMalformedAudio
+				}
+				size = binary.BigEndian.Uint64(data[p+8 : p+16])
+				header = 16
+			}
+			if size < header || size > uint64(len(data)-p) {
+				return ErrMalformedAudio
+			}
+			body := data[p+int(header) : p+int(size)]
+			descend, err := visit(path, typ, body)
+			if err != nil {
+				return err
+			}
+			if descend {
+				if err := walk(body, path+typ+"/", depth+1); err != nil {
+					return err
+				}
+			}
+			p += int(size)
+		}
+		return nil
+	}
+	return walk(data, "", 0)
+}
+
+// parseMediaHeader reads timescale and duration from an mdhd/mvhd box.
+func parseMediaHeader(b []byte) (timescale uint32, duration uint64) {
+	if len(b) < 4 {
+		return 0, 0
+	}
+	if b[0] == 1 {
+		if len(b) < 32 {
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(b[20:24]), binary.BigEndian.Uint64(b[24:32])
+	}
+	if len(b) < 20 {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint32(b[12:16]), uint64(binary.BigEndian.Uint32(b[16:20]))
+}
+
+// parseSampleSizes reads an stsz box.
+func parseSampleSizes(b []byte) []uint32 {
+	if len(b) < 12 {
+		return nil
+	}
+	fixed := binary.BigEndian.Uint32(b[4:8])
+	count := int(binary.BigEndian.Uint32(b[8:12]))
+	if fixed != 0 {
+		// Every frame the same size: a flat waveform.
+		sizes := make([]uint32, min(count, PeakCount))
+		for i := range sizes {
+			sizes[i] = fixed
+		}
+		return sizes
+	}
+	if count > (len(b)-12)/4 {
+		count = (len(b) - 12) / 4
+	}
+	sizes := make([]uint32, count)
+	for i := range sizes {
+		sizes[i] = binary.BigEndian.Uint32(b[12+4*i:])
+	}
+	return sizes
+}
+
+// --- Helpers ---
+
+// scalePeaks maps raw levels to [0,1] relative to floor and the loudest
+// bar, rounded to two decimals to keep payloads small. A flat or silent
+// clip gets a uniform low waveform.
+func scalePeaks(raw []float64, floor float64) []float64 {
+	top := 0.0
+	for _, v := range raw {
+		top = max(top, v)
+	}
+	out := make([]float64, len(raw))
+	for i, v := range raw {
+		if top-floor <= 0 {
+			out[i] = 0.1
+			continue
+		}
+		out[i] = math.Round((v-floor)/(top-floor)*100) / 100
+	}
+	return out
+}
+
+func minOf(vs []float64) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+	m := vs[0]
+	for _, v := range vs[1:] {
+		m = min(m, v)
+	}
+	return m
+}
@@ -0,0 +1,44 @@
+// Package media stores user-uploaded files and serves them through
+// short-lived signed URLs.
+package media
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// BlobStore holds uploaded media by key. Keys are slash-separated paths
+// whose first segment is a namespace, e.g. "chat/<conversationID>/<id>".
+type BlobStore interface {
+	Put(key, contentType string, data []byte) error
+	// Open returns the blob's content and metadata. Callers must close it.
+	Open(key string) (io.ReadSeekCloser, Info, error)
+	Delete(key string) error
+	// DeleteAll removes every blob under prefix, itself a key such as
+	// "chat/<conversationID>".
+	DeleteAll(prefix string) error
+}
+
+// Info describes a stored blob.
+type Info struct {
+	ContentType string
+	Size        int64
+}
+
+// ErrNotFound is returned when a blob doesn't exist.
+var ErrNotFound = errors.New("blob not found")
+
+var keyPattern = regexp.MustCompile(`^[a-z]+(/[A-Za-z0-9_-]+)+$`)
+
+// ValidKey reports whether key is safe to use with a BlobStore.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// KeySegment encodes s, such as a user ID, for use as one segment of a key.
+// User IDs carry characters like ':' and '+' that keys don't allow.
+func KeySegment(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
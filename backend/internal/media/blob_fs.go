@@ -0,0 +1,103 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fsBlobStore keeps blobs as files under a root directory, with the content
+// type in a sidecar file.
+type fsBlobStore struct {
+	root string
+}
+
+// NewFSBlobStore returns a BlobStore rooted at dir, creating it if needed.
+func NewFSBlobStore(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fsBlobStore{root: dir}, nil
+}
+
+func (s *fsBlobStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *fsBlobStore) Put(key, contentType string, data []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write to a temp file first so readers never see a partial blob.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p+".type", []byte(contentType), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *fsBlobStore) Open(key string) (io.ReadSeekCloser, Info, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, Info{}, ErrNotFound
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, Info{}, ErrNotFound
+		}
+		return nil, Info{}, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	info := Info{ContentType: "application/octet-stream", Size: st.Size()}
+	if ct, err := os.ReadFile(p + ".type"); err == nil {
+		info.ContentType = strings.TrimSpace(string(ct))
+	}
+	return f, info, nil
+}
+
+func (s *fsBlobStore) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{p, p + ".type"} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fsBlobStore) DeleteAll(prefix string) error {
+	p, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(p)
+}
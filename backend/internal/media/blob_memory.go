@@ -0,0 +1,70 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+type memoryBlob struct {
+	data        []byte
+	contentType string
+}
+
+type memoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+// NewInMemoryBlobStore returns a BlobStore that keeps everything in memory.
+// Suitable for development only.
+func NewInMemoryBlobStore() BlobStore {
+	return &memoryBlobStore{blobs: make(map[string]memoryBlob)}
+}
+
+func (s *memoryBlobStore) Put(key, contentType string, data []byte) error {
+	if !ValidKey(key) {
+		return errors.New("invalid blob key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = memoryBlob{data: bytes.Clone(data), contentType: contentType}
+	return nil
+}
+
+func (s *memoryBlobStore) Open(key string) (io.ReadSeekCloser, Info, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, Info{}, ErrNotFound
+	}
+	r := nopCloser{bytes.NewReader(b.data)}
+	return r, Info{ContentType: b.contentType, Size: int64(len(b.data))}, nil
+}
+
+func (s *memoryBlobStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryBlobStore) DeleteAll(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix+"/") {
+			delete(s.blobs, key)
+		}
+	}
+	return nil
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// AccessFunc reports whether viewerID may still fetch key. It is checked on
+// every request, so access ends as soon as the underlying relationship does
+// (e.g. an unmatch), even if a signed URL hasn't expired.
+type AccessFunc func(viewerID, key string) (bool, error)
+
+// Handler serves blobs behind signed URLs.
+type Handler struct {
+	logger *log.Logger
+	blobs  BlobStore
+	signer *Signer
+
+	mu     sync.RWMutex
+	guards map[string]AccessFunc
+}
+
+func NewHandler(logger *log.Logger, blobs BlobStore, signer *Signer) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger: logger,
+		blobs:  blobs,
+		signer: signer,
+		guards: make(map[string]AccessFunc),
+	}
+}
+
+// Protect registers the access check for keys under namespace. Keys in a
+// namespace without a check are never served.
+func (h *Handler) Protect(namespace string, check AccessFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.guards[namespace] = check
+}
+
+// Serve handles GET /v1/media/{key...}
+//
+// The URL must carry a valid signature from Signer.URL. Range requests are
+// supported so players can seek.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	key := r.PathValue("key")
+	viewerID, ok := h.signer.Verify(key, r.URL.Query())
+	if !ok || !ValidKey(key) {
+		httpjson.Error(w, http.StatusForbidden, errors.New("invalid or expired link"))
+		return
+	}
+
+	namespace, _, _ := strings.Cut(key, "/")
+	h.mu.RLock()
+	check := h.guards[namespace]
+	h.mu.RUnlock()
+	if check == nil {
+		httpjson.Error(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	allowed, err := check(viewerID, key)
+	if err != nil {
+		h.logger.Printf("media: access check error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load media"))
+		return
+	}
+	if !allowed {
+		httpjson.Error(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	f, info, err := h.blobs.Open(key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpjson.Error(w, http.StatusNotFound, errors.New("not found"))
+			return
+		}
+		h.logger.Printf("media: open error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("could not load media"))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	// Blobs never change once written; the URL itself expires.
+	w.Header().Set("Cache-Control", "private, max-age=3600, immutable")
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
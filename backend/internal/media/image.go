@@ -0,0 +1,111 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"strings"
+)
+
+// Image content types accepted for upload.
+const (
+	ImageJPEG = "image/jpeg"
+	ImagePNG  = "image/png"
+)
+
+// MaxImageSide bounds either dimension of an uploaded image.
+const MaxImageSide = 8000
+
+// previewWidth is the width of blur previews. At this size nothing is
+// recognisable; clients scale it up, which blurs it further.
+const previewWidth = 24
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	ErrMalformedImage   = errors.New("malformed image")
+)
+
+// ImageInfo is what the server derives from an uploaded image.
+type ImageInfo struct {
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// NormalizeImageType maps a content type to ImageJPEG or ImagePNG, or "".
+func NormalizeImageType(contentType string) string {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(ct) {
+	case "image/jpeg", "image/jpg":
+		return ImageJPEG
+	case "image/png":
+		return ImagePNG
+	}
+	return ""
+}
+
+// ProbeImage checks that data is a JPEG or PNG of sane dimensions. The
+// format is taken from the bytes, not the declared type.
+func ProbeImage(data []byte) (*ImageInfo, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	info := &ImageInfo{Width: cfg.Width, Height: cfg.Height}
+	switch format {
+	case "jpeg":
+		info.ContentType = ImageJPEG
+	case "png":
+		info.ContentType = ImagePNG
+	default:
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > MaxImageSide || cfg.Height > MaxImageSide {
+		return nil, ErrMalformedImage
+	}
+	return info, nil
+}
+
+// BlurPreview returns a tiny JPEG version of an image, averaged down so no
+// detail survives.
+func BlurPreview(data []byte) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrMalformedImage
+	}
+	b := src.Bounds()
+	w := min(previewWidth, b.Dx())
+	h := max(1, b.Dy()*w/b.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			// Sample a bounded grid of each cell; averaging every source
+			// pixel of a large photo isn't worth the CPU.
+			var r, g, bl, a, n uint64
+			stepY, stepX := max(1, (y1-y0)/8), max(1, (x1-x0)/8)
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			if n > 0 {
+				dst.Set(x, y, color.RGBA64{
+					R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n),
+				})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 60}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,493 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/safety"
+	"github.com/rijey/kindl/backend/internal/verification"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+	// historyLimit bounds the earlier reports and actions shown with a case.
+	historyLimit    = 20
+	maxReasonLength = 1000
+	// maxSuspensionHours caps a suspension at a year; anything longer
+	// should be a ban.
+	maxSuspensionHours = 365 * 24
+)
+
+// EventWarning is the frame a warned user receives on their live sockets.
+const EventWarning = "moderation.warning"
+
+// Connections reaches users' live sockets; *chat.Hub implements it.
+type Connections interface {
+	SendFrame(v any, userIDs ...string)
+	// Disconnect closes every socket the user holds.
+	Disconnect(userID string)
+}
+
+// QueueLeaver takes a user out of the blind date queue; *blinddate.Queue
+// implements it.
+type QueueLeaver interface {
+	Leave(userID string) error
+}
+
+// Verifications settles selfie verification cases; *verification.Service
+// implements it.
+type Verifications interface {
+	Review(attemptID, viewerID string) (*verification.Review, error)
+	Decide(attemptID string, approved bool, reason string) (*verification.Attempt, error)
+}
+
+// Handler exposes the admin review API. The auth middleware only lets
+// admins reach /v1/admin/ routes.
+type Handler struct {
+	logger   *log.Logger
+	store    Store
+	reports  safety.ReportStore
+	accounts auth.AccountStore
+	profiles onboarding.Store
+	messages chat.MessageStore
+	conns    Connections
+	queue    QueueLeaver
+	verify   Verifications
+}
+
+func NewHandler(logger *log.Logger, store Store, reports safety.ReportStore, accounts auth.AccountStore,
+	profiles onboarding.Store, messages chat.MessageStore, conns Connections, queue QueueLeaver,
+	verify Verifications) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		store:    store,
+		reports:  reports,
+		accounts: accounts,
+		profiles: profiles,
+		messages: messages,
+		conns:    conns,
+		queue:    queue,
+		verify:   verify,
+	}
+}
+
+// --- Request payloads ---
+
+type actionRequest struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	// PhotoID names the photo to hide: one of the case's photo IDs or the
+	// ID of a reported image message.
+	PhotoID string `json:"photoId"`
+	// DurationHours is how long a suspension lasts.
+	DurationHours int `json:"durationHours"`
+}
+
+// ProfileView is the reported user's profile as a moderator sees it.
+type ProfileView struct {
+	UserID           string     `json:"userId"`
+	DisplayName      string     `json:"displayName"`
+	Gender           string     `json:"gender"`
+	Pronouns         string     `json:"pronouns,omitempty"`
+	Birthdate        string     `json:"birthdate"`
+	Intent           string     `json:"intent,omitempty"`
+	ConnectionStyle  string     `json:"connectionStyle,omitempty"`
+	Interests        []string   `json:"interests"`
+	PreferredGenders []string   `json:"preferredGenders"`
+	OnboardedAt      *time.Time `json:"onboardedAt,omitempty"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	PrimaryPhotoID   string     `json:"primaryPhotoId,omitempty"`
+	VerifiedAt       *time.Time `json:"verifiedAt,omitempty"`
+	HiddenPhotoIDs   []string   `json:"hiddenPhotoIds"`
+}
+
+// CaseDetail is everything a moderator needs to decide a case.
+type CaseDetail struct {
+	Case *safety.Report `json:"case"`
+	// Profile is nil when the reported user no longer has one.
+	Profile *ProfileView  `json:"profile"`
+	Account *auth.Account `json:"account"`
+	// History holds earlier reports about the same user.
+	History []safety.Report `json:"history"`
+	// Actions is the audit trail for the reported user.
+	Actions []Action `json:"actions"`
+	// Verification holds the selfie to compare with the profile, for
+	// verification cases.
+	Verification *verification.Review `json:"verification,omitempty"`
+}
+
+type warningEvent struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// --- Helpers ---
+
+func listLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultListLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(n, maxListLimit), nil
+}
+
+func (h *Handler) profileView(userID string) (*ProfileView, error) {
+	p, err := h.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := h.store.HiddenPhotos(userID)
+	if err != nil {
+		return nil, err
+	}
+	v := &ProfileView{
+		UserID:           p.UserID,
+		DisplayName:      p.DisplayName,
+		Gender:           p.Gender,
+		Pronouns:         p.Pronouns,
+		Birthdate:        p.Birthdate,
+		Intent:           p.Intent,
+		ConnectionStyle:  p.ConnectionStyle,
+		Interests:        p.Interests,
+		PreferredGenders: p.PreferredGenders,
+		OnboardedAt:      p.OnboardedAt,
+		UpdatedAt:        p.UpdatedAt,
+		PrimaryPhotoID:   p.PrimaryPhotoID,
+		VerifiedAt:       p.VerifiedAt,
+		HiddenPhotoIDs:   make([]string, 0, len(hidden)),
+	}
+	for id := range hidden {
+		v.HiddenPhotoIDs = append(v.HiddenPhotoIDs, id)
+	}
+	slices.Sort(v.HiddenPhotoIDs)
+	return v, nil
+}
+
+// validateAction checks a request against the case it acts on and returns
+// the suspension end, if any.
+func validateAction(req *actionRequest, c *safety.Report, now time.Time) (*time.Time, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(req.Reason) > maxReasonLength {
+		return nil, errors.New("reason is too long")
+	}
+	if c.Category == safety.CategoryVerification {
+		switch req.Type {
+		case ActionApproveVerification:
+		case ActionRejectVerification:
+			if req.Reason == "" {
+				return nil, errors.New("reason is required")
+			}
+		default:
+			return nil, errors.New("type must be approve_verification or reject_verification")
+		}
+		if req.PhotoID != "" || req.DurationHours != 0 {
+			return nil, errors.New("photoId and durationHours don't apply to verification")
+		}
+		return nil, nil
+	}
+
+	switch req.Type {
+	case ActionDismiss, ActionHidePhoto:
+	case ActionWarn, ActionSuspend, ActionBan:
+		if req.Reason == "" {
+			return nil, errors.New("reason is required")
+		}
+	default:
+		return nil, errors.New("type must be dismiss, warn, hide_photo, suspend or ban")
+	}
+
+	if req.Type == ActionHidePhoto {
+		if req.PhotoID == "" {
+			return nil, errors.New("photoId is required")
+		}
+		if reportedImage(c, req.PhotoID) == nil && !slices.Contains(c.PhotoIDs, req.PhotoID) {
+			return nil, errors.New("photoId is not part of this case")
+		}
+	} else if req.PhotoID != "" {
+		return nil, errors.New("photoId only applies to hide_photo")
+	}
+
+	if req.Type != ActionSuspend {
+		if req.DurationHours != 0 {
+			return nil, errors.New("durationHours only applies to suspend")
+		}
+		return nil, nil
+	}
+	if req.DurationHours < 1 || req.DurationHours > maxSuspensionHours {
+		return nil, errors.New("durationHours must be between 1 and " + strconv.Itoa(maxSuspensionHours))
+	}
+	until := now.Add(time.Duration(req.DurationHours) * time.Hour)
+	return &until, nil
+}
+
+// reportedImage returns the reported chat photo with the given message ID.
+func reportedImage(c *safety.Report, messageID string) *safety.ReportedMessage {
+	if c.Context != safety.SourceChat {
+		return nil
+	}
+	for i, m := range c.Messages {
+		if m.MessageID == messageID && m.Kind == chat.KindImage {
+			return &c.Messages[i]
+		}
+	}
+	return nil
+}
+
+// apply carries out an action on the case's reported user.
+func (h *Handler) apply(a *Action, c *safety.Report) error {
+	target := a.TargetUserID
+	switch a.Type {
+	case ActionApproveVerification, ActionRejectVerification:
+		if _, err := h.verify.Decide(c.ContextID, a.Type == ActionApproveVerification, a.Reason); err != nil {
+			return err
+		}
+
+	case ActionWarn:
+		h.conns.SendFrame(warningEvent{Type: EventWarning, Reason: a.Reason}, target)
+
+	case ActionHidePhoto:
+		if err := h.store.HidePhoto(target, a.PhotoID, a.ID, a.CreatedAt); err != nil {
+			return err
+		}
+		// Photos sent in chat are taken down for both participants.
+		if reportedImage(c, a.PhotoID) != nil {
+			msg, err := h.messages.Delete(c.ContextID, a.PhotoID, a.CreatedAt)
+			if err != nil && !errors.Is(err, chat.ErrMessageNotFound) {
+				return err
+			}
+			if msg != nil {
+				h.conns.SendFrame(chat.Event{
+					Type:           chat.EventMessageDeleted,
+					ConversationID: c.ContextID,
+					Message:        msg,
+				}, target, c.ReporterID)
+			}
+		}
+
+	case ActionSuspend, ActionBan:
+		status := auth.StatusSuspended
+		if a.Type == ActionBan {
+			status = auth.StatusBanned
+		}
+		// Restricting revokes their sessions; the middleware refuses
+		// their tokens from here on, and open sockets are closed now.
+		if _, err := h.accounts.Restrict(target, status, a.Until, a.CreatedAt); err != nil {
+			return err
+		}
+		h.conns.Disconnect(target)
+		if err := h.queue.Leave(target); err != nil {
+			h.logger.Printf("moderation: blind date leave error: %v", err)
+		}
+	}
+	return nil
+}
+
+// --- Handlers ---
+
+// Cases handles GET /v1/admin/cases: open reports, highest priority first
+// and oldest first within a priority.
+func (h *Handler) Cases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	limit, err := listLimit(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	cases, err := h.reports.Queue(limit)
+	if err != nil {
+		h.logger.Printf("Cases error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load cases"))
+		return
+	}
+	if cases == nil {
+		cases = []safety.Report{}
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"cases": cases})
+}
+
+// Case handles GET /v1/admin/cases/{id}: the report with its evidence, the
+// reported user's profile and account, and their history.
+func (h *Handler) Case(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	c, err := h.reports.GetReport(r.PathValue("id"))
+	if errors.Is(err, safety.ErrReportNotFound) {
+		httpjson.Error(w, http.StatusNotFound, errors.New("case not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Printf("Case error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load case"))
+		return
+	}
+
+	detail := CaseDetail{Case: c, History: []safety.Report{}}
+	if detail.Profile, err = h.profileView(c.ReportedUserID); err == nil {
+		detail.Account, err = h.accounts.Get(c.ReportedUserID)
+	}
+	var history []safety.Report
+	if err == nil {
+		history, err = h.reports.ReportsAgainst(c.ReportedUserID, historyLimit+1)
+	}
+	if err == nil {
+		detail.Actions, err = h.store.Actions(Filter{TargetUserID: c.ReportedUserID, Limit: historyLimit})
+	}
+	if err == nil && c.Category == safety.CategoryVerification {
+		var viewerID string
+		if viewerID, err = auth.UserID(r); err == nil {
+			detail.Verification, err = h.verify.Review(c.ContextID, viewerID)
+		}
+	}
+	if err != nil {
+		h.logger.Printf("Case context error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load case"))
+		return
+	}
+	for _, rep := range history {
+		if rep.ID != c.ID && len(detail.History) < historyLimit {
+			detail.History = append(detail.History, rep)
+		}
+	}
+	if detail.Actions == nil {
+		detail.Actions = []Action{}
+	}
+	httpjson.Write(w, http.StatusOK, detail)
+}
+
+// CaseActions handles POST /v1/admin/cases/{id}/actions. The action is
+// applied to the reported user, recorded in the audit trail and closes the
+// case: dismissing marks it dismissed, anything else actioned.
+func (h *Handler) CaseActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	actorID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	var req actionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c, err := h.reports.GetReport(r.PathValue("id"))
+	if errors.Is(err, safety.ErrReportNotFound) {
+		httpjson.Error(w, http.StatusNotFound, errors.New("case not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Printf("CaseActions load error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load case"))
+		return
+	}
+	if c.ReportedUserID == actorID {
+		httpjson.Error(w, http.StatusForbidden, errors.New("you can't act on a case about yourself"))
+		return
+	}
+	now := time.Now().UTC()
+	until, err := validateAction(&req, c, now)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Closing the case first means two moderators can't both act on it.
+	status := safety.ReportStatusActioned
+	if req.Type == ActionDismiss {
+		status = safety.ReportStatusDismissed
+	}
+	c, err = h.reports.Resolve(c.ID, status, actorID, now)
+	if errors.Is(err, safety.ErrReportResolved) {
+		httpjson.Error(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("CaseActions resolve error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to resolve case"))
+		return
+	}
+
+	a := &Action{
+		ID:           idgen.New(),
+		CaseID:       c.ID,
+		ActorID:      actorID,
+		TargetUserID: c.ReportedUserID,
+		Type:         req.Type,
+		Reason:       req.Reason,
+		PhotoID:      req.PhotoID,
+		Until:        until,
+		CreatedAt:    now,
+	}
+	if err := h.apply(a, c); err != nil {
+		h.logger.Printf("CaseActions apply %s error: %v", a.Type, err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to apply action"))
+		return
+	}
+	if a, err = h.store.Record(*a); err != nil {
+		h.logger.Printf("CaseActions audit error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to record action"))
+		return
+	}
+	httpjson.Write(w, http.StatusCreated, map[string]any{"action": a, "case": c})
+}
+
+// Audit handles GET /v1/admin/audit, optionally filtered by ?userId=,
+// ?caseId= and ?actorId=, newest first.
+func (h *Handler) Audit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	limit, err := listLimit(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query()
+	actions, err := h.store.Actions(Filter{
+		TargetUserID: q.Get("userId"),
+		CaseID:       q.Get("caseId"),
+		ActorID:      q.Get("actorId"),
+		Limit:        limit,
+	})
+	if err != nil {
+		h.logger.Printf("Audit error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load audit trail"))
+		return
+	}
+	if actions == nil {
+		actions = []Action{}
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"actions": actions})
+}
@@ -0,0 +1,61 @@
+// Package moderation is the admin side of safety: working through reported
+// cases, acting on the people behind them and keeping an audit trail of
+// every decision.
+package moderation
+
+import "time"
+
+// Action types a moderator can take on a case.
+const (
+	ActionDismiss   = "dismiss"
+	ActionWarn      = "warn"
+	ActionHidePhoto = "hide_photo"
+	ActionSuspend   = "suspend"
+	ActionBan       = "ban"
+	// Verification cases are settled with these instead.
+	ActionApproveVerification = "approve_verification"
+	ActionRejectVerification  = "reject_verification"
+)
+
+// Action is one entry in the audit trail: who did what to whom, and why.
+type Action struct {
+	ID string `json:"id"`
+	// CaseID is the report the action resolved.
+	CaseID       string `json:"caseId,omitempty"`
+	ActorID      string `json:"actorId"`
+	TargetUserID string `json:"targetUserId"`
+	Type         string `json:"type"`
+	Reason       string `json:"reason,omitempty"`
+	PhotoID      string `json:"photoId,omitempty"`
+	// Until is when a suspension ends.
+	Until     *time.Time `json:"until,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Filter narrows an audit trail listing; empty fields match everything.
+type Filter struct {
+	TargetUserID string
+	CaseID       string
+	ActorID      string
+	Limit        int
+}
+
+func (f Filter) matches(a *Action) bool {
+	return (f.TargetUserID == "" || a.TargetUserID == f.TargetUserID) &&
+		(f.CaseID == "" || a.CaseID == f.CaseID) &&
+		(f.ActorID == "" || a.ActorID == f.ActorID)
+}
+
+// Store persists the audit trail and moderation state that has no other
+// home, such as hidden photos.
+type Store interface {
+	// Record appends an action to the audit trail. Entries are never
+	// changed or removed afterwards.
+	Record(a Action) (*Action, error)
+	// Actions lists audit entries matching f, newest first.
+	Actions(f Filter) ([]Action, error)
+	// HidePhoto hides one of userID's photos; hiding twice is a no-op.
+	HidePhoto(userID, photoID, actionID string, at time.Time) error
+	// HiddenPhotos returns the IDs of userID's hidden photos.
+	HiddenPhotos(userID string) (map[string]struct{}, error)
+}
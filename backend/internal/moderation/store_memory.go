@@ -0,0 +1,65 @@
+package moderation
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu sync.Mutex
+	// actions is append-only, oldest first.
+	actions []Action
+	// hidden is keyed by user, then photo.
+	hidden map[string]map[string]struct{}
+}
+
+// NewInMemoryStore returns an in-memory moderation Store.
+func NewInMemoryStore() Store {
+	return &memoryStore{hidden: make(map[string]map[string]struct{})}
+}
+
+func (s *memoryStore) Record(a Action) (*Action, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions = append(s.actions, a)
+	return &a, nil
+}
+
+func (s *memoryStore) Actions(f Filter) ([]Action, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Action
+	for _, a := range slices.Backward(s.actions) {
+		if !f.matches(&a) {
+			continue
+		}
+		out = append(out, a)
+		if len(out) == f.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) HidePhoto(userID, photoID, actionID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	photos := s.hidden[userID]
+	if photos == nil {
+		photos = make(map[string]struct{})
+		s.hidden[userID] = photos
+	}
+	photos[photoID] = struct{}{}
+	return nil
+}
+
+func (s *memoryStore) HiddenPhotos(userID string) (map[string]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]struct{}, len(s.hidden[userID]))
+	for id := range s.hidden[userID] {
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pgStore keeps the audit trail in moderation_actions, which a trigger makes
+// append-only, and hidden photos in hidden_photos (sql/0019_moderation.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a moderation Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+func (s *pgStore) Record(a Action) (*Action, error) {
+	var until sql.NullTime
+	if a.Until != nil {
+		until = sql.NullTime{Time: *a.Until, Valid: true}
+	}
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO moderation_actions (id, case_id, actor_id, target_user_id, type, reason, photo_id, until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, a.ID, a.CaseID, a.ActorID, a.TargetUserID, a.Type, a.Reason, a.PhotoID, until, a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *pgStore) Actions(f Filter) ([]Action, error) {
+	var (
+		where []string
+		args  []any
+	)
+	for _, c := range []struct{ column, value string }{
+		{"target_user_id", f.TargetUserID},
+		{"case_id", f.CaseID},
+		{"actor_id", f.ActorID},
+	} {
+		if c.value != "" {
+			args = append(args, c.value)
+			where = append(where, c.column+" = $"+strconv.Itoa(len(args)))
+		}
+	}
+	query := `SELECT id, case_id, actor_id, target_user_id, type, reason, photo_id, until, created_at
+		FROM moderation_actions`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := s.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Action
+	for rows.Next() {
+		var (
+			a     Action
+			until sql.NullTime
+		)
+		if err := rows.Scan(&a.ID, &a.CaseID, &a.ActorID, &a.TargetUserID, &a.Type, &a.Reason,
+			&a.PhotoID, &until, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if until.Valid {
+			a.Until = &until.Time
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) HidePhoto(userID, photoID, actionID string, at time.Time) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		INSERT INTO hidden_photos (user_id, photo_id, action_id, hidden_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, photo_id) DO NOTHING
+	`, userID, photoID, actionID, at)
+	return err
+}
+
+func (s *pgStore) HiddenPhotos(userID string) (map[string]struct{}, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT photo_id FROM hidden_photos WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = struct{}{}
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,170 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+// Handler exposes device registration and quiet hours.
+type Handler struct {
+	logger *log.Logger
+	store  Store
+}
+
+func NewHandler(logger *log.Logger, store Store) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{logger: logger, store: store}
+}
+
+// --- Request payloads ---
+
+type registerDeviceRequest struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+// --- Handlers ---
+
+// Devices handles /v1/devices
+//
+//	GET   the caller's devices
+//	POST  {"platform":"expo|apns|fcm","token":"..."} registers a device.
+//	      Apps should register on every launch; a known token is
+//	      refreshed, and moves to the caller if someone else had it.
+func (h *Handler) Devices(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		devices, err := h.store.Devices(userID)
+		if err != nil {
+			h.logger.Printf("List devices error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to list devices"))
+			return
+		}
+		if devices == nil {
+			devices = []Device{}
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"devices": devices})
+
+	case http.MethodPost:
+		var req registerDeviceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := ValidateToken(req.Platform, req.Token); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		now := time.Now().UTC()
+		d, err := h.store.RegisterDevice(Device{
+			ID:         idgen.New(),
+			UserID:     userID,
+			Platform:   req.Platform,
+			Token:      req.Token,
+			CreatedAt:  now,
+			LastSeenAt: now,
+		})
+		if err != nil {
+			h.logger.Printf("Register device error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to register device"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"device": d})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// Device handles DELETE /v1/devices/{id}, for signing out of a device.
+func (h *Handler) Device(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = h.store.DeleteDevice(userID, r.PathValue("id"))
+	if errors.Is(err, ErrDeviceNotFound) {
+		httpjson.Error(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.logger.Printf("Delete device error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to delete device"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// QuietHours handles /v1/notifications/quiet-hours
+//
+//	GET     the caller's quiet hours, null if none
+//	PUT     {"start":"22:00","end":"07:30","timeZone":"Europe/Berlin"};
+//	        pushes due in the window wait until it ends
+//	DELETE  clears them
+func (h *Handler) QuietHours(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q, err := h.store.QuietHours(userID)
+		if err != nil {
+			h.logger.Printf("Get quiet hours error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load quiet hours"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"quietHours": q})
+
+	case http.MethodPut:
+		var q QuietHours
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := q.Validate(); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := h.store.SetQuietHours(userID, &q); err != nil {
+			h.logger.Printf("Set quiet hours error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save quiet hours"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"quietHours": q})
+
+	case http.MethodDelete:
+		if err := h.store.SetQuietHours(userID, nil); err != nil {
+			h.logger.Printf("Clear quiet hours error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to clear quiet hours"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
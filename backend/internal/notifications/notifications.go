@@ -0,0 +1,215 @@
+// Package notifications sends push notifications about new matches, sparks
+// and messages to the devices people register. Notifications go into an
+// outbox that a worker drains through a PushSender, retrying failures,
+// holding pushes back during each user's quiet hours, and collapsing
+// pending pushes that share a collapse key into the latest one.
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Platforms a device token can belong to.
+const (
+	PlatformExpo = "expo"
+	PlatformAPNs = "apns"
+	PlatformFCM  = "fcm"
+)
+
+// Kinds of notification.
+const (
+	KindMatch   = "match"
+	KindSpark   = "spark"
+	KindMessage = "message"
+)
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrInvalidPlatform is returned when registering an unknown platform.
+	ErrInvalidPlatform = errors.New("platform must be expo, apns or fcm")
+	// ErrInvalidToken is returned when registering a token that doesn't
+	// look like one from its platform, and by a PushSender when the
+	// platform no longer accepts a token.
+	ErrInvalidToken = errors.New("invalid push token")
+	// ErrInvalidQuietHours is returned for malformed quiet hours.
+	ErrInvalidQuietHours = errors.New(`quiet hours need start and end as "HH:MM" and a valid timeZone`)
+)
+
+var (
+	expoToken = regexp.MustCompile(`^Expo(nent)?PushToken\[[^\]]+\]$`)
+	apnsToken = regexp.MustCompile(`^[0-9a-fA-F]{64,200}$`)
+)
+
+// maxTokenLength bounds FCM registration tokens, which have no fixed
+// format.
+const maxTokenLength = 4096
+
+// ValidateToken checks that token looks like one issued on platform.
+func ValidateToken(platform, token string) error {
+	switch platform {
+	case PlatformExpo:
+		if !expoToken.MatchString(token) {
+			return ErrInvalidToken
+		}
+	case PlatformAPNs:
+		if !apnsToken.MatchString(token) {
+			return ErrInvalidToken
+		}
+	case PlatformFCM:
+		if token == "" || len(token) > maxTokenLength || strings.ContainsAny(token, " \t\r\n") {
+			return ErrInvalidToken
+		}
+	default:
+		return ErrInvalidPlatform
+	}
+	return nil
+}
+
+// Device is somewhere a user receives pushes. A token belongs to one user
+// at a time: registering it again moves it to whoever is signed in.
+type Device struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Platform   string    `json:"platform"`
+	Token      string    `json:"token"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// QuietHours is a daily window, in the user's time zone, during which
+// pushes wait. A window whose end is before its start runs past midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone"`
+}
+
+// parseClock reads "HH:MM" as minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks q and normalises its times.
+func (q *QuietHours) Validate() error {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return ErrInvalidQuietHours
+	}
+	end, err := parseClock(q.End)
+	if err != nil || start == end {
+		return ErrInvalidQuietHours
+	}
+	if q.TimeZone == "" {
+		q.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return ErrInvalidQuietHours
+	}
+	q.Start = fmt.Sprintf("%02d:%02d", start/60, start%60)
+	q.End = fmt.Sprintf("%02d:%02d", end/60, end%60)
+	return nil
+}
+
+// Until returns when the quiet hours around t end, or the zero time if t
+// isn't within them. q must be valid.
+func (q *QuietHours) Until(t time.Time) time.Time {
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.Time{}
+	}
+	start, _ := parseClock(q.Start)
+	end, _ := parseClock(q.End)
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	var quiet bool
+	if start < end {
+		quiet = now >= start && now < end
+	} else {
+		quiet = now >= start || now < end
+	}
+	if !quiet {
+		return time.Time{}
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// Notification is something to tell a user about.
+type Notification struct {
+	Kind string
+	// UserID receives the notification; ActorID caused it.
+	UserID  string
+	ActorID string
+	// SubjectID is the match, spark or conversation it is about.
+	SubjectID string
+	// Text is a spark's note or a message's text, shown as the body.
+	Text string
+}
+
+// Push is a rendered notification waiting in the outbox.
+type Push struct {
+	ID     string
+	UserID string
+	Kind   string
+	// CollapseKey groups pushes that supersede each other, such as those
+	// for one conversation: a newer push replaces a pending one, and the
+	// platform replaces a delivered one on the device.
+	CollapseKey string
+	Title       string
+	Body        string
+	Data        map[string]string
+	// Attempts counts failed sends.
+	Attempts  int
+	LastError string
+	// NotBefore is when the push may next be sent.
+	NotBefore time.Time
+	CreatedAt time.Time
+}
+
+// Store holds devices, quiet hours and the outbox.
+type Store interface {
+	// RegisterDevice saves d, or refreshes the device with d's token,
+	// moving it to d.UserID.
+	RegisterDevice(d Device) (*Device, error)
+	// Devices lists userID's devices, newest first.
+	Devices(userID string) ([]Device, error)
+	// DeleteDevice removes one of userID's devices. ErrDeviceNotFound if
+	// they have no device with that ID.
+	DeleteDevice(userID, deviceID string) error
+	// RemoveToken forgets a token the platform has rejected.
+	RemoveToken(token string) error
+
+	// QuietHours returns userID's quiet hours, or nil if they have none.
+	QuietHours(userID string) (*QuietHours, error)
+	// SetQuietHours replaces userID's quiet hours; nil clears them.
+	SetQuietHours(userID string, q *QuietHours) error
+
+	// Enqueue adds p to the outbox. A pending push with the same user and
+	// non-empty collapse key is replaced by p.
+	Enqueue(p Push) error
+	// Claim returns up to limit pushes due at now and hides them from
+	// other claims until now+lease, so an instance that dies mid-send
+	// doesn't lose them.
+	Claim(now time.Time, limit int, lease time.Duration) ([]Push, error)
+	// Retry records a failed send and schedules the next attempt.
+	Retry(pushID string, at time.Time, lastError string) error
+	// Hold postpones a push without counting an attempt.
+	Hold(pushID string, at time.Time) error
+	// Done removes a push that was sent or given up on.
+	Done(pushID string) error
+
+	// DeleteUser removes the user's devices, quiet hours and pushes.
+	DeleteUser(userID string) error
+}
@@ -0,0 +1,131 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout bounds a call to the push gateway.
+const defaultHTTPTimeout = 10 * time.Second
+
+// ErrRejected is returned by a PushSender when the platform refused a
+// message for good; sending it again won't help.
+var ErrRejected = errors.New("push rejected")
+
+// Message is one push to one device.
+type Message struct {
+	Platform    string            `json:"platform"`
+	Token       string            `json:"token"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Data        map[string]string `json:"data,omitempty"`
+	CollapseKey string            `json:"collapseKey,omitempty"`
+}
+
+// PushSender delivers messages to devices. It returns ErrInvalidToken when
+// the token is dead and ErrRejected when the message will never be
+// accepted; any other error is worth retrying.
+type PushSender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+type httpSender struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSender returns a PushSender that POSTs each Message to url as
+// JSON, for a gateway that relays to Expo, APNs and FCM (or a local stub).
+// The gateway answers 2xx when the message was accepted, 404 or 410 for a
+// dead token and any other 4xx except 429 for a message it won't take. A
+// nil client uses one with a short timeout.
+func NewHTTPSender(url string, client *http.Client) PushSender {
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &httpSender{url: url, client: client}
+}
+
+func (s *httpSender) Send(ctx context.Context, m Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push gateway request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return ErrInvalidToken
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: gateway returned %s", ErrRejected, resp.Status)
+	}
+	return fmt.Errorf("push gateway returned %s", resp.Status)
+}
+
+// Fake is a PushSender for tests and local development. It remembers
+// every message, logs it when given a logger, and fails for tokens told
+// to.
+type Fake struct {
+	logger *log.Logger
+
+	mu       sync.Mutex
+	sent     []Message
+	failures map[string]error
+}
+
+// NewFake returns a Fake. A nil logger keeps it quiet.
+func NewFake(logger *log.Logger) *Fake {
+	return &Fake{logger: logger, failures: make(map[string]error)}
+}
+
+// Fail makes sends to token return err; a nil err lets them through again.
+func (f *Fake) Fail(token string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, token)
+		return
+	}
+	f.failures[token] = err
+}
+
+// Sent returns the messages delivered so far, oldest first.
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
+
+func (f *Fake) Send(_ context.Context, m Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failures[m.Token]; err != nil {
+		return err
+	}
+	f.sent = append(f.sent, m)
+	if f.logger != nil {
+		f.logger.Printf("push (fake) to %s %s: %s: %s", m.Platform, m.Token, m.Title, m.Body)
+	}
+	return nil
+}
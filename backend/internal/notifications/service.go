@@ -0,0 +1,195 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+const (
+	// dispatchBatch bounds how many pushes one pass of Run sends.
+	dispatchBatch = 100
+	// claimLease is how long a claimed push stays hidden from other
+	// instances before it is tried again.
+	claimLease = time.Minute
+	// maxAttempts is how many failed sends a push gets before it is
+	// dropped.
+	maxAttempts = 6
+	// retryBase doubles with every failed attempt, up to retryMax.
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+	// maxBodyRunes trims message and note previews.
+	maxBodyRunes = 140
+)
+
+// Service turns notifications into pushes and delivers them.
+type Service struct {
+	logger   *log.Logger
+	store    Store
+	profiles onboarding.Store
+	sender   PushSender
+}
+
+func NewService(logger *log.Logger, store Store, profiles onboarding.Store, sender PushSender) *Service {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Service{
+		logger:   logger,
+		store:    store,
+		profiles: profiles,
+		sender:   sender,
+	}
+}
+
+// Notify queues a push for n. Failures are logged: a lost notification
+// must never fail the request that caused it.
+func (s *Service) Notify(n Notification) {
+	p, err := s.render(n)
+	if err != nil {
+		s.logger.Printf("notifications: render %s: %v", n.Kind, err)
+		return
+	}
+	if err := s.store.Enqueue(*p); err != nil {
+		s.logger.Printf("notifications: enqueue %s: %v", n.Kind, err)
+	}
+}
+
+// render writes the push for n.
+func (s *Service) render(n Notification) (*Push, error) {
+	name := "Someone"
+	if p, err := s.profiles.GetProfile(n.ActorID); err == nil && p.DisplayName != "" {
+		name = p.DisplayName
+	} else if err != nil && !errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	p := &Push{
+		ID:        idgen.New(),
+		UserID:    n.UserID,
+		Kind:      n.Kind,
+		Data:      map[string]string{"type": n.Kind},
+		NotBefore: now,
+		CreatedAt: now,
+	}
+	switch n.Kind {
+	case KindMatch:
+		p.CollapseKey = "match:" + n.SubjectID
+		p.Title = "It's a match!"
+		p.Body = "You and " + name + " liked each other."
+		p.Data["matchId"] = n.SubjectID
+	case KindSpark:
+		p.CollapseKey = "spark:" + n.SubjectID
+		p.Title = name + " sent you a spark"
+		p.Body = "Take a look before it fades."
+		if n.Text != "" {
+			p.Body = trim(n.Text)
+		}
+		p.Data["sparkId"] = n.SubjectID
+	case KindMessage:
+		p.CollapseKey = "conversation:" + n.SubjectID
+		p.Title = name
+		p.Body = trim(n.Text)
+		p.Data["conversationId"] = n.SubjectID
+	default:
+		return nil, fmt.Errorf("unknown notification kind %q", n.Kind)
+	}
+	return p, nil
+}
+
+func trim(s string) string {
+	r := []rune(s)
+	if len(r) <= maxBodyRunes {
+		return s
+	}
+	return string(r[:maxBodyRunes-1]) + "…"
+}
+
+// backoff is the wait after a push's attempts-th failure.
+func backoff(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts && d < retryMax; i++ {
+		d *= 2
+	}
+	return min(d, retryMax)
+}
+
+// Run sends due pushes every interval. It blocks until ctx is cancelled.
+func (s *Service) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		pushes, err := s.store.Claim(time.Now().UTC(), dispatchBatch, claimLease)
+		if err != nil {
+			s.logger.Printf("notifications claim error: %v", err)
+		}
+		for _, p := range pushes {
+			if err := s.dispatch(ctx, p); err != nil {
+				s.logger.Printf("notifications: push %s error: %v", p.ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch sends p to every device of its user, holding it through quiet
+// hours and scheduling a retry when a device could not be reached. A retry
+// goes to every device again; the collapse key keeps the duplicates from
+// piling up.
+func (s *Service) dispatch(ctx context.Context, p Push) error {
+	now := time.Now().UTC()
+	q, err := s.store.QuietHours(p.UserID)
+	if err != nil {
+		return s.store.Retry(p.ID, now.Add(backoff(p.Attempts+1)), err.Error())
+	}
+	if q != nil {
+		if until := q.Until(now); !until.IsZero() {
+			return s.store.Hold(p.ID, until)
+		}
+	}
+
+	devices, err := s.store.Devices(p.UserID)
+	if err != nil {
+		return s.store.Retry(p.ID, now.Add(backoff(p.Attempts+1)), err.Error())
+	}
+	var failed error
+	for _, d := range devices {
+		err := s.sender.Send(ctx, Message{
+			Platform:    d.Platform,
+			Token:       d.Token,
+			Title:       p.Title,
+			Body:        p.Body,
+			Data:        p.Data,
+			CollapseKey: p.CollapseKey,
+		})
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			if err := s.store.RemoveToken(d.Token); err != nil {
+				s.logger.Printf("notifications: remove token: %v", err)
+			}
+		case errors.Is(err, ErrRejected):
+			s.logger.Printf("notifications: push %s to device %s: %v", p.ID, d.ID, err)
+		case err != nil:
+			failed = err
+		}
+	}
+	if failed == nil {
+		return s.store.Done(p.ID)
+	}
+	if p.Attempts+1 >= maxAttempts {
+		s.logger.Printf("notifications: giving up on push %s after %d attempts: %v", p.ID, maxAttempts, failed)
+		return s.store.Done(p.ID)
+	}
+	return s.store.Retry(p.ID, now.Add(backoff(p.Attempts+1)), failed.Error())
+}
@@ -0,0 +1,329 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+var (
+	aliceExpo = Device{ID: "d1", UserID: "alice", Platform: PlatformExpo, Token: "ExponentPushToken[alice]"}
+	aliceFCM  = Device{ID: "d2", UserID: "alice", Platform: PlatformFCM, Token: "alice-fcm"}
+)
+
+func newTestService(t *testing.T, devices ...Device) (*Service, Store, *Fake) {
+	t.Helper()
+	store := NewInMemoryStore()
+	for _, d := range devices {
+		if _, err := store.RegisterDevice(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake := NewFake(nil)
+	return NewService(log.New(io.Discard, "", 0), store, onboarding.NewInMemoryStore(), fake), store, fake
+}
+
+// outbox returns every pending push in an in-memory store, oldest first.
+func outbox(t *testing.T, store Store) []Push {
+	t.Helper()
+	m := store.(*memoryStore)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var pushes []Push
+	for _, p := range m.pushes {
+		pushes = append(pushes, clonePush(p))
+	}
+	sort.Slice(pushes, func(i, j int) bool { return pushes[i].CreatedAt.Before(pushes[j].CreatedAt) })
+	return pushes
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    QuietHours
+		want  QuietHours
+		valid bool
+	}{
+		{"normalised", QuietHours{Start: "7:05", End: "22:00"}, QuietHours{Start: "07:05", End: "22:00", TimeZone: "UTC"}, true},
+		{"time zone kept", QuietHours{Start: "22:00", End: "07:30", TimeZone: "Europe/Berlin"}, QuietHours{Start: "22:00", End: "07:30", TimeZone: "Europe/Berlin"}, true},
+		{"empty window", QuietHours{Start: "22:00", End: "22:00"}, QuietHours{}, false},
+		{"bad clock", QuietHours{Start: "25:00", End: "07:00"}, QuietHours{}, false},
+		{"missing end", QuietHours{Start: "22:00"}, QuietHours{}, false},
+		{"unknown zone", QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}, QuietHours{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.in
+			err := q.Validate()
+			if (err == nil) != tt.valid {
+				t.Fatalf("Validate error = %v, want valid %v", err, tt.valid)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidQuietHours) {
+				t.Errorf("error = %v, want ErrInvalidQuietHours", err)
+			}
+			if tt.valid && q != tt.want {
+				t.Errorf("normalised to %+v, want %+v", q, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}
+	afternoon := QuietHours{Start: "13:00", End: "15:00", TimeZone: "UTC"}
+	berlin := QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}
+	tests := []struct {
+		name string
+		q    QuietHours
+		t    time.Time
+		want time.Time
+	}{
+		{"before overnight window", overnight, at(15, 21, 59), time.Time{}},
+		{"overnight window starts", overnight, at(15, 22, 0), at(16, 7, 0)},
+		{"overnight before midnight", overnight, at(15, 23, 30), at(16, 7, 0)},
+		{"overnight after midnight", overnight, at(16, 3, 0), at(16, 7, 0)},
+		{"overnight window ends", overnight, at(16, 7, 0), time.Time{}},
+		{"inside daytime window", afternoon, at(15, 14, 59), at(15, 15, 0)},
+		{"daytime window ends", afternoon, at(15, 15, 0), time.Time{}},
+		{"outside daytime window", afternoon, at(15, 9, 0), time.Time{}},
+		{"in the user's time zone", berlin, at(15, 21, 30), at(16, 6, 0)},
+		{"outside in the user's time zone", berlin, at(15, 20, 30), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Until(tt.t); !got.Equal(tt.want) {
+				t.Errorf("Until(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyCollapses(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications []Notification
+		wantBodies    []string
+	}{
+		{
+			name: "messages in one conversation",
+			notifications: []Notification{
+				{Kind: KindMessage, UserID: "alice", ActorID: "bob", SubjectID: "m1", Text: "hi"},
+				{Kind: KindMessage, UserID: "alice", ActorID: "bob", SubjectID: "m1", Text: "are you there?"},
+			},
+			wantBodies: []string{"are you there?"},
+		},
+		{
+			name: "different conversations",
+			notifications: []Notification{
+				{Kind: KindMessage, UserID: "alice", ActorID: "bob", SubjectID: "m1", Text: "hi"},
+				{Kind: KindMessage, UserID: "alice", ActorID: "carol", SubjectID: "m2", Text: "hello"},
+			},
+			wantBodies: []string{"hi", "hello"},
+		},
+		{
+			name: "same conversation, different recipients",
+			notifications: []Notification{
+				{Kind: KindMessage, UserID: "alice", ActorID: "bob", SubjectID: "m1", Text: "hi"},
+				{Kind: KindMessage, UserID: "bob", ActorID: "alice", SubjectID: "m1", Text: "hey"},
+			},
+			wantBodies: []string{"hi", "hey"},
+		},
+		{
+			name: "match and message",
+			notifications: []Notification{
+				{Kind: KindMatch, UserID: "alice", ActorID: "bob", SubjectID: "m1"},
+				{Kind: KindMessage, UserID: "alice", ActorID: "bob", SubjectID: "m1", Text: "hi"},
+			},
+			wantBodies: []string{"You and Someone liked each other.", "hi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestService(t)
+			for _, n := range tt.notifications {
+				s.Notify(n)
+				time.Sleep(time.Millisecond) // keep CreatedAt ordered
+			}
+			pushes := outbox(t, store)
+			if len(pushes) != len(tt.wantBodies) {
+				t.Fatalf("outbox has %d pushes, want %d", len(pushes), len(tt.wantBodies))
+			}
+			for i, p := range pushes {
+				if p.Body != tt.wantBodies[i] {
+					t.Errorf("push %d body = %q, want %q", i, p.Body, tt.wantBodies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnqueueWithoutCollapseKey(t *testing.T) {
+	store := NewInMemoryStore()
+	now := time.Now()
+	for _, id := range []string{"p1", "p2"} {
+		if err := store.Enqueue(Push{ID: id, UserID: "alice", NotBefore: now, CreatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(outbox(t, store)); n != 2 {
+		t.Fatalf("outbox has %d pushes, want 2", n)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	errDown := errors.New("gateway down")
+	tests := []struct {
+		name     string
+		attempts int
+		failures map[string]error
+		// quiet puts the recipient inside their quiet hours.
+		quiet bool
+
+		wantSent     int
+		wantPending  bool
+		wantAttempts int
+		wantDelay    time.Duration // from dispatch to the next try, if pending
+		wantDevices  int
+	}{
+		{name: "delivered to every device", wantSent: 2, wantDevices: 2},
+		{
+			name:     "transient failure retries",
+			failures: map[string]error{aliceFCM.Token: errDown},
+			wantSent: 1, wantPending: true, wantAttempts: 1, wantDelay: retryBase, wantDevices: 2,
+		},
+		{
+			name:     "later failures back off further",
+			attempts: 3,
+			failures: map[string]error{aliceFCM.Token: errDown},
+			wantSent: 1, wantPending: true, wantAttempts: 4, wantDelay: 8 * retryBase, wantDevices: 2,
+		},
+		{
+			name:        "last attempt gives up",
+			attempts:    maxAttempts - 1,
+			failures:    map[string]error{aliceExpo.Token: errDown, aliceFCM.Token: errDown},
+			wantDevices: 2,
+		},
+		{
+			name:     "dead token is removed",
+			failures: map[string]error{aliceFCM.Token: ErrInvalidToken},
+			wantSent: 1, wantDevices: 1,
+		},
+		{
+			name:     "rejected message is not retried",
+			failures: map[string]error{aliceFCM.Token: ErrRejected},
+			wantSent: 1, wantDevices: 2,
+		},
+		{
+			name:        "held through quiet hours",
+			quiet:       true,
+			wantPending: true, wantDevices: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, fake := newTestService(t, aliceExpo, aliceFCM)
+			for token, err := range tt.failures {
+				fake.Fail(token, err)
+			}
+			var quietUntil time.Time
+			if tt.quiet {
+				now := time.Now().UTC()
+				q := QuietHours{
+					Start: now.Add(-time.Hour).Format("15:04"),
+					End:   now.Add(time.Hour).Format("15:04"),
+				}
+				if err := q.Validate(); err != nil {
+					t.Fatal(err)
+				}
+				if err := store.SetQuietHours("alice", &q); err != nil {
+					t.Fatal(err)
+				}
+				quietUntil = q.Until(now)
+			}
+
+			now := time.Now().UTC()
+			p := Push{
+				ID: "p1", UserID: "alice", Kind: KindMessage, CollapseKey: "conversation:m1",
+				Title: "Bob", Body: "hi", Attempts: tt.attempts, NotBefore: now, CreatedAt: now,
+			}
+			if err := store.Enqueue(p); err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now().UTC()
+			if err := s.dispatch(context.Background(), p); err != nil {
+				t.Fatalf("dispatch: %v", err)
+			}
+
+			sent := fake.Sent()
+			if len(sent) != tt.wantSent {
+				t.Errorf("sent %d messages, want %d", len(sent), tt.wantSent)
+			}
+			for _, m := range sent {
+				if m.CollapseKey != p.CollapseKey || m.Body != p.Body {
+					t.Errorf("sent %+v, want the push's body and collapse key", m)
+				}
+			}
+
+			pending := outbox(t, store)
+			if (len(pending) == 1) != tt.wantPending {
+				t.Fatalf("pending pushes = %d, want pending %v", len(pending), tt.wantPending)
+			}
+			if tt.wantPending {
+				got := pending[0]
+				if got.Attempts != tt.wantAttempts {
+					t.Errorf("attempts = %d, want %d", got.Attempts, tt.wantAttempts)
+				}
+				if tt.quiet {
+					if !got.NotBefore.Equal(quietUntil) {
+						t.Errorf("held until %v, want %v", got.NotBefore, quietUntil)
+					}
+				} else {
+					if delay := got.NotBefore.Sub(start); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+						t.Errorf("next try in %v, want %v", delay, tt.wantDelay)
+					}
+					if got.LastError != errDown.Error() {
+						t.Errorf("last error = %q, want %q", got.LastError, errDown.Error())
+					}
+				}
+			}
+
+			devices, err := store.Devices("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(devices) != tt.wantDevices {
+				t.Errorf("alice has %d devices, want %d", len(devices), tt.wantDevices)
+			}
+		})
+	}
+}
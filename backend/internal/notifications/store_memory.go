@@ -0,0 +1,185 @@
+package notifications
+
+import (
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	devices map[string]*Device // by token
+	quiet   map[string]QuietHours
+	pushes  map[string]*Push
+}
+
+// NewInMemoryStore returns a notifications Store kept in memory.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		devices: make(map[string]*Device),
+		quiet:   make(map[string]QuietHours),
+		pushes:  make(map[string]*Push),
+	}
+}
+
+func (s *memoryStore) RegisterDevice(d Device) (*Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.devices[d.Token]; ok {
+		if existing.UserID != d.UserID {
+			// A new owner gets a new device.
+			existing.ID = idgen.New()
+			existing.CreatedAt = d.LastSeenAt
+		}
+		existing.UserID = d.UserID
+		existing.Platform = d.Platform
+		existing.LastSeenAt = d.LastSeenAt
+		out := *existing
+		return &out, nil
+	}
+	stored := d
+	s.devices[d.Token] = &stored
+	return &d, nil
+}
+
+func (s *memoryStore) Devices(userID string) ([]Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Device
+	for _, d := range s.devices {
+		if d.UserID == userID {
+			out = append(out, *d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) DeleteDevice(userID, deviceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, d := range s.devices {
+		if d.ID == deviceID && d.UserID == userID {
+			delete(s.devices, token)
+			return nil
+		}
+	}
+	return ErrDeviceNotFound
+}
+
+func (s *memoryStore) RemoveToken(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, token)
+	return nil
+}
+
+func (s *memoryStore) QuietHours(userID string) (*QuietHours, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.quiet[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &q, nil
+}
+
+func (s *memoryStore) SetQuietHours(userID string, q *QuietHours) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q == nil {
+		delete(s.quiet, userID)
+		return nil
+	}
+	s.quiet[userID] = *q
+	return nil
+}
+
+func clonePush(p *Push) Push {
+	out := *p
+	out.Data = maps.Clone(p.Data)
+	return out
+}
+
+func (s *memoryStore) Enqueue(p Push) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.CollapseKey != "" {
+		for id, pending := range s.pushes {
+			if pending.UserID == p.UserID && pending.CollapseKey == p.CollapseKey {
+				delete(s.pushes, id)
+			}
+		}
+	}
+	stored := clonePush(&p)
+	s.pushes[p.ID] = &stored
+	return nil
+}
+
+func (s *memoryStore) Claim(now time.Time, limit int, lease time.Duration) ([]Push, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Push
+	for _, p := range s.pushes {
+		if !p.NotBefore.After(now) {
+			due = append(due, p)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NotBefore.Before(due[j].NotBefore) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	out := make([]Push, 0, len(due))
+	for _, p := range due {
+		p.NotBefore = now.Add(lease)
+		out = append(out, clonePush(p))
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Retry(pushID string, at time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pushes[pushID]; ok {
+		p.Attempts++
+		p.LastError = lastError
+		p.NotBefore = at
+	}
+	return nil
+}
+
+func (s *memoryStore) Hold(pushID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pushes[pushID]; ok {
+		p.NotBefore = at
+	}
+	return nil
+}
+
+func (s *memoryStore) Done(pushID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pushes, pushID)
+	return nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, d := range s.devices {
+		if d.UserID == userID {
+			delete(s.devices, token)
+		}
+	}
+	delete(s.quiet, userID)
+	for id, p := range s.pushes {
+		if p.UserID == userID {
+			delete(s.pushes, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// pgStore keeps devices in push_devices, quiet hours in quiet_hours and
+// the outbox in push_outbox (sql/0024_notifications.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a notifications Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const deviceColumns = `id, user_id, platform, token, created_at, last_seen_at`
+
+func scanDevice(row interface{ Scan(...any) error }) (*Device, error) {
+	var d Device
+	if err := row.Scan(&d.ID, &d.UserID, &d.Platform, &d.Token, &d.CreatedAt, &d.LastSeenAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *pgStore) RegisterDevice(d Device) (*Device, error) {
+	// Every SET expression sees the old row, so the CASEs compare against
+	// the previous owner.
+	return scanDevice(s.db.QueryRowContext(context.Background(), `
+		INSERT INTO push_devices (id, user_id, platform, token, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (token) DO UPDATE SET
+			id           = CASE WHEN push_devices.user_id = EXCLUDED.user_id
+			                    THEN push_devices.id ELSE EXCLUDED.id END,
+			created_at   = CASE WHEN push_devices.user_id = EXCLUDED.user_id
+			                    THEN push_devices.created_at ELSE EXCLUDED.created_at END,
+			user_id      = EXCLUDED.user_id,
+			platform     = EXCLUDED.platform,
+			last_seen_at = EXCLUDED.last_seen_at
+		RETURNING `+deviceColumns,
+		d.ID, d.UserID, d.Platform, d.Token, d.CreatedAt, d.LastSeenAt))
+}
+
+func (s *pgStore) Devices(userID string) ([]Device, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+deviceColumns+` FROM push_devices
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Device
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) DeleteDevice(userID, deviceID string) error {
+	res, err := s.db.ExecContext(context.Background(),
+		`DELETE FROM push_devices WHERE id = $1 AND user_id = $2`, deviceID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (s *pgStore) RemoveToken(token string) error {
+	_, err := s.db.ExecContext(context.Background(), `DELETE FROM push_devices WHERE token = $1`, token)
+	return err
+}
+
+func (s *pgStore) QuietHours(userID string) (*QuietHours, error) {
+	var q QuietHours
+	err := s.db.QueryRowContext(context.Background(), `
+		SELECT start_time, end_time, time_zone FROM quiet_hours WHERE user_id = $1
+	`, userID).Scan(&q.Start, &q.End, &q.TimeZone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (s *pgStore) SetQuietHours(userID string, q *QuietHours) error {
+	ctx := context.Background()
+	if q == nil {
+		_, err := s.db.ExecContext(ctx, `DELETE FROM quiet_hours WHERE user_id = $1`, userID)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO quiet_hours (user_id, start_time, end_time, time_zone)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			start_time = EXCLUDED.start_time,
+			end_time   = EXCLUDED.end_time,
+			time_zone  = EXCLUDED.time_zone
+	`, userID, q.Start, q.End, q.TimeZone)
+	return err
+}
+
+const pushColumns = `id, user_id, kind, collapse_key, title, body, data, attempts, last_error, not_before, created_at`
+
+func scanPush(row interface{ Scan(...any) error }) (*Push, error) {
+	var (
+		p    Push
+		data []byte
+	)
+	if err := row.Scan(&p.ID, &p.UserID, &p.Kind, &p.CollapseKey, &p.Title, &p.Body, &data,
+		&p.Attempts, &p.LastError, &p.NotBefore, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.Data); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *pgStore) Enqueue(p Push) error {
+	data, err := json.Marshal(p.Data)
+	if err != nil {
+		return err
+	}
+	if p.Data == nil {
+		data = []byte("{}")
+	}
+	_, err = s.db.ExecContext(context.Background(), `
+		INSERT INTO push_outbox (id, user_id, kind, collapse_key, title, body, data, not_before, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, collapse_key) WHERE collapse_key <> '' DO UPDATE SET
+			id         = EXCLUDED.id,
+			kind       = EXCLUDED.kind,
+			title      = EXCLUDED.title,
+			body       = EXCLUDED.body,
+			data       = EXCLUDED.data,
+			attempts   = 0,
+			last_error = '',
+			not_before = EXCLUDED.not_before,
+			created_at = EXCLUDED.created_at
+	`, p.ID, p.UserID, p.Kind, p.CollapseKey, p.Title, p.Body, data, p.NotBefore, p.CreatedAt)
+	return err
+}
+
+func (s *pgStore) Claim(now time.Time, limit int, lease time.Duration) ([]Push, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		UPDATE push_outbox SET not_before = $2
+		WHERE id IN (
+			SELECT id FROM push_outbox
+			WHERE not_before <= $1
+			ORDER BY not_before
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+pushColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Push
+	for rows.Next() {
+		p, err := scanPush(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Retry(pushID string, at time.Time, lastError string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		UPDATE push_outbox SET attempts = attempts + 1, last_error = $2, not_before = $3
+		WHERE id = $1
+	`, pushID, lastError, at)
+	return err
+}
+
+func (s *pgStore) Hold(pushID string, at time.Time) error {
+	_, err := s.db.ExecContext(context.Background(),
+		`UPDATE push_outbox SET not_before = $2 WHERE id = $1`, pushID, at)
+	return err
+}
+
+func (s *pgStore) Done(pushID string) error {
+	_, err := s.db.ExecContext(context.Background(), `DELETE FROM push_outbox WHERE id = $1`, pushID)
+	return err
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		`DELETE FROM push_devices WHERE user_id = $1`,
+		`DELETE FROM quiet_hours WHERE user_id = $1`,
+		`DELETE FROM push_outbox WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+package onboarding
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+)
+
+// ActivityResolution is how precisely last-active times are kept. Recording
+// activity at most this often keeps it to one write per user every few
+// minutes however busy they are.
+const ActivityResolution = 5 * time.Minute
+
+// TrackActivity records that the caller was active on every request the auth
+// middleware identified, so it must run inside that middleware. Failures are
+// logged and never fail the request.
+func TrackActivity(logger *log.Logger, store Store, next http.Handler) http.Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	t := &activityTracker{store: store, touched: make(map[string]time.Time)}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+			if err := t.touch(userID, time.Now()); err != nil {
+				logger.Printf("activity error: %v", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// activityTracker remembers when this instance last recorded each user, so
+// most requests skip the store entirely.
+type activityTracker struct {
+	store Store
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+	pruned  time.Time
+}
+
+func (t *activityTracker) touch(userID string, now time.Time) error {
+	t.mu.Lock()
+	if now.Sub(t.pruned) >= ActivityResolution {
+		for id, at := range t.touched {
+			if now.Sub(at) >= ActivityResolution {
+				delete(t.touched, id)
+			}
+		}
+		t.pruned = now
+	}
+	if at, ok := t.touched[userID]; ok && now.Sub(at) < ActivityResolution {
+		t.mu.Unlock()
+		return nil
+	}
+	t.touched[userID] = now
+	t.mu.Unlock()
+
+	return t.store.TouchActivity(userID, now)
+}
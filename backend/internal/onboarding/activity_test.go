@@ -0,0 +1,55 @@
+package onboarding
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityTracker(t *testing.T) {
+	store := NewInMemoryStore()
+	if err := store.MarkOnboardingComplete("u1"); err != nil {
+		t.Fatal(err)
+	}
+	tracker := &activityTracker{store: store, touched: make(map[string]time.Time)}
+	start := time.Now().Add(time.Hour)
+
+	lastActive := func() time.Time {
+		t.Helper()
+		p, err := store.GetProfile("u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *p.LastActiveAt
+	}
+
+	steps := []struct {
+		at   time.Time
+		want time.Time
+	}{
+		{start, start},
+		{start.Add(time.Minute), start},
+		{start.Add(ActivityResolution), start.Add(ActivityResolution)},
+		{start.Add(2*ActivityResolution - time.Second), start.Add(ActivityResolution)},
+	}
+	for i, s := range steps {
+		if err := tracker.touch("u1", s.at); err != nil {
+			t.Fatal(err)
+		}
+		if got := lastActive(); !got.Equal(s.want) {
+			t.Errorf("step %d: last active %v, want %v", i, got, s.want)
+		}
+	}
+
+	if err := tracker.touch("nobody", start); err != nil {
+		t.Errorf("touching a user without a profile: %v", err)
+	}
+	if _, ok := tracker.touched["u1"]; !ok {
+		t.Error("u1 was pruned while still fresh")
+	}
+	if err := tracker.touch("u2", start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tracker.touched["u1"]; ok {
+		t.Error("stale entries were never pruned")
+	}
+}
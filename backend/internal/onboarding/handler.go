@@ -5,8 +5,13 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/screening"
 )
 
 // Store defines the minimal persistence API the onboarding handlers need.
@@ -20,21 +25,64 @@ type Store interface {
 	ReplaceInterests(userID string, interests []string) error
 	UpdateLocation(userID string, in LocationInput) error
 	MarkOnboardingComplete(userID string) error
+	// SetPrimaryPhoto records which photo leads the profile. Changing it
+	// clears VerifiedAt: the new photo has to be verified again.
+	SetPrimaryPhoto(userID, photoID string) error
+	// MarkVerified sets VerifiedAt, provided photoID is still the primary
+	// photo. It reports false, changing nothing, when it isn't.
+	MarkVerified(userID, photoID string, at time.Time) (bool, error)
+	// Snooze hides the user until until, or until Wake when until is nil.
+	// Snoozing again moves the end but keeps SnoozedAt. It returns
+	// ErrProfileNotFound for users without a profile.
+	Snooze(userID string, at time.Time, until *time.Time) error
+	// TouchActivity records that the user was active at at. It skips the
+	// write when the stored time is less than ActivityResolution older, and
+	// does nothing for users without a profile.
+	TouchActivity(userID string, at time.Time) error
+	// Wake ends the user's snooze, if any.
+	Wake(userID string) error
+	// WakeExpired ends up to limit snoozes whose end is at or before now
+	// and returns the users woken.
+	WakeExpired(now time.Time, limit int) ([]string, error)
+	// DeleteUser removes the user and their profile. In Postgres this
+	// deletes the users row, taking every row that references it along.
+	DeleteUser(userID string) error
+
+	// GetProfile returns the onboarding snapshot for a single user, or
+	// ErrProfileNotFound if the user has not started onboarding.
+	GetProfile(userID string) (*ProfileSnapshot, error)
+	// ListProfiles returns every stored profile. It is intended for the
+	// in-memory discovery store; Postgres callers should query directly.
+	ListProfiles() ([]ProfileSnapshot, error)
+	// ListProfilesNear returns profiles that may lie within radiusKm of the
+	// given point. The result can include a few farther profiles from the
+	// edges of index cells; callers filter on exact distance.
+	ListProfilesNear(lat, lng, radiusKm float64) ([]ProfileSnapshot, error)
 }
 
+// maxPhotoIDLength bounds client-generated photo IDs.
+const maxPhotoIDLength = 64
+
+// ErrProfileNotFound is returned by Store.GetProfile for unknown users.
+var ErrProfileNotFound = errors.New("profile not found")
+
 // Handler exposes HTTP handlers for the onboarding flow.
 type Handler struct {
-	logger *log.Logger
-	store  Store
+	logger   *log.Logger
+	store    Store
+	intents  intents.Store
+	screener *screening.Screener
 }
 
-func NewHandler(logger *log.Logger, store Store) *Handler {
+func NewHandler(logger *log.Logger, store Store, intentStore intents.Store, screener *screening.Screener) *Handler {
 	if logger == nil {
 		logger = log.Default()
 	}
 	return &Handler{
-		logger: logger,
-		store:  store,
+		logger:   logger,
+		store:    store,
+		intents:  intentStore,
+		screener: screener,
 	}
 }
 
@@ -73,6 +121,10 @@ type interestsRequest struct {
 	Interests []string `json:"interests"`
 }
 
+type primaryPhotoRequest struct {
+	PhotoID string `json:"photoId"`
+}
+
 type LocationInput struct {
 	Lat      float64 `json:"lat"`
 	Lng      float64 `json:"lng"`
@@ -81,262 +133,291 @@ type LocationInput struct {
 
 type locationRequest = LocationInput
 
-// --- Helpers ---
-
-func getUserID(r *http.Request) (string, error) {
-	// Prefer authenticated user ID from JWT middleware if available.
-	if uid, ok := auth.UserIDFromContext(r.Context()); ok && uid != "" {
-		return uid, nil
-	}
-
-	// Fallback for development: explicit debug header.
-	uid := r.Header.Get("X-Debug-UserID")
-	if uid == "" {
-		return "", errors.New("missing user context")
-	}
-	return uid, nil
-}
-
-func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
-}
-
-func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]string{"error": err.Error()})
-}
-
 // --- Handlers ---
 
 // UpdateIntent handles PUT /v1/onboarding/intent
 func (h *Handler) UpdateIntent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req intentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	if req.Intent == "" {
-		writeError(w, http.StatusBadRequest, errors.New("intent is required"))
+		httpjson.Error(w, http.StatusBadRequest, errors.New("intent is required"))
+		return
+	}
+	// Older app builds send WhatBringsYouScreen answers; store the catalogue
+	// ID they resolve to so feeds and scoring see one vocabulary.
+	intent, err := intents.Resolve(h.intents, req.Intent)
+	if errors.Is(err, intents.ErrNotFound) {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("unknown intent"))
+		return
+	}
+	if err != nil {
+		h.logger.Printf("UpdateIntent catalogue error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save intent"))
 		return
 	}
 
-	if err := h.store.UpsertIntent(userID, req.Intent); err != nil {
+	if err := h.store.UpsertIntent(userID, intent.ID); err != nil {
 		h.logger.Printf("UpdateIntent error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save intent"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save intent"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true, "intent": intent.ID})
 }
 
 // UpdatePreference handles PUT /v1/onboarding/preference
 func (h *Handler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req preferenceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	if len(req.PreferredGenders) == 0 {
-		writeError(w, http.StatusBadRequest, errors.New("preferredGenders is required"))
+		httpjson.Error(w, http.StatusBadRequest, errors.New("preferredGenders is required"))
 		return
 	}
 
 	if err := h.store.UpsertPreference(userID, req.PreferredGenders); err != nil {
 		h.logger.Printf("UpdatePreference error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save preference"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save preference"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // UpdateWhoAreYou handles PUT /v1/onboarding/who-are-you
 func (h *Handler) UpdateWhoAreYou(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req WhoAreYouInput
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	if req.DisplayName == "" {
-		writeError(w, http.StatusBadRequest, errors.New("displayName is required"))
+		httpjson.Error(w, http.StatusBadRequest, errors.New("displayName is required"))
+		return
+	}
+	name := screening.Content{Kind: screening.KindDisplayName, AuthorID: userID, Text: req.DisplayName}
+	verdict := h.screener.Check(r.Context(), name)
+	if verdict.Action == screening.ActionBlock {
+		httpjson.Error(w, http.StatusUnprocessableEntity, verdict.Err())
 		return
 	}
 
 	if err := h.store.UpsertWhoAreYou(userID, req); err != nil {
 		h.logger.Printf("UpdateWhoAreYou error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save profile"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save profile"))
 		return
 	}
+	h.screener.Review(name, verdict)
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// UpdatePrimaryPhoto handles PUT /v1/onboarding/primary-photo
+//
+// A verified profile loses its badge when the primary photo changes.
+func (h *Handler) UpdatePrimaryPhoto(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req primaryPhotoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	req.PhotoID = strings.TrimSpace(req.PhotoID)
+	if req.PhotoID == "" {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("photoId is required"))
+		return
+	}
+	if len(req.PhotoID) > maxPhotoIDLength {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("photoId is too long"))
+		return
+	}
+
+	if err := h.store.SetPrimaryPhoto(userID, req.PhotoID); err != nil {
+		h.logger.Printf("UpdatePrimaryPhoto error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save primary photo"))
+		return
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // UpdateConnectionStyle handles PUT /v1/onboarding/connection-style
 func (h *Handler) UpdateConnectionStyle(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req connectionStyleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	if req.ConnectionStyle == "" {
-		writeError(w, http.StatusBadRequest, errors.New("connectionStyle is required"))
+		httpjson.Error(w, http.StatusBadRequest, errors.New("connectionStyle is required"))
 		return
 	}
 
 	if err := h.store.UpsertConnectionStyle(userID, req.ConnectionStyle); err != nil {
 		h.logger.Printf("UpdateConnectionStyle error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save connection style"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save connection style"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // UpdateLifestyle handles PUT /v1/onboarding/lifestyle
 func (h *Handler) UpdateLifestyle(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req lifestyleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
 	if err := h.store.UpsertLifestyle(userID, req); err != nil {
 		h.logger.Printf("UpdateLifestyle error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save lifestyle"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save lifestyle"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // UpdateInterests handles PUT /v1/onboarding/interests
 func (h *Handler) UpdateInterests(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req interestsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	if len(req.Interests) == 0 {
-		writeError(w, http.StatusBadRequest, errors.New("interests is required"))
+		httpjson.Error(w, http.StatusBadRequest, errors.New("interests is required"))
 		return
 	}
 
 	if err := h.store.ReplaceInterests(userID, req.Interests); err != nil {
 		h.logger.Printf("UpdateInterests error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save interests"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save interests"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // UpdateLocation handles PUT /v1/onboarding/location
 func (h *Handler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	var req locationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		httpjson.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
 	if err := h.store.UpdateLocation(userID, req); err != nil {
 		h.logger.Printf("UpdateLocation error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to save location"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to save location"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
 
 // Complete handles POST /v1/onboarding/complete
 func (h *Handler) Complete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 		return
 	}
-	userID, err := getUserID(r)
+	userID, err := auth.UserID(r)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err)
+		httpjson.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	if err := h.store.MarkOnboardingComplete(userID); err != nil {
 		h.logger.Printf("Complete onboarding error: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("failed to complete onboarding"))
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to complete onboarding"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	httpjson.Write(w, http.StatusOK, map[string]any{"success": true})
 }
-
-
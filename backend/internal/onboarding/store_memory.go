@@ -3,6 +3,8 @@ package onboarding
 import (
 	"sync"
 	"time"
+
+	"github.com/rijey/kindl/backend/internal/geo"
 )
 
 // ProfileSnapshot is a minimal in-memory representation of a user's onboarding
@@ -27,11 +29,30 @@ type ProfileSnapshot struct {
 	Accuracy          float64
 	OnboardedAt       *time.Time
 	UpdatedAt         time.Time
+	// PrimaryPhotoID is the photo that leads the profile; VerifiedAt is
+	// set while a selfie check against it stands.
+	PrimaryPhotoID string
+	VerifiedAt     *time.Time
+	// SnoozedAt is set while the user is snoozed, hidden from everyone who
+	// hasn't matched with them; SnoozedUntil ends the snooze, or nil for
+	// one that lasts until they wake up.
+	SnoozedAt    *time.Time
+	SnoozedUntil *time.Time
+	// LastActiveAt is when the user last used the app, to within
+	// ActivityResolution.
+	LastActiveAt *time.Time
+}
+
+// Snoozed reports whether p is snoozed at now. A snooze whose end has
+// passed no longer counts, even before it is cleared.
+func (p *ProfileSnapshot) Snoozed(now time.Time) bool {
+	return p.SnoozedAt != nil && (p.SnoozedUntil == nil || now.Before(*p.SnoozedUntil))
 }
 
 type memoryStore struct {
 	mu       sync.Mutex
 	profiles map[string]*ProfileSnapshot
+	grid     *geo.Grid
 }
 
 // NewInMemoryStore returns an in-memory onboarding store.
@@ -40,6 +61,7 @@ type memoryStore struct {
 func NewInMemoryStore() Store {
 	return &memoryStore{
 		profiles: make(map[string]*ProfileSnapshot),
+		grid:     geo.NewGrid(),
 	}
 }
 
@@ -125,6 +147,7 @@ func (s *memoryStore) UpdateLocation(userID string, in LocationInput) error {
 	p.Lng = in.Lng
 	p.Accuracy = in.Accuracy
 	p.UpdatedAt = time.Now()
+	s.grid.Set(userID, in.Lat, in.Lng)
 	return nil
 }
 
@@ -134,8 +157,160 @@ func (s *memoryStore) MarkOnboardingComplete(userID string) error {
 	p := s.getOrCreate(userID)
 	now := time.Now()
 	p.OnboardedAt = &now
+	p.LastActiveAt = &now
 	p.UpdatedAt = now
 	return nil
 }
 
+func (s *memoryStore) SetPrimaryPhoto(userID, photoID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.getOrCreate(userID)
+	if p.PrimaryPhotoID != photoID {
+		p.PrimaryPhotoID = photoID
+		p.VerifiedAt = nil
+	}
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *memoryStore) MarkVerified(userID, photoID string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[userID]
+	if !ok || photoID == "" || p.PrimaryPhotoID != photoID {
+		return false, nil
+	}
+	p.VerifiedAt = &at
+	p.UpdatedAt = time.Now()
+	return true, nil
+}
 
+func (s *memoryStore) Snooze(userID string, at time.Time, until *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[userID]
+	if !ok {
+		return ErrProfileNotFound
+	}
+	if p.SnoozedAt == nil {
+		p.SnoozedAt = &at
+	}
+	p.SnoozedUntil = nil
+	if until != nil {
+		t := *until
+		p.SnoozedUntil = &t
+	}
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *memoryStore) TouchActivity(userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[userID]
+	if !ok || (p.LastActiveAt != nil && at.Sub(*p.LastActiveAt) < ActivityResolution) {
+		return nil
+	}
+	p.LastActiveAt = &at
+	return nil
+}
+
+func (s *memoryStore) Wake(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.profiles[userID]; ok && p.SnoozedAt != nil {
+		p.SnoozedAt, p.SnoozedUntil = nil, nil
+		p.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *memoryStore) WakeExpired(now time.Time, limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var woken []string
+	for id, p := range s.profiles {
+		if len(woken) == limit {
+			break
+		}
+		if p.SnoozedAt == nil || p.SnoozedUntil == nil || p.SnoozedUntil.After(now) {
+			continue
+		}
+		p.SnoozedAt, p.SnoozedUntil = nil, nil
+		p.UpdatedAt = time.Now()
+		woken = append(woken, id)
+	}
+	return woken, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.profiles, userID)
+	s.grid.Remove(userID)
+	return nil
+}
+
+func (s *memoryStore) GetProfile(userID string) (*ProfileSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[userID]
+	if !ok {
+		return nil, ErrProfileNotFound
+	}
+	cp := cloneProfile(p)
+	return &cp, nil
+}
+
+func (s *memoryStore) ListProfiles() ([]ProfileSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ProfileSnapshot, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		out = append(out, cloneProfile(p))
+	}
+	return out, nil
+}
+
+func (s *memoryStore) ListProfilesNear(lat, lng, radiusKm float64) ([]ProfileSnapshot, error) {
+	ids := s.grid.Near(lat, lng, radiusKm)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ProfileSnapshot, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := s.profiles[id]; ok {
+			out = append(out, cloneProfile(p))
+		}
+	}
+	return out, nil
+}
+
+// cloneProfile copies a snapshot so callers can't mutate store state.
+func cloneProfile(p *ProfileSnapshot) ProfileSnapshot {
+	cp := *p
+	cp.PreferredGenders = append([]string(nil), p.PreferredGenders...)
+	cp.Interests = append([]string(nil), p.Interests...)
+	if p.OnboardedAt != nil {
+		t := *p.OnboardedAt
+		cp.OnboardedAt = &t
+	}
+	if p.VerifiedAt != nil {
+		t := *p.VerifiedAt
+		cp.VerifiedAt = &t
+	}
+	if p.SnoozedAt != nil {
+		t := *p.SnoozedAt
+		cp.SnoozedAt = &t
+	}
+	if p.SnoozedUntil != nil {
+		t := *p.SnoozedUntil
+		cp.SnoozedUntil = &t
+	}
+	if p.LastActiveAt != nil {
+		t := *p.LastActiveAt
+		cp.LastActiveAt = &t
+	}
+	return cp
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/rijey/kindl/backend/internal/geo"
 )
 
 // pgStore is a Postgres-backed implementation of the onboarding Store.
@@ -35,6 +39,7 @@ import (
 //	  location_lat DOUBLE PRECISION,
 //	  location_lng DOUBLE PRECISION,
 //	  location_accuracy DOUBLE PRECISION,
+//	  location_geohash TEXT,
 //	  onboarded_at TIMESTAMPTZ,
 //	  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 //	);
@@ -214,15 +219,16 @@ func (s *pgStore) UpdateLocation(userID string, in LocationInput) error {
 	}
 
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO profiles (user_id, location_lat, location_lng, location_accuracy)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO profiles (user_id, location_lat, location_lng, location_accuracy, location_geohash)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (user_id)
 		DO UPDATE SET
 			location_lat      = EXCLUDED.location_lat,
 			location_lng      = EXCLUDED.location_lng,
 			location_accuracy = EXCLUDED.location_accuracy,
+			location_geohash  = EXCLUDED.location_geohash,
 			updated_at        = now()
-	`, userID, in.Lat, in.Lng, in.Accuracy)
+	`, userID, in.Lat, in.Lng, in.Accuracy, geo.Encode(in.Lat, in.Lng, geo.IndexPrecision))
 	return err
 }
 
@@ -233,10 +239,282 @@ func (s *pgStore) MarkOnboardingComplete(userID string) error {
 	}
 
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO profiles (user_id, onboarded_at)
-		VALUES ($1, now())
+		INSERT INTO profiles (user_id, onboarded_at, last_active_at)
+		VALUES ($1, now(), now())
+		ON CONFLICT (user_id)
+		DO UPDATE SET onboarded_at = now(), last_active_at = now(), updated_at = now()
+	`, userID)
+	return err
+}
+
+func (s *pgStore) SetPrimaryPhoto(userID, photoID string) error {
+	ctx := context.Background()
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO profiles (user_id, primary_photo_id)
+		VALUES ($1, $2)
 		ON CONFLICT (user_id)
-		DO UPDATE SET onboarded_at = now(), updated_at = now()
+		DO UPDATE SET
+			primary_photo_id = EXCLUDED.primary_photo_id,
+			verified_at      = CASE WHEN profiles.primary_photo_id = EXCLUDED.primary_photo_id
+			                        THEN profiles.verified_at END,
+			updated_at       = now()
+	`, userID, photoID)
+	return err
+}
+
+func (s *pgStore) MarkVerified(userID, photoID string, at time.Time) (bool, error) {
+	if photoID == "" {
+		return false, nil
+	}
+	res, err := s.db.ExecContext(context.Background(), `
+		UPDATE profiles SET verified_at = $3, updated_at = now()
+		WHERE user_id = $1 AND primary_photo_id = $2
+	`, userID, photoID, at)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *pgStore) Snooze(userID string, at time.Time, until *time.Time) error {
+	res, err := s.db.ExecContext(context.Background(), `
+		UPDATE profiles SET
+			snoozed_at    = COALESCE(snoozed_at, $2),
+			snoozed_until = $3,
+			updated_at    = now()
+		WHERE user_id = $1
+	`, userID, at, until)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrProfileNotFound
+	}
+	return nil
+}
+
+// TouchActivity leaves updated_at alone: using the app isn't a profile edit.
+func (s *pgStore) TouchActivity(userID string, at time.Time) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		UPDATE profiles SET last_active_at = $2
+		WHERE user_id = $1 AND (last_active_at IS NULL OR last_active_at <= $3)
+	`, userID, at, at.Add(-ActivityResolution))
+	return err
+}
+
+func (s *pgStore) Wake(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		UPDATE profiles SET snoozed_at = NULL, snoozed_until = NULL, updated_at = now()
+		WHERE user_id = $1 AND snoozed_at IS NOT NULL
 	`, userID)
 	return err
 }
+
+func (s *pgStore) WakeExpired(now time.Time, limit int) ([]string, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		UPDATE profiles SET snoozed_at = NULL, snoozed_until = NULL, updated_at = now()
+		WHERE user_id IN (
+			SELECT user_id FROM profiles
+			WHERE snoozed_until <= $1
+			ORDER BY snoozed_until
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING user_id
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var woken []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		woken = append(woken, id)
+	}
+	return woken, rows.Err()
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `DELETE FROM users WHERE id = $1`, userID)
+	return err
+}
+
+// profileColumns is the column list scanned by scanProfile.
+const profileColumns = `
+	p.user_id, p.intent, p.preferred_genders, p.display_name, p.gender, p.pronouns,
+	p.birthdate, p.connection_style, p.height_cm, p.drinks, p.smokes, p.exercise_level,
+	p.relationship_style, p.location_lat, p.location_lng, p.location_accuracy,
+	p.onboarded_at, p.updated_at, p.primary_photo_id, p.verified_at,
+	p.snoozed_at, p.snoozed_until, p.last_active_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProfile reads a profiles row selected with profileColumns. Most columns
+// are nullable because each onboarding screen fills in only its own fields.
+func scanProfile(row rowScanner) (*ProfileSnapshot, error) {
+	var (
+		p                                         ProfileSnapshot
+		intent, prefs, name, gender, pronouns     sql.NullString
+		style, drinks, smokes, exercise, relStyle sql.NullString
+		birthdate, onboardedAt, verifiedAt        sql.NullTime
+		snoozedAt, snoozedUntil, lastActiveAt     sql.NullTime
+		height                                    sql.NullInt64
+		lat, lng, accuracy                        sql.NullFloat64
+	)
+	if err := row.Scan(
+		&p.UserID, &intent, &prefs, &name, &gender, &pronouns,
+		&birthdate, &style, &height, &drinks, &smokes, &exercise,
+		&relStyle, &lat, &lng, &accuracy,
+		&onboardedAt, &p.UpdatedAt, &p.PrimaryPhotoID, &verifiedAt,
+		&snoozedAt, &snoozedUntil, &lastActiveAt,
+	); err != nil {
+		return nil, err
+	}
+
+	p.Intent = intent.String
+	p.PreferredGenders = SplitGenders(prefs.String)
+	p.DisplayName = name.String
+	p.Gender = gender.String
+	p.Pronouns = pronouns.String
+	if birthdate.Valid {
+		p.Birthdate = birthdate.Time.Format("2006-01-02")
+	}
+	p.ConnectionStyle = style.String
+	p.HeightCm = int(height.Int64)
+	p.Drinks = drinks.String
+	p.Smokes = smokes.String
+	p.ExerciseLevel = exercise.String
+	p.RelationshipStyle = relStyle.String
+	p.Lat = lat.Float64
+	p.Lng = lng.Float64
+	p.Accuracy = accuracy.Float64
+	if onboardedAt.Valid {
+		t := onboardedAt.Time
+		p.OnboardedAt = &t
+	}
+	if verifiedAt.Valid {
+		t := verifiedAt.Time
+		p.VerifiedAt = &t
+	}
+	if snoozedAt.Valid {
+		t := snoozedAt.Time
+		p.SnoozedAt = &t
+	}
+	if snoozedUntil.Valid {
+		t := snoozedUntil.Time
+		p.SnoozedUntil = &t
+	}
+	if lastActiveAt.Valid {
+		t := lastActiveAt.Time
+		p.LastActiveAt = &t
+	}
+	return &p, nil
+}
+
+// SplitGenders parses the comma-separated preferred_genders column.
+func SplitGenders(joined string) []string {
+	if joined == "" {
+		return nil
+	}
+	return strings.Split(joined, ",")
+}
+
+func (s *pgStore) GetProfile(userID string) (*ProfileSnapshot, error) {
+	ctx := context.Background()
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+profileColumns+` FROM profiles p WHERE p.user_id = $1`, userID)
+	p, err := scanProfile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT interest_key FROM user_interests WHERE user_id = $1 ORDER BY interest_key
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		p.Interests = append(p.Interests, key)
+	}
+	return p, rows.Err()
+}
+
+func (s *pgStore) ListProfiles() ([]ProfileSnapshot, error) {
+	ctx := context.Background()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+profileColumns+` FROM profiles p ORDER BY p.user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ProfileSnapshot
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) ListProfilesNear(lat, lng, radiusKm float64) ([]ProfileSnapshot, error) {
+	ctx := context.Background()
+
+	cells := geo.Cover(lat, lng, radiusKm)
+	if cells == nil {
+		return s.ListProfiles()
+	}
+
+	args := make([]any, 0, len(cells))
+	prefixes := make([]string, 0, len(cells))
+	for i, cell := range cells {
+		args = append(args, cell+"%")
+		prefixes = append(prefixes, fmt.Sprintf("p.location_geohash LIKE $%d", i+1))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+profileColumns+`
+		FROM profiles p
+		WHERE `+strings.Join(prefixes, " OR ")+`
+		ORDER BY p.user_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ProfileSnapshot
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,79 @@
+// Package pubsub fans events out between API instances.
+package pubsub
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// Handler receives the payload of a published event. Handlers run on the
+// bus's delivery goroutine and must not block.
+type Handler func(payload []byte)
+
+// Bus publishes opaque payloads on named topics. Every subscriber on every
+// instance connected to the same bus receives each payload, including the
+// instance that published it.
+type Bus interface {
+	Publish(topic string, payload []byte) error
+	// Subscribe registers h for topic and returns a function that removes it.
+	Subscribe(topic string, h Handler) (unsubscribe func())
+	Close() error
+}
+
+// ErrClosed is returned when publishing on a closed bus.
+var ErrClosed = errors.New("pubsub: bus closed")
+
+// Topic names double as Postgres channel names, so keep them simple.
+var topicPattern = regexp.MustCompile(`^[a-z][a-z0-9_.]{0,62}$`)
+
+func validTopic(topic string) error {
+	if !topicPattern.MatchString(topic) {
+		return errors.New("pubsub: invalid topic " + topic)
+	}
+	return nil
+}
+
+// subscriptions is the handler registry shared by the implementations.
+type subscriptions struct {
+	next     int
+	handlers map[string]map[int]Handler
+}
+
+func (s *subscriptions) add(topic string, h Handler) int {
+	if s.handlers == nil {
+		s.handlers = make(map[string]map[int]Handler)
+	}
+	s.next++
+	set, ok := s.handlers[topic]
+	if !ok {
+		set = make(map[int]Handler)
+		s.handlers[topic] = set
+	}
+	set[s.next] = h
+	return s.next
+}
+
+// remove drops a handler and reports whether topic has none left.
+func (s *subscriptions) remove(topic string, id int) bool {
+	set := s.handlers[topic]
+	delete(set, id)
+	if len(set) == 0 {
+		delete(s.handlers, topic)
+		return true
+	}
+	return false
+}
+
+func (s *subscriptions) forTopic(topic string) []Handler {
+	set := s.handlers[topic]
+	out := make([]Handler, 0, len(set))
+	for _, h := range set {
+		out = append(out, h)
+	}
+	return out
+}
+
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,112 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInProcessFanOut(t *testing.T) {
+	b := NewInProcess()
+	var mu sync.Mutex
+	got := map[string][]string{}
+	record := func(name string) Handler {
+		return func(payload []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] = append(got[name], string(payload))
+		}
+	}
+	b.Subscribe("chat.events", record("a"))
+	unsubscribeB := b.Subscribe("chat.events", record("b"))
+	b.Subscribe("chat.presence", record("c"))
+
+	if err := b.Publish("chat.events", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribeB()
+	unsubscribeB() // a second call is a no-op
+	if err := b.Publish("chat.events", []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"a": 2, "b": 1, "c": 0}
+	for name, n := range want {
+		if len(got[name]) != n {
+			t.Errorf("%s received %v, want %d payloads", name, got[name], n)
+		}
+	}
+}
+
+func TestPublishChecks(t *testing.T) {
+	b := NewInProcess()
+	for _, topic := range []string{"", "Chat", "chat events", "1chat"} {
+		if err := b.Publish(topic, nil); err == nil {
+			t.Errorf("Publish(%q) succeeded, want an invalid topic error", topic)
+		}
+	}
+
+	called := false
+	b.Subscribe("chat.events", func([]byte) { called = true })
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish("chat.events", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close error = %v, want ErrClosed", err)
+	}
+	if called {
+		t.Error("a handler ran after Close")
+	}
+}
+
+// Hubs publish from request goroutines while connections come and go, so
+// subscribing, unsubscribing and publishing all race each other.
+func TestInProcessConcurrentUse(t *testing.T) {
+	b := NewInProcess()
+	var delivered atomic.Int64
+	b.Subscribe("chat.events", func([]byte) { delivered.Add(1) })
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if err := b.Publish("chat.events", []byte("x")); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				unsubscribe := b.Subscribe("chat.events", func([]byte) {})
+				unsubscribe()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := delivered.Load(); n != 800 {
+		t.Errorf("delivered %d payloads, want 800", n)
+	}
+}
+
+func TestPostgresDispatch(t *testing.T) {
+	b := &pgBus{logger: log.New(io.Discard, "", 0)}
+	var got []string
+	b.subs.add("chat.events", func(payload []byte) { got = append(got, string(payload)) })
+
+	b.dispatch(context.Background(), "chat.events", inlinePrefix+`{"type":"message"}`)
+	b.dispatch(context.Background(), "chat.events", "no prefix")
+	b.dispatch(context.Background(), "chat.presence", inlinePrefix+"other topic")
+
+	if len(got) != 1 || got[0] != `{"type":"message"}` {
+		t.Errorf("delivered %q, want only the inline payload", got)
+	}
+}
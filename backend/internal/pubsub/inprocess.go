@@ -0,0 +1,57 @@
+package pubsub
+
+import "sync"
+
+// inProcessBus delivers payloads to subscribers in the same process. It is
+// all a single-node deployment needs.
+type inProcessBus struct {
+	mu     sync.Mutex
+	subs   subscriptions
+	closed bool
+}
+
+// NewInProcess returns a Bus that only reaches the current process.
+func NewInProcess() Bus {
+	return &inProcessBus{}
+}
+
+func (b *inProcessBus) Publish(topic string, payload []byte) error {
+	if err := validTopic(topic); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	handlers := b.subs.forTopic(topic)
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+func (b *inProcessBus) Subscribe(topic string, h Handler) func() {
+	b.mu.Lock()
+	id := b.subs.add(topic, h)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.subs.remove(topic, id)
+			b.mu.Unlock()
+		})
+	}
+}
+
+func (b *inProcessBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.subs = subscriptions{}
+	b.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,240 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// maxInlinePayload keeps NOTIFY payloads under Postgres's 8000 byte
+	// limit. Larger payloads are spilled to the pubsub_payloads table and
+	// the notification carries a reference instead.
+	maxInlinePayload = 7000
+	// spillRetention is how long spilled payloads are kept for listeners
+	// to fetch.
+	spillRetention = 5 * time.Minute
+
+	inlinePrefix = "i:"
+	spillPrefix  = "r:"
+)
+
+// pgBus fans payloads out through Postgres LISTEN/NOTIFY, so every API
+// instance sharing the database receives them without extra infrastructure.
+//
+// Payloads must be UTF-8 text (JSON in practice). Delivery is at-most-once:
+// notifications sent while the listener is reconnecting are lost, which
+// clients tolerate because they resync history on reconnect.
+type pgBus struct {
+	logger *log.Logger
+	db     *sql.DB
+	dsn    string
+
+	mu      sync.Mutex
+	subs    subscriptions
+	closed  bool
+	restart context.CancelFunc
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPostgres starts a Bus that publishes with NOTIFY on db and listens on
+// a dedicated connection opened from dsn. Call Close to stop it.
+func NewPostgres(logger *log.Logger, db *sql.DB, dsn string) Bus {
+	if logger == nil {
+		logger = log.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &pgBus{
+		logger: logger,
+		db:     db,
+		dsn:    dsn,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go b.run(ctx)
+	return b
+}
+
+func (b *pgBus) Publish(topic string, payload []byte) error {
+	if err := validTopic(topic); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	ctx := context.Background()
+	if len(payload) <= maxInlinePayload {
+		_, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, topic, inlinePrefix+string(payload))
+		return err
+	}
+
+	var id int64
+	if err := b.db.QueryRowContext(ctx, `
+		INSERT INTO pubsub_payloads (topic, payload) VALUES ($1, $2) RETURNING id
+	`, topic, payload).Scan(&id); err != nil {
+		return err
+	}
+	_, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, topic, spillPrefix+formatInt(id))
+	return err
+}
+
+func (b *pgBus) Subscribe(topic string, h Handler) func() {
+	b.mu.Lock()
+	_, listening := b.subs.handlers[topic]
+	id := b.subs.add(topic, h)
+	restart := b.restart
+	b.mu.Unlock()
+
+	// The listener connection LISTENs on every subscribed topic when it
+	// (re)connects, so a new topic means reconnecting.
+	if !listening && restart != nil {
+		restart()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.subs.remove(topic, id)
+			b.mu.Unlock()
+		})
+	}
+}
+
+func (b *pgBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.cancel()
+	<-b.done
+	return nil
+}
+
+// run keeps a listener connection alive until ctx is cancelled.
+func (b *pgBus) run(ctx context.Context) {
+	defer close(b.done)
+
+	janitor := time.NewTicker(spillRetention)
+	defer janitor.Stop()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-janitor.C:
+				b.purgeSpilled(ctx)
+			}
+		}
+	}()
+
+	backoff := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// Restarted to pick up a new topic.
+			backoff = time.Second
+			continue
+		}
+		b.logger.Printf("pubsub: listener error (retrying in %s): %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// listen holds one listener session. It returns nil when asked to restart.
+func (b *pgBus) listen(ctx context.Context) error {
+	sessCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	b.mu.Lock()
+	b.restart = cancel
+	topics := make([]string, 0, len(b.subs.handlers))
+	for topic := range b.subs.handlers {
+		topics = append(topics, topic)
+	}
+	b.mu.Unlock()
+
+	conn, err := pgx.Connect(sessCtx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for _, topic := range topics {
+		if _, err := conn.Exec(sessCtx, "LISTEN "+pgx.Identifier{topic}.Sanitize()); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(sessCtx)
+		if err != nil {
+			if sessCtx.Err() != nil && ctx.Err() == nil {
+				return nil
+			}
+			return err
+		}
+		b.dispatch(ctx, n.Channel, n.Payload)
+	}
+}
+
+func (b *pgBus) dispatch(ctx context.Context, topic, raw string) {
+	var payload []byte
+	switch {
+	case strings.HasPrefix(raw, inlinePrefix):
+		payload = []byte(raw[len(inlinePrefix):])
+	case strings.HasPrefix(raw, spillPrefix):
+		err := b.db.QueryRowContext(ctx, `
+			SELECT payload FROM pubsub_payloads WHERE id = $1
+		`, raw[len(spillPrefix):]).Scan(&payload)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				b.logger.Printf("pubsub: load spilled payload: %v", err)
+			}
+			return
+		}
+	default:
+		b.logger.Printf("pubsub: ignoring malformed notification on %s", topic)
+		return
+	}
+
+	b.mu.Lock()
+	handlers := b.subs.forTopic(topic)
+	b.mu.Unlock()
+	for _, h := range handlers {
+		h(payload)
+	}
+}
+
+func (b *pgBus) purgeSpilled(ctx context.Context) {
+	_, err := b.db.ExecContext(ctx, `
+		DELETE FROM pubsub_payloads WHERE created_at < now() - make_interval(secs => $1)
+	`, spillRetention.Seconds())
+	if err != nil && ctx.Err() == nil {
+		b.logger.Printf("pubsub: purge spilled payloads: %v", err)
+	}
+}
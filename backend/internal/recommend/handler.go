@@ -0,0 +1,60 @@
+package recommend
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// Handler exposes intent recommendations over HTTP.
+type Handler struct {
+	logger      *log.Logger
+	recommender *Recommender
+}
+
+func NewHandler(logger *log.Logger, recommender *Recommender) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:      logger,
+		recommender: recommender,
+	}
+}
+
+// --- Handlers ---
+
+// Recommended handles GET /v1/intents/recommended?limit=3
+func (h *Handler) Recommended(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	limit := DefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+			return
+		}
+		limit = n
+	}
+
+	recs, err := h.recommender.For(userID, limit)
+	if err != nil {
+		h.logger.Printf("Recommended intents error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load recommendations"))
+		return
+	}
+	httpjson.Write(w, http.StatusOK, recs)
+}
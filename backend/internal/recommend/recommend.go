@@ -0,0 +1,264 @@
+// Package recommend ranks intents for a user by blending what they told us
+// in onboarding, how they've behaved in the app and what's popular, so every
+// client shows the same recommendations.
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/scoring"
+)
+
+// ScoreSource reports a user's raw behaviour score per intent; negative
+// means they've mostly turned it down (events.Scores).
+type ScoreSource interface {
+	IntentScores(userID string) (map[string]float64, error)
+}
+
+// PopularitySource counts users drawn to each intent recently
+// (events.Store).
+type PopularitySource interface {
+	IntentPopularity(since time.Time) (map[string]int, error)
+}
+
+// Component names and weights. Behaviour dominates once there is some; for
+// new users interests and popularity carry the ranking.
+const (
+	ComponentInterests  = "interests"
+	ComponentBehavior   = "behavior"
+	ComponentPopularity = "popularity"
+
+	weightInterests  = 0.3
+	weightBehavior   = 0.5
+	weightPopularity = 0.2
+)
+
+const (
+	DefaultLimit = 3
+	// maxBlended mirrors PersonalizationEngine.getBlendedRecommendations.
+	maxBlended = 2
+	// blendMinVibes is how many of the user's vibes an intent must share to
+	// count as a blend of them.
+	blendMinVibes = 2
+	// rejectedPenalty scales the score of intents the user keeps passing on.
+	rejectedPenalty = 0.5
+
+	popularityWindow = 30 * 24 * time.Hour
+	popularityTTL    = 5 * time.Minute
+	maxReasons       = 3
+)
+
+// interestVibes maps InterestsScreen interest keys to the vibe tags they
+// suggest, in the vocabulary of the intent catalogue's VibeTags.
+var interestVibes = map[string][]string{
+	"music":        {"expressive", "inspiring", "emotional"},
+	"travel":       {"adventurous", "curious", "spontaneous"},
+	"fitness":      {"energetic", "active"},
+	"series":       {"cozy", "relaxed", "comfortable"},
+	"art":          {"artistic", "expressive", "imaginative"},
+	"pets":         {"compassionate", "comfortable", "genuine"},
+	"foodie":       {"cozy", "social"},
+	"tech":         {"curious", "philosophical"},
+	"outdoors":     {"nature-loving", "adventurous", "active"},
+	"spirituality": {"mindful", "peaceful", "grounded", "reflective"},
+}
+
+// VibeTags returns the vibe tags suggested by a set of interests, sorted
+// and without duplicates.
+func VibeTags(interests []string) []string {
+	var out []string
+	for _, key := range interests {
+		for _, tag := range interestVibes[key] {
+			if !slices.Contains(out, tag) {
+				out = append(out, tag)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Item is one recommended intent with the breakdown behind its score.
+type Item struct {
+	Intent       intents.Intent      `json:"intent"`
+	Score        float64             `json:"score"`
+	MatchedVibes []string            `json:"matchedVibes"`
+	Components   []scoring.Component `json:"components"`
+	Reasons      []scoring.Reason    `json:"reasons"`
+}
+
+// Recommendations is everything a client needs to render the personalised
+// part of the intent screen.
+type Recommendations struct {
+	// VibeTags are the user's vibes as derived from their interests.
+	VibeTags    []string `json:"vibeTags"`
+	Recommended []Item   `json:"recommended"`
+	// Blended are intents that combine several of the user's vibes.
+	Blended []Item `json:"blended"`
+}
+
+// Recommender blends the signals. It caches population popularity briefly
+// since it changes slowly and is the same for everyone.
+type Recommender struct {
+	intents    intents.Store
+	profiles   onboarding.Store
+	scores     ScoreSource
+	popularity PopularitySource
+	now        func() time.Time
+
+	mu        sync.Mutex
+	popular   map[string]int
+	popularAt time.Time
+}
+
+func NewRecommender(intentStore intents.Store, profiles onboarding.Store, scores ScoreSource, popularity PopularitySource) *Recommender {
+	return &Recommender{
+		intents:    intentStore,
+		profiles:   profiles,
+		scores:     scores,
+		popularity: popularity,
+		now:        time.Now,
+	}
+}
+
+func (r *Recommender) popularIntents(now time.Time) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.popular != nil && now.Sub(r.popularAt) < popularityTTL {
+		return r.popular, nil
+	}
+	popular, err := r.popularity.IntentPopularity(now.Add(-popularityWindow))
+	if err != nil {
+		return nil, err
+	}
+	r.popular, r.popularAt = popular, now
+	return popular, nil
+}
+
+// For ranks the active catalogue for userID and returns the top limit
+// intents plus blends. Users who haven't onboarded get interest-free
+// recommendations rather than an error.
+func (r *Recommender) For(userID string, limit int) (*Recommendations, error) {
+	now := r.now().UTC()
+
+	catalogue, err := r.intents.List(false)
+	if err != nil {
+		return nil, err
+	}
+	var interests []string
+	profile, err := r.profiles.GetProfile(userID)
+	switch {
+	case err == nil:
+		interests = profile.Interests
+	case !errors.Is(err, onboarding.ErrProfileNotFound):
+		return nil, err
+	}
+	scores, err := r.scores.IntentScores(userID)
+	if err != nil {
+		return nil, err
+	}
+	popular, err := r.popularIntents(now)
+	if err != nil {
+		return nil, err
+	}
+
+	vibes := VibeTags(interests)
+	var topScore float64
+	for _, v := range scores {
+		topScore = max(topScore, v)
+	}
+	var topPopular int
+	for _, n := range popular {
+		topPopular = max(topPopular, n)
+	}
+
+	items := make([]Item, 0, len(catalogue))
+	for _, in := range catalogue {
+		item := Item{Intent: in, MatchedVibes: []string{}, Reasons: []scoring.Reason{}}
+		for _, tag := range in.VibeTags {
+			if slices.Contains(vibes, tag) {
+				item.MatchedVibes = append(item.MatchedVibes, tag)
+			}
+		}
+
+		interestValue := math.Min(1, float64(len(item.MatchedVibes))/blendMinVibes)
+		var behaviorValue, popularityValue float64
+		if topScore > 0 {
+			behaviorValue = max(0, scores[in.ID]) / topScore
+		}
+		if topPopular > 0 {
+			popularityValue = float64(popular[in.ID]) / float64(topPopular)
+		}
+		item.Components = []scoring.Component{
+			{Name: ComponentInterests, Value: interestValue, Weight: weightInterests},
+			{Name: ComponentBehavior, Value: behaviorValue, Weight: weightBehavior},
+			{Name: ComponentPopularity, Value: popularityValue, Weight: weightPopularity},
+		}
+		for _, c := range item.Components {
+			item.Score += c.Value * c.Weight
+		}
+		if scores[in.ID] < 0 {
+			item.Score *= rejectedPenalty
+		}
+		item.Reasons = reasons(&item, behaviorValue, popularityValue)
+		items = append(items, item)
+	}
+
+	// Stable, so ties keep catalogue order.
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+
+	out := &Recommendations{VibeTags: vibes, Recommended: items[:min(limit, len(items))], Blended: []Item{}}
+	if out.VibeTags == nil {
+		out.VibeTags = []string{}
+	}
+	if len(vibes) >= blendMinVibes {
+		for _, item := range items {
+			if len(item.MatchedVibes) >= blendMinVibes && scores[item.Intent.ID] >= 0 && len(out.Blended) < maxBlended {
+				out.Blended = append(out.Blended, item)
+			}
+		}
+	}
+	return out, nil
+}
+
+// reasons explains an item's strongest signals, biggest contribution first.
+func reasons(item *Item, behaviorValue, popularityValue float64) []scoring.Reason {
+	type scored struct {
+		scoring.Reason
+		weight float64
+	}
+	var all []scored
+	if n := len(item.MatchedVibes); n > 0 {
+		all = append(all, scored{
+			Reason: scoring.Reason{Code: ComponentInterests, Text: fmt.Sprintf("Fits your %s vibe", strings.Join(item.MatchedVibes[:min(n, 2)], " & "))},
+			weight: math.Min(1, float64(n)/blendMinVibes) * weightInterests,
+		})
+	}
+	if behaviorValue >= 0.5 {
+		all = append(all, scored{
+			Reason: scoring.Reason{Code: ComponentBehavior, Text: "You keep coming back to this"},
+			weight: behaviorValue * weightBehavior,
+		})
+	}
+	if popularityValue >= 0.5 {
+		all = append(all, scored{
+			Reason: scoring.Reason{Code: ComponentPopularity, Text: "Popular right now"},
+			weight: popularityValue * weightPopularity,
+		})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].weight > all[j].weight })
+	out := []scoring.Reason{}
+	for i := 0; i < len(all) && i < maxReasons; i++ {
+		out = append(out, all[i].Reason)
+	}
+	return out
+}
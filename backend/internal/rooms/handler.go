@@ -0,0 +1,417 @@
+package rooms
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/intents"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// Length limits for room details, in characters.
+const (
+	maxNameLength        = 60
+	maxDescriptionLength = 280
+)
+
+// Real-time events, delivered to every member over the chat socket
+// (/v1/ws).
+const (
+	EventMessage        = "room.message"
+	EventMessageDeleted = "room.message.deleted"
+	EventMember         = "room.member"
+)
+
+// Membership changes carried by EventMember.
+const (
+	changeJoined   = "joined"
+	changeLeft     = "left"
+	changeKicked   = "kicked"
+	changeBanned   = "banned"
+	changeRole     = "role"
+	changeMuted    = "muted"
+	changeUnmuted  = "unmuted"
+	changeNewOwner = "owner"
+)
+
+// Notifier delivers frames to users' live connections; *chat.Hub
+// implements it.
+type Notifier interface {
+	SendFrame(v any, userIDs ...string)
+}
+
+// BlockList reports who a user blocked or was blocked by; safety.Store
+// implements it. Room messages are hidden between blocked pairs in both
+// directions.
+type BlockList interface {
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+}
+
+// Event is the frame for every room event.
+type Event struct {
+	Type    string      `json:"type"`
+	RoomID  string      `json:"roomId"`
+	Message *Message    `json:"message,omitempty"`
+	Member  *MemberView `json:"member,omitempty"`
+	Change  string      `json:"change,omitempty"`
+}
+
+// MemberView is a member with their display name.
+type MemberView struct {
+	Member
+	DisplayName string     `json:"displayName"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// Handler exposes rooms over HTTP and pushes room events to members.
+type Handler struct {
+	logger   *log.Logger
+	store    Store
+	profiles onboarding.Store
+	intents  intents.Store
+	blocks   BlockList
+	notifier Notifier
+	screener *screening.Screener
+}
+
+func NewHandler(logger *log.Logger, store Store, profiles onboarding.Store, intentStore intents.Store, blocks BlockList,
+	notifier Notifier, screener *screening.Screener) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		store:    store,
+		profiles: profiles,
+		intents:  intentStore,
+		blocks:   blocks,
+		notifier: notifier,
+		screener: screener,
+	}
+}
+
+// --- Request payloads ---
+
+type createRoomRequest struct {
+	IntentID    string `json:"intentId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Capacity    int    `json:"capacity"`
+}
+
+type roomResponse struct {
+	Room       *Room   `json:"room"`
+	Membership *Member `json:"membership,omitempty"`
+}
+
+// --- Helpers ---
+
+// storeError writes the response for a store error, logging anything
+// unexpected.
+func (h *Handler) storeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, ErrRoomNotFound), errors.Is(err, ErrMessageNotFound):
+		httpjson.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrNotMember), errors.Is(err, ErrBanned):
+		httpjson.Error(w, http.StatusForbidden, err)
+	case errors.Is(err, ErrRoomFull):
+		httpjson.Error(w, http.StatusConflict, err)
+	default:
+		h.logger.Printf("rooms %s error: %v", action, err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to "+action))
+	}
+}
+
+// membership loads the caller's membership of the room in the path,
+// writing an error response when they aren't a member.
+func (h *Handler) membership(w http.ResponseWriter, r *http.Request) (*Member, bool) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return nil, false
+	}
+	m, err := h.store.Member(r.PathValue("id"), userID)
+	if err != nil {
+		h.storeError(w, err, "load membership")
+		return nil, false
+	}
+	return m, true
+}
+
+func (h *Handler) view(m Member) MemberView {
+	p, err := h.profiles.GetProfile(m.UserID)
+	if err != nil {
+		p = nil
+	}
+	return memberView(m, p)
+}
+
+// memberView shows m with what others see of their profile p, if known.
+func memberView(m Member, p *onboarding.ProfileSnapshot) MemberView {
+	v := MemberView{Member: m}
+	if p != nil {
+		v.DisplayName = p.DisplayName
+		v.VerifiedAt = p.VerifiedAt
+	}
+	return v
+}
+
+// broadcast sends ev to every current member, plus any extra users (such
+// as someone who was just removed).
+func (h *Handler) broadcast(ev Event, extra ...string) {
+	members, err := h.store.Members(ev.RoomID)
+	if err != nil {
+		h.logger.Printf("rooms: list members for event: %v", err)
+		return
+	}
+	// Messages skip members who blocked the sender or were blocked by them.
+	var skip map[string]struct{}
+	if ev.Message != nil {
+		if skip, err = h.blocks.ExcludedUserIDs(ev.Message.SenderID); err != nil {
+			h.logger.Printf("rooms: load blocks for event: %v", err)
+			return
+		}
+	}
+	ids := make([]string, 0, len(members)+len(extra))
+	for _, m := range members {
+		if _, ok := skip[m.UserID]; ok {
+			continue
+		}
+		ids = append(ids, m.UserID)
+	}
+	h.notifier.SendFrame(ev, append(ids, extra...)...)
+}
+
+func (h *Handler) memberEvent(roomID, change string, m Member, extra ...string) {
+	v := h.view(m)
+	h.broadcast(Event{Type: EventMember, RoomID: roomID, Member: &v, Change: change}, extra...)
+}
+
+// --- Handlers ---
+
+// Rooms handles /v1/rooms
+//
+//	GET  lists rooms, optionally for one intent (?intentId=)
+//	POST creates a room; the creator becomes its owner
+func (h *Handler) Rooms(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rooms, err := h.store.List(r.URL.Query().Get("intentId"))
+		if err != nil {
+			h.storeError(w, err, "list rooms")
+			return
+		}
+		if rooms == nil {
+			rooms = []Room{}
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"rooms": rooms})
+
+	case http.MethodPost:
+		var req createRoomRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		room, err := validateRoom(req)
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		intent, err := intents.Resolve(h.intents, room.IntentID)
+		if errors.Is(err, intents.ErrNotFound) {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("unknown intent"))
+			return
+		}
+		if err != nil {
+			h.storeError(w, err, "create room")
+			return
+		}
+		room.IntentID = intent.ID
+		room.ID = idgen.New()
+		room.CreatedBy = userID
+		room.CreatedAt = time.Now().UTC()
+
+		created, err := h.store.Create(room, userID)
+		if err != nil {
+			h.storeError(w, err, "create room")
+			return
+		}
+		owner, err := h.store.Member(created.ID, userID)
+		if err != nil {
+			h.storeError(w, err, "create room")
+			return
+		}
+		httpjson.Write(w, http.StatusCreated, roomResponse{Room: created, Membership: owner})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func validateRoom(req createRoomRequest) (Room, error) {
+	room := Room{
+		IntentID:    strings.TrimSpace(req.IntentID),
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Capacity:    req.Capacity,
+	}
+	if room.IntentID == "" {
+		return room, errors.New("intentId is required")
+	}
+	if room.Name == "" {
+		return room, errors.New("name is required")
+	}
+	if utf8.RuneCountInString(room.Name) > maxNameLength {
+		return room, errors.New("name is too long")
+	}
+	if utf8.RuneCountInString(room.Description) > maxDescriptionLength {
+		return room, errors.New("description is too long")
+	}
+	if room.Capacity == 0 {
+		room.Capacity = DefaultCapacity
+	}
+	if room.Capacity < MinCapacity || room.Capacity > MaxCapacity {
+		return room, errors.New("capacity must be between 2 and 200")
+	}
+	return room, nil
+}
+
+// Room handles GET /v1/rooms/{id}
+//
+// The response includes the caller's membership when they have one.
+func (h *Handler) Room(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	room, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.storeError(w, err, "load room")
+		return
+	}
+	resp := roomResponse{Room: room}
+	if m, err := h.store.Member(room.ID, userID); err == nil {
+		resp.Membership = m
+	} else if !errors.Is(err, ErrNotMember) {
+		h.storeError(w, err, "load room")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, resp)
+}
+
+// Join handles POST /v1/rooms/{id}/join
+//
+// Joining is idempotent. A full room answers 409; banned users get 403.
+func (h *Handler) Join(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	roomID := r.PathValue("id")
+	m, created, err := h.store.Join(roomID, userID, time.Now().UTC())
+	if err != nil {
+		h.storeError(w, err, "join room")
+		return
+	}
+	if created {
+		h.memberEvent(roomID, changeJoined, *m)
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"membership": m})
+}
+
+// Leave handles POST /v1/rooms/{id}/leave
+//
+// When the owner leaves, the longest-standing moderator (or member) takes
+// over.
+func (h *Handler) Leave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	m, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	removed, newOwner, err := h.store.Leave(m.RoomID, m.UserID)
+	if err != nil {
+		h.storeError(w, err, "leave room")
+		return
+	}
+	if removed {
+		h.memberEvent(m.RoomID, changeLeft, *m, m.UserID)
+		h.announceOwner(m.RoomID, newOwner)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// announceOwner tells members about an ownership handover, if there was
+// one.
+func (h *Handler) announceOwner(roomID, newOwner string) {
+	if newOwner == "" {
+		return
+	}
+	owner, err := h.store.Member(roomID, newOwner)
+	if err != nil {
+		h.logger.Printf("rooms: load new owner: %v", err)
+		return
+	}
+	h.memberEvent(roomID, changeNewOwner, *owner)
+}
+
+// Members handles GET /v1/rooms/{id}/members
+//
+// Only members can see who else is in a room. Snoozed members stay in the
+// room but are left off the list until they wake up.
+func (h *Handler) Members(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	m, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	members, err := h.store.Members(m.RoomID)
+	if err != nil {
+		h.storeError(w, err, "list members")
+		return
+	}
+	now := time.Now()
+	out := make([]MemberView, 0, len(members))
+	for _, member := range members {
+		p, err := h.profiles.GetProfile(member.UserID)
+		if err != nil {
+			p = nil
+		}
+		if p != nil && p.Snoozed(now) && member.UserID != m.UserID {
+			continue
+		}
+		out = append(out, memberView(member, p))
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"members": out})
+}
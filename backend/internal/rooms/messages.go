@@ -0,0 +1,213 @@
+package rooms
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// maxTextLength caps a single room message, in characters.
+const maxTextLength = 2000
+
+// maxClientIDLength bounds client-generated message IDs.
+const maxClientIDLength = 64
+
+// History page sizes.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+type sendMessageRequest struct {
+	ClientID string `json:"clientId"`
+	Text     string `json:"text"`
+}
+
+// Messages handles /v1/rooms/{id}/messages
+//
+//	GET  returns history newest first; pass nextCursor as ?before= for
+//	     older messages
+//	POST sends {"clientId":"...","text":"..."}; 201 when stored, 200 for
+//	     a retry of the same clientId
+//
+// Only members may read or post, and muted members may only read. New
+// messages reach every member as room.message events on /v1/ws.
+func (h *Handler) Messages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listMessages(w, r)
+	case http.MethodPost:
+		h.sendMessage(w, r)
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (h *Handler) listMessages(w http.ResponseWriter, r *http.Request) {
+	limit := defaultHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+	m, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+
+	msgs, more, err := h.store.Messages(m.RoomID, r.URL.Query().Get("before"), limit)
+	if errors.Is(err, ErrMessageNotFound) {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("invalid cursor"))
+		return
+	}
+	if err != nil {
+		h.storeError(w, err, "load messages")
+		return
+	}
+	var next string
+	if more && len(msgs) > 0 {
+		next = msgs[len(msgs)-1].ID
+	}
+	// Filtering after paging keeps the cursor stable; a page may come back
+	// short when it held messages from blocked people.
+	blocked, err := h.blocks.ExcludedUserIDs(m.UserID)
+	if err != nil {
+		h.storeError(w, err, "load messages")
+		return
+	}
+	visible := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if _, skip := blocked[msg.SenderID]; !skip {
+			visible = append(visible, msg)
+		}
+	}
+	msgs = visible
+	httpjson.Write(w, http.StatusOK, map[string]any{
+		"messages":   msgs,
+		"nextCursor": next,
+	})
+}
+
+func (h *Handler) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var req sendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	switch {
+	case req.ClientID == "":
+		httpjson.Error(w, http.StatusBadRequest, errors.New("clientId is required"))
+		return
+	case len(req.ClientID) > maxClientIDLength:
+		httpjson.Error(w, http.StatusBadRequest, errors.New("clientId is too long"))
+		return
+	case text == "":
+		httpjson.Error(w, http.StatusBadRequest, errors.New("text is required"))
+		return
+	case utf8.RuneCountInString(text) > maxTextLength:
+		httpjson.Error(w, http.StatusBadRequest, errors.New("message is too long"))
+		return
+	}
+
+	m, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	if m.Muted(now) {
+		httpjson.Error(w, http.StatusForbidden, errors.New("you are muted in this room until "+m.MutedUntil.Format(time.RFC3339)))
+		return
+	}
+
+	msg := Message{
+		ID:       idgen.New(),
+		RoomID:   m.RoomID,
+		SenderID: m.UserID,
+		ClientID: req.ClientID,
+		Text:     text,
+		SentAt:   now,
+	}
+	verdict := h.screener.Check(r.Context(), screeningContent(&msg))
+	switch verdict.Action {
+	case screening.ActionBlock:
+		httpjson.Error(w, http.StatusUnprocessableEntity, verdict.Err())
+		return
+	case screening.ActionSoftHide:
+		msg.HiddenReason = verdict.Label()
+	}
+
+	stored, created, err := h.store.AppendMessage(msg)
+	if err != nil {
+		h.storeError(w, err, "send message")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		h.screener.Review(screeningContent(stored), verdict)
+		h.broadcast(Event{Type: EventMessage, RoomID: m.RoomID, Message: stored})
+	}
+	httpjson.Write(w, status, map[string]any{"message": stored})
+}
+
+// screeningContent describes a room message to the screener.
+func screeningContent(msg *Message) screening.Content {
+	return screening.Content{
+		Kind:      screening.KindRoomMessage,
+		AuthorID:  msg.SenderID,
+		ContextID: msg.RoomID,
+		ID:        msg.ID,
+		Text:      msg.Text,
+	}
+}
+
+// MessageItem handles DELETE /v1/rooms/{id}/messages/{messageId}
+//
+// Senders can delete their own messages; moderators can delete messages
+// from anyone they outrank, or from people who have left.
+func (h *Handler) MessageItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	m, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	msg, err := h.store.GetMessage(m.RoomID, r.PathValue("messageId"))
+	if err != nil {
+		h.storeError(w, err, "load message")
+		return
+	}
+	if msg.SenderID != m.UserID {
+		allowed, err := h.outranks(m, msg.SenderID)
+		if err != nil {
+			h.storeError(w, err, "delete message")
+			return
+		}
+		if !allowed {
+			httpjson.Error(w, http.StatusForbidden, errors.New("you can't delete this message"))
+			return
+		}
+	}
+
+	deleted, err := h.store.DeleteMessage(m.RoomID, msg.ID, m.UserID, time.Now().UTC())
+	if err != nil {
+		h.storeError(w, err, "delete message")
+		return
+	}
+	h.broadcast(Event{Type: EventMessageDeleted, RoomID: m.RoomID, Message: deleted})
+	httpjson.Write(w, http.StatusOK, map[string]any{"message": deleted})
+}
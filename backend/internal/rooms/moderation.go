@@ -0,0 +1,199 @@
+package rooms
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// Mute durations, in minutes.
+const (
+	defaultMuteMinutes = 60
+	maxMuteMinutes     = 7 * 24 * 60
+)
+
+// Moderation actions accepted by Moderate.
+const (
+	actionMute   = "mute"
+	actionUnmute = "unmute"
+	actionKick   = "kick"
+	actionBan    = "ban"
+)
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+type moderationRequest struct {
+	Action  string `json:"action"`
+	Minutes int    `json:"minutes"`
+}
+
+// rank orders roles for moderation: people can only act on those below
+// them.
+func rank(role string) int {
+	switch role {
+	case RoleOwner:
+		return 2
+	case RoleModerator:
+		return 1
+	}
+	return 0
+}
+
+// outranks reports whether actor may moderate targetID. Former members can
+// be moderated by any moderator.
+func (h *Handler) outranks(actor *Member, targetID string) (bool, error) {
+	if !actor.CanModerate() || actor.UserID == targetID {
+		return false, nil
+	}
+	target, err := h.store.Member(actor.RoomID, targetID)
+	if errors.Is(err, ErrNotMember) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return rank(actor.Role) > rank(target.Role), nil
+}
+
+// MemberRole handles PUT /v1/rooms/{id}/members/{userId}/role
+//
+// Body: {"role":"moderator"} or {"role":"member"}. Only the owner can
+// appoint or remove moderators.
+func (h *Handler) MemberRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	actor, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Role != RoleModerator && req.Role != RoleMember {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("role must be moderator or member"))
+		return
+	}
+	if actor.Role != RoleOwner {
+		httpjson.Error(w, http.StatusForbidden, errors.New("only the room owner can change roles"))
+		return
+	}
+	targetID := r.PathValue("userId")
+	if targetID == actor.UserID {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("cannot change your own role"))
+		return
+	}
+
+	if err := h.store.SetRole(actor.RoomID, targetID, req.Role); err != nil {
+		h.storeError(w, err, "change role")
+		return
+	}
+	target, err := h.store.Member(actor.RoomID, targetID)
+	if err != nil {
+		h.storeError(w, err, "change role")
+		return
+	}
+	h.memberEvent(actor.RoomID, changeRole, *target)
+	httpjson.Write(w, http.StatusOK, map[string]any{"member": h.view(*target)})
+}
+
+// Moderate handles POST /v1/rooms/{id}/members/{userId}/moderation
+//
+// Body: {"action":"mute","minutes":60}, or action unmute, kick or ban.
+// Moderators act on members; the owner also on moderators. Kicked users
+// may rejoin, banned users may not.
+func (h *Handler) Moderate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	actor, ok := h.membership(w, r)
+	if !ok {
+		return
+	}
+	var req moderationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	switch req.Action {
+	case actionMute:
+		if req.Minutes == 0 {
+			req.Minutes = defaultMuteMinutes
+		}
+		if req.Minutes < 1 || req.Minutes > maxMuteMinutes {
+			httpjson.Error(w, http.StatusBadRequest, errors.New("minutes must be between 1 and 10080"))
+			return
+		}
+	case actionUnmute, actionKick, actionBan:
+	default:
+		httpjson.Error(w, http.StatusBadRequest, errors.New("action must be mute, unmute, kick or ban"))
+		return
+	}
+
+	targetID := r.PathValue("userId")
+	allowed, err := h.outranks(actor, targetID)
+	if err != nil {
+		h.storeError(w, err, "moderate")
+		return
+	}
+	if !allowed {
+		httpjson.Error(w, http.StatusForbidden, errors.New("you can't moderate this member"))
+		return
+	}
+	target, err := h.store.Member(actor.RoomID, targetID)
+	if errors.Is(err, ErrNotMember) && req.Action == actionBan {
+		// Banning ahead of a rejoin: the user just has to exist.
+		if _, err := h.profiles.GetProfile(targetID); err != nil {
+			httpjson.Error(w, http.StatusNotFound, errors.New("user not found"))
+			return
+		}
+	} else if err != nil {
+		h.storeError(w, err, "moderate")
+		return
+	}
+
+	now := time.Now().UTC()
+	switch req.Action {
+	case actionMute, actionUnmute:
+		var until *time.Time
+		change := changeUnmuted
+		if req.Action == actionMute {
+			t := now.Add(time.Duration(req.Minutes) * time.Minute)
+			until, change = &t, changeMuted
+		}
+		if err := h.store.Mute(actor.RoomID, targetID, until); err != nil {
+			h.storeError(w, err, "moderate")
+			return
+		}
+		target.MutedUntil = until
+		h.memberEvent(actor.RoomID, change, *target)
+		httpjson.Write(w, http.StatusOK, map[string]any{"member": h.view(*target)})
+
+	case actionKick:
+		if _, _, err := h.store.Leave(actor.RoomID, targetID); err != nil {
+			h.storeError(w, err, "moderate")
+			return
+		}
+		h.memberEvent(actor.RoomID, changeKicked, *target, targetID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case actionBan:
+		if err := h.store.Ban(actor.RoomID, targetID, actor.UserID, now); err != nil {
+			h.storeError(w, err, "moderate")
+			return
+		}
+		if target != nil {
+			h.memberEvent(actor.RoomID, changeBanned, *target, targetID)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,127 @@
+// Package rooms implements intent rooms: topic-based group spaces where
+// people who share an intent talk together.
+package rooms
+
+import (
+	"errors"
+	"time"
+)
+
+// Member roles. The owner manages moderators; moderators (and the owner)
+// can mute, kick and ban members and delete messages.
+const (
+	RoleOwner     = "owner"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+)
+
+// Capacity bounds for a room's member list.
+const (
+	DefaultCapacity = 50
+	MinCapacity     = 2
+	MaxCapacity     = 200
+)
+
+var (
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrNotMember       = errors.New("not a member of this room")
+	ErrRoomFull        = errors.New("this room is full")
+	ErrBanned          = errors.New("you can't join this room")
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// Room is a group space tied to an intent.
+type Room struct {
+	ID          string    `json:"id"`
+	IntentID    string    `json:"intentId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Capacity    int       `json:"capacity"`
+	MemberCount int       `json:"memberCount"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Member is one person's membership of a room.
+type Member struct {
+	RoomID     string     `json:"-"`
+	UserID     string     `json:"userId"`
+	Role       string     `json:"role"`
+	JoinedAt   time.Time  `json:"joinedAt"`
+	MutedUntil *time.Time `json:"mutedUntil,omitempty"`
+}
+
+// Muted reports whether the member may not post at now.
+func (m *Member) Muted(now time.Time) bool {
+	return m.MutedUntil != nil && now.Before(*m.MutedUntil)
+}
+
+// CanModerate reports whether the member holds a moderation role.
+func (m *Member) CanModerate() bool {
+	return m.Role == RoleOwner || m.Role == RoleModerator
+}
+
+// Message is one post in a room.
+type Message struct {
+	ID        string     `json:"id"`
+	Seq       int64      `json:"-"`
+	RoomID    string     `json:"roomId"`
+	SenderID  string     `json:"senderId"`
+	ClientID  string     `json:"clientId,omitempty"`
+	Text      string     `json:"text"`
+	SentAt    time.Time  `json:"sentAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy string     `json:"-"`
+	// HiddenReason is set when screening soft-hid the message; clients
+	// show it collapsed until tapped.
+	HiddenReason string `json:"hiddenReason,omitempty"`
+}
+
+// Store persists rooms, their members and messages.
+type Store interface {
+	// Create stores a room with owner as its first member.
+	Create(room Room, owner string) (*Room, error)
+	// Get returns a room with its current member count, or
+	// ErrRoomNotFound.
+	Get(id string) (*Room, error)
+	// List returns rooms for an intent, or every room when intentID is
+	// empty, oldest first.
+	List(intentID string) ([]Room, error)
+	// Join adds userID as a member. Joining twice returns the existing
+	// membership with created=false. ErrRoomFull once the room reaches
+	// capacity and ErrBanned for banned users; the capacity check and
+	// insert are atomic.
+	Join(roomID, userID string, now time.Time) (m *Member, created bool, err error)
+	// Leave removes userID. When the owner leaves, ownership passes to the
+	// longest-standing moderator, or else the longest-standing member;
+	// newOwner names them. removed is false if userID wasn't a member.
+	Leave(roomID, userID string) (removed bool, newOwner string, err error)
+	// Ban removes userID and stops them from joining again.
+	Ban(roomID, userID, bannedBy string, at time.Time) error
+	// Member returns userID's membership, or ErrNotMember.
+	Member(roomID, userID string) (*Member, error)
+	// Members lists a room's members in join order.
+	Members(roomID string) ([]Member, error)
+	// SetRole changes a member's role.
+	SetRole(roomID, userID, role string) error
+	// Mute silences a member until the given time; nil unmutes.
+	Mute(roomID, userID string, until *time.Time) error
+
+	// AppendMessage stores a message. A retry with the same sender and
+	// client ID returns the original with created=false.
+	AppendMessage(msg Message) (stored *Message, created bool, err error)
+	// Messages returns up to limit messages, newest first, older than the
+	// before message when set. more reports whether older ones remain.
+	Messages(roomID, before string, limit int) (msgs []Message, more bool, err error)
+	// GetMessage returns one message, or ErrMessageNotFound.
+	GetMessage(roomID, messageID string) (*Message, error)
+	// DeleteMessage clears a message's text, leaving a tombstone.
+	DeleteMessage(roomID, messageID, deletedBy string, at time.Time) (*Message, error)
+	// SentMessages lists the messages userID posted in any room, newest
+	// first.
+	SentMessages(userID string) ([]Message, error)
+
+	// DeleteUser removes userID from every room, handing ownership on as
+	// Leave does, and deletes their messages and bans.
+	DeleteUser(userID string) error
+}
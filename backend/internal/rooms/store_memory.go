@@ -0,0 +1,329 @@
+package rooms
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryRoom struct {
+	room     Room
+	members  []*Member
+	banned   map[string]struct{}
+	messages []*Message
+	byClient map[[2]string]*Message
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	seq   int64
+	rooms map[string]*memoryRoom
+	order []string
+}
+
+// NewInMemoryStore returns a room Store for single-instance deployments.
+func NewInMemoryStore() Store {
+	return &memoryStore{rooms: make(map[string]*memoryRoom)}
+}
+
+func (r *memoryRoom) view() *Room {
+	out := r.room
+	out.MemberCount = len(r.members)
+	return &out
+}
+
+func (r *memoryRoom) indexOf(userID string) int {
+	return slices.IndexFunc(r.members, func(m *Member) bool { return m.UserID == userID })
+}
+
+func copyMember(m *Member) *Member {
+	out := *m
+	if m.MutedUntil != nil {
+		t := *m.MutedUntil
+		out.MutedUntil = &t
+	}
+	return &out
+}
+
+func (s *memoryStore) room(id string) (*memoryRoom, error) {
+	r, ok := s.rooms[id]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return r, nil
+}
+
+func (s *memoryStore) Create(room Room, owner string) (*Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := &memoryRoom{
+		room:     room,
+		banned:   make(map[string]struct{}),
+		byClient: make(map[[2]string]*Message),
+	}
+	r.members = []*Member{{RoomID: room.ID, UserID: owner, Role: RoleOwner, JoinedAt: room.CreatedAt}}
+	s.rooms[room.ID] = r
+	s.order = append(s.order, room.ID)
+	return r.view(), nil
+}
+
+func (s *memoryStore) Get(id string) (*Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.view(), nil
+}
+
+func (s *memoryStore) List(intentID string) ([]Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Room
+	for _, id := range s.order {
+		r := s.rooms[id]
+		if intentID == "" || r.room.IntentID == intentID {
+			out = append(out, *r.view())
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Join(roomID, userID string, now time.Time) (*Member, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return nil, false, err
+	}
+	if i := r.indexOf(userID); i >= 0 {
+		return copyMember(r.members[i]), false, nil
+	}
+	if _, ok := r.banned[userID]; ok {
+		return nil, false, ErrBanned
+	}
+	if len(r.members) >= r.room.Capacity {
+		return nil, false, ErrRoomFull
+	}
+	m := &Member{RoomID: roomID, UserID: userID, Role: RoleMember, JoinedAt: now}
+	r.members = append(r.members, m)
+	return copyMember(m), true, nil
+}
+
+// removeLocked drops a member and hands ownership on if needed.
+func (r *memoryRoom) removeLocked(userID string) (bool, string) {
+	i := r.indexOf(userID)
+	if i < 0 {
+		return false, ""
+	}
+	wasOwner := r.members[i].Role == RoleOwner
+	r.members = slices.Delete(r.members, i, i+1)
+	if !wasOwner || len(r.members) == 0 {
+		return true, ""
+	}
+	next := slices.IndexFunc(r.members, func(m *Member) bool { return m.Role == RoleModerator })
+	if next < 0 {
+		next = 0
+	}
+	r.members[next].Role = RoleOwner
+	return true, r.members[next].UserID
+}
+
+func (s *memoryStore) Leave(roomID, userID string) (bool, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return false, "", err
+	}
+	removed, newOwner := r.removeLocked(userID)
+	return removed, newOwner, nil
+}
+
+func (s *memoryStore) Ban(roomID, userID, bannedBy string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return err
+	}
+	r.removeLocked(userID)
+	r.banned[userID] = struct{}{}
+	return nil
+}
+
+func (s *memoryStore) Member(roomID, userID string) (*Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return nil, err
+	}
+	i := r.indexOf(userID)
+	if i < 0 {
+		return nil, ErrNotMember
+	}
+	return copyMember(r.members[i]), nil
+}
+
+func (s *memoryStore) Members(roomID string) ([]Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Member, len(r.members))
+	for i, m := range r.members {
+		out[i] = *copyMember(m)
+	}
+	return out, nil
+}
+
+func (s *memoryStore) SetRole(roomID, userID, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return err
+	}
+	i := r.indexOf(userID)
+	if i < 0 {
+		return ErrNotMember
+	}
+	r.members[i].Role = role
+	return nil
+}
+
+func (s *memoryStore) Mute(roomID, userID string, until *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return err
+	}
+	i := r.indexOf(userID)
+	if i < 0 {
+		return ErrNotMember
+	}
+	r.members[i].MutedUntil = until
+	return nil
+}
+
+func (s *memoryStore) AppendMessage(msg Message) (*Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(msg.RoomID)
+	if err != nil {
+		return nil, false, err
+	}
+	key := [2]string{msg.SenderID, msg.ClientID}
+	if existing, ok := r.byClient[key]; ok && msg.ClientID != "" {
+		out := *existing
+		return &out, false, nil
+	}
+	s.seq++
+	stored := msg
+	stored.Seq = s.seq
+	r.messages = append(r.messages, &stored)
+	if msg.ClientID != "" {
+		r.byClient[key] = &stored
+	}
+	out := stored
+	return &out, true, nil
+}
+
+func (s *memoryStore) Messages(roomID, before string, limit int) ([]Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.room(roomID)
+	if err != nil {
+		return nil, false, err
+	}
+	end := len(r.messages)
+	if before != "" {
+		end = slices.IndexFunc(r.messages, func(m *Message) bool { return m.ID == before })
+		if end < 0 {
+			return nil, false, ErrMessageNotFound
+		}
+	}
+	var out []Message
+	for i := end - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, *r.messages[i])
+	}
+	return out, end-len(out) > 0, nil
+}
+
+func (s *memoryStore) find(roomID, messageID string) (*Message, error) {
+	r, err := s.room(roomID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(r.messages, func(m *Message) bool { return m.ID == messageID })
+	if i < 0 {
+		return nil, ErrMessageNotFound
+	}
+	return r.messages[i], nil
+}
+
+func (s *memoryStore) GetMessage(roomID, messageID string) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(roomID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	out := *m
+	return &out, nil
+}
+
+func (s *memoryStore) DeleteMessage(roomID, messageID, deletedBy string, at time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.find(roomID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if m.DeletedAt == nil {
+		m.Text = ""
+		m.DeletedAt = &at
+		m.DeletedBy = deletedBy
+	}
+	out := *m
+	return &out, nil
+}
+
+func (s *memoryStore) SentMessages(userID string) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Message
+	for _, r := range s.rooms {
+		for _, m := range r.messages {
+			if m.SenderID == userID {
+				out = append(out, *m)
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b Message) int { return b.SentAt.Compare(a.SentAt) })
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.rooms {
+		r.removeLocked(userID)
+		delete(r.banned, userID)
+		r.messages = slices.DeleteFunc(r.messages, func(m *Message) bool { return m.SenderID == userID })
+		for key := range r.byClient {
+			if key[0] == userID {
+				delete(r.byClient, key)
+			}
+		}
+		if r.room.CreatedBy == userID {
+			r.room.CreatedBy = ""
+		}
+	}
+	return nil
+}
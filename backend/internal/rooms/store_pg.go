@@ -0,0 +1,467 @@
+package rooms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// pgStore persists rooms in rooms, room_members, room_bans and
+// room_messages (sql/0014_rooms.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a room Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const roomColumns = `r.id, r.intent_id, r.name, r.description, r.capacity, COALESCE(r.created_by, ''), r.created_at,
+	(SELECT count(*) FROM room_members m WHERE m.room_id = r.id)`
+
+func scanRoom(row interface{ Scan(...any) error }) (*Room, error) {
+	var r Room
+	if err := row.Scan(&r.ID, &r.IntentID, &r.Name, &r.Description, &r.Capacity,
+		&r.CreatedBy, &r.CreatedAt, &r.MemberCount); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+const memberColumns = `room_id, user_id, role, joined_at, muted_until`
+
+func scanMember(row interface{ Scan(...any) error }) (*Member, error) {
+	var (
+		m          Member
+		mutedUntil sql.NullTime
+	)
+	if err := row.Scan(&m.RoomID, &m.UserID, &m.Role, &m.JoinedAt, &mutedUntil); err != nil {
+		return nil, err
+	}
+	if mutedUntil.Valid {
+		t := mutedUntil.Time
+		m.MutedUntil = &t
+	}
+	return &m, nil
+}
+
+const messageColumns = `id, seq, room_id, sender_id, client_id, body, sent_at, deleted_at, COALESCE(deleted_by, ''), hidden_reason`
+
+func scanMessage(row interface{ Scan(...any) error }) (*Message, error) {
+	var (
+		m         Message
+		deletedAt sql.NullTime
+	)
+	if err := row.Scan(&m.ID, &m.Seq, &m.RoomID, &m.SenderID, &m.ClientID, &m.Text,
+		&m.SentAt, &deletedAt, &m.DeletedBy, &m.HiddenReason); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		t := deletedAt.Time
+		m.DeletedAt = &t
+	}
+	return &m, nil
+}
+
+func (s *pgStore) Create(room Room, owner string) (*Room, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO rooms (id, intent_id, name, description, capacity, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, room.ID, room.IntentID, room.Name, room.Description, room.Capacity, owner, room.CreatedAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO room_members (room_id, user_id, role, joined_at) VALUES ($1, $2, 'owner', $3)
+	`, room.ID, owner, room.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	room.CreatedBy = owner
+	room.MemberCount = 1
+	return &room, nil
+}
+
+func (s *pgStore) Get(id string) (*Room, error) {
+	r, err := scanRoom(s.db.QueryRowContext(context.Background(),
+		`SELECT `+roomColumns+` FROM rooms r WHERE r.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRoomNotFound
+	}
+	return r, err
+}
+
+func (s *pgStore) List(intentID string) ([]Room, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+roomColumns+` FROM rooms r
+		WHERE $1 = '' OR r.intent_id = $1
+		ORDER BY r.created_at, r.id
+	`, intentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Room
+	for rows.Next() {
+		r, err := scanRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// lockRoom locks the room row for the rest of the transaction, serialising
+// membership changes, and returns its capacity.
+func lockRoom(ctx context.Context, tx *sql.Tx, roomID string) (int, error) {
+	var capacity int
+	err := tx.QueryRowContext(ctx, `SELECT capacity FROM rooms WHERE id = $1 FOR UPDATE`, roomID).Scan(&capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrRoomNotFound
+	}
+	return capacity, err
+}
+
+func (s *pgStore) Join(roomID, userID string, now time.Time) (*Member, bool, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	capacity, err := lockRoom(ctx, tx, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+	m, err := scanMember(tx.QueryRowContext(ctx,
+		`SELECT `+memberColumns+` FROM room_members WHERE room_id = $1 AND user_id = $2`, roomID, userID))
+	if err == nil {
+		return m, false, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	var banned, count int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM room_bans WHERE room_id = $1 AND user_id = $2),
+		       (SELECT count(*) FROM room_members WHERE room_id = $1)
+	`, roomID, userID).Scan(&banned, &count); err != nil {
+		return nil, false, err
+	}
+	if banned > 0 {
+		return nil, false, ErrBanned
+	}
+	if count >= capacity {
+		return nil, false, ErrRoomFull
+	}
+
+	m, err = scanMember(tx.QueryRowContext(ctx, `
+		INSERT INTO room_members (room_id, user_id, role, joined_at) VALUES ($1, $2, 'member', $3)
+		RETURNING `+memberColumns, roomID, userID, now))
+	if err != nil {
+		return nil, false, err
+	}
+	return m, true, tx.Commit()
+}
+
+// removeMember deletes a membership inside a transaction holding the room
+// lock, promoting a new owner if the owner left.
+func removeMember(ctx context.Context, tx *sql.Tx, roomID, userID string) (bool, string, error) {
+	var role string
+	err := tx.QueryRowContext(ctx, `
+		DELETE FROM room_members WHERE room_id = $1 AND user_id = $2 RETURNING role
+	`, roomID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if role != RoleOwner {
+		return true, "", nil
+	}
+
+	var next string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE room_members SET role = 'owner'
+		WHERE room_id = $1 AND user_id = (
+			SELECT user_id FROM room_members WHERE room_id = $1
+			ORDER BY role = 'moderator' DESC, joined_at, user_id
+			LIMIT 1
+		)
+		RETURNING user_id
+	`, roomID).Scan(&next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, "", nil
+	}
+	return true, next, err
+}
+
+func (s *pgStore) Leave(roomID, userID string) (bool, string, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockRoom(ctx, tx, roomID); err != nil {
+		return false, "", err
+	}
+	removed, newOwner, err := removeMember(ctx, tx, roomID, userID)
+	if err != nil {
+		return false, "", err
+	}
+	return removed, newOwner, tx.Commit()
+}
+
+func (s *pgStore) Ban(roomID, userID, bannedBy string, at time.Time) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockRoom(ctx, tx, roomID); err != nil {
+		return err
+	}
+	if _, _, err := removeMember(ctx, tx, roomID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO room_bans (room_id, user_id, banned_by, banned_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (room_id, user_id) DO NOTHING
+	`, roomID, userID, bannedBy, at); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *pgStore) Member(roomID, userID string) (*Member, error) {
+	m, err := scanMember(s.db.QueryRowContext(context.Background(),
+		`SELECT `+memberColumns+` FROM room_members WHERE room_id = $1 AND user_id = $2`, roomID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := s.Get(roomID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotMember
+	}
+	return m, err
+}
+
+func (s *pgStore) Members(roomID string) ([]Member, error) {
+	if _, err := s.Get(roomID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+memberColumns+` FROM room_members WHERE room_id = $1 ORDER BY joined_at, user_id
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+// updateMember runs an UPDATE on one membership, mapping "no row" to
+// ErrNotMember.
+func (s *pgStore) updateMember(query string, args ...any) error {
+	res, err := s.db.ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+func (s *pgStore) SetRole(roomID, userID, role string) error {
+	return s.updateMember(`UPDATE room_members SET role = $3 WHERE room_id = $1 AND user_id = $2`, roomID, userID, role)
+}
+
+func (s *pgStore) Mute(roomID, userID string, until *time.Time) error {
+	return s.updateMember(`UPDATE room_members SET muted_until = $3 WHERE room_id = $1 AND user_id = $2`, roomID, userID, until)
+}
+
+func (s *pgStore) AppendMessage(msg Message) (*Message, bool, error) {
+	ctx := context.Background()
+	m, err := scanMessage(s.db.QueryRowContext(ctx, `
+		INSERT INTO room_messages (id, room_id, sender_id, client_id, body, sent_at, hidden_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (room_id, sender_id, client_id) DO NOTHING
+		RETURNING `+messageColumns, msg.ID, msg.RoomID, msg.SenderID, msg.ClientID, msg.Text, msg.SentAt, msg.HiddenReason))
+	if err == nil {
+		return m, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+	m, err = scanMessage(s.db.QueryRowContext(ctx, `
+		SELECT `+messageColumns+` FROM room_messages WHERE room_id = $1 AND sender_id = $2 AND client_id = $3
+	`, msg.RoomID, msg.SenderID, msg.ClientID))
+	if err != nil {
+		return nil, false, err
+	}
+	return m, false, nil
+}
+
+func (s *pgStore) Messages(roomID, before string, limit int) ([]Message, bool, error) {
+	ctx := context.Background()
+
+	// Sequence numbers only grow, so the cursor stays stable while new
+	// messages arrive.
+	var beforeSeq int64 = 1<<63 - 1
+	if before != "" {
+		err := s.db.QueryRowContext(ctx, `SELECT seq FROM room_messages WHERE room_id = $1 AND id = $2`,
+			roomID, before).Scan(&beforeSeq)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, ErrMessageNotFound
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+messageColumns+` FROM room_messages
+		WHERE room_id = $1 AND seq < $2
+		ORDER BY seq DESC
+		LIMIT $3
+	`, roomID, beforeSeq, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(out) > limit
+	if more {
+		out = out[:limit]
+	}
+	return out, more, nil
+}
+
+func (s *pgStore) GetMessage(roomID, messageID string) (*Message, error) {
+	m, err := scanMessage(s.db.QueryRowContext(context.Background(),
+		`SELECT `+messageColumns+` FROM room_messages WHERE room_id = $1 AND id = $2`, roomID, messageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	return m, err
+}
+
+func (s *pgStore) DeleteMessage(roomID, messageID, deletedBy string, at time.Time) (*Message, error) {
+	ctx := context.Background()
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE room_messages SET body = '', deleted_at = $3, deleted_by = $4
+		WHERE room_id = $1 AND id = $2 AND deleted_at IS NULL
+	`, roomID, messageID, at, deletedBy)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetMessage(roomID, messageID)
+}
+
+func (s *pgStore) SentMessages(userID string) ([]Message, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+messageColumns+` FROM room_messages
+		WHERE sender_id = $1
+		ORDER BY sent_at DESC, seq DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+// DeleteUser leaves each room under its lock, in ID order so two deletions
+// can't deadlock, before deleting the rest outright.
+func (s *pgStore) DeleteUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT room_id FROM room_members WHERE user_id = $1 ORDER BY room_id
+	`, userID)
+	if err != nil {
+		return err
+	}
+	var roomIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		roomIDs = append(roomIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, roomID := range roomIDs {
+		if _, err := lockRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+		if _, _, err := removeMember(ctx, tx, roomID, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range []string{
+		`DELETE FROM room_messages WHERE sender_id = $1`,
+		`DELETE FROM room_bans WHERE user_id = $1`,
+		`UPDATE room_bans SET banned_by = NULL WHERE banned_by = $1`,
+		`UPDATE rooms SET created_by = NULL WHERE created_by = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
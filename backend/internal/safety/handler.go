@@ -0,0 +1,231 @@
+package safety
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/blinddate"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/rooms"
+)
+
+// Handler exposes blocks and reports over HTTP.
+type Handler struct {
+	logger   *log.Logger
+	store    Store
+	reports  ReportStore
+	profiles onboarding.Store
+	matches  matches.Store
+	messages chat.MessageStore
+	rooms    rooms.Store
+	sessions *blinddate.Sessions
+}
+
+func NewHandler(logger *log.Logger, store Store, reports ReportStore, profiles onboarding.Store,
+	matchStore matches.Store, messages chat.MessageStore, roomStore rooms.Store, sessions *blinddate.Sessions) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{
+		logger:   logger,
+		store:    store,
+		reports:  reports,
+		profiles: profiles,
+		matches:  matchStore,
+		messages: messages,
+		rooms:    roomStore,
+		sessions: sessions,
+	}
+}
+
+// --- Request payloads ---
+
+type blockRequest struct {
+	UserID string `json:"userId"`
+	// BlindDateSessionID blocks the anonymous partner of a blind date
+	// instead of a known user.
+	BlindDateSessionID string `json:"blindDateSessionId"`
+	// Source is where the block was made: profile (default), chat or room.
+	Source string `json:"source"`
+}
+
+// BlockView is a block as its author sees it. Partners blocked from a
+// blind date stay anonymous.
+type BlockView struct {
+	Block
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// --- Helpers ---
+
+// checkTarget validates that a block or report target is someone else who
+// exists.
+func (h *Handler) checkTarget(userID, targetID string) (int, error) {
+	if targetID == "" {
+		return http.StatusBadRequest, errors.New("userId is required")
+	}
+	if targetID == userID {
+		return http.StatusBadRequest, errors.New("cannot target yourself")
+	}
+	if _, err := h.profiles.GetProfile(targetID); err != nil {
+		if errors.Is(err, onboarding.ErrProfileNotFound) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		h.logger.Printf("safety checkTarget profile error: %v", err)
+		return http.StatusInternalServerError, errors.New("failed to load user")
+	}
+	return 0, nil
+}
+
+// sessionPartner resolves the anonymous partner in a blind date session.
+func (h *Handler) sessionPartner(sessionID, userID string) (string, int, error) {
+	partnerID, err := h.sessions.Partner(sessionID, userID)
+	if errors.Is(err, blinddate.ErrSessionNotFound) {
+		return "", http.StatusNotFound, err
+	}
+	if err != nil {
+		h.logger.Printf("safety session partner error: %v", err)
+		return "", http.StatusInternalServerError, errors.New("failed to load blind date session")
+	}
+	return partnerID, 0, nil
+}
+
+// block records the block and cuts every open line between the two: an
+// active match is ended, and a running blind date is torn down. Those steps
+// are best effort once the block itself is stored, since the block alone
+// already hides the pair from each other.
+func (h *Handler) block(userID, targetID, source, sessionID string) (*Block, bool, error) {
+	b, created, err := h.store.Block(userID, targetID, source)
+	if err != nil {
+		return nil, false, err
+	}
+	m, err := h.matches.Find(userID, targetID)
+	switch {
+	case err == nil && m.State == matches.StateActive:
+		if _, err := h.matches.Unmatch(m.ID, userID); err != nil {
+			h.logger.Printf("safety block unmatch error: %v", err)
+		}
+	case err != nil && !errors.Is(err, matches.ErrNotFound):
+		h.logger.Printf("safety block match lookup error: %v", err)
+	}
+	if sessionID != "" {
+		if err := h.sessions.End(sessionID, userID); err != nil && !errors.Is(err, blinddate.ErrSessionNotFound) {
+			h.logger.Printf("safety block end session error: %v", err)
+		}
+	}
+	return b, created, nil
+}
+
+// view renders b for its author.
+func (h *Handler) view(b Block) BlockView {
+	v := BlockView{Block: b}
+	if b.Source == SourceBlindDate {
+		return v
+	}
+	if p, err := h.profiles.GetProfile(b.BlockedID); err == nil {
+		v.DisplayName = p.DisplayName
+	}
+	return v
+}
+
+// --- Handlers ---
+
+// Blocks handles /v1/blocks
+//
+//	GET  lists the people the caller blocked, newest first
+//	POST blocks someone, by userId or by blindDateSessionId
+func (h *Handler) Blocks(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		blocks, err := h.store.ListBlocks(userID)
+		if err != nil {
+			h.logger.Printf("List blocks error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load blocks"))
+			return
+		}
+		out := make([]BlockView, 0, len(blocks))
+		for _, b := range blocks {
+			out = append(out, h.view(b))
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"blocks": out})
+
+	case http.MethodPost:
+		var req blockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		targetID, source := strings.TrimSpace(req.UserID), req.Source
+		switch {
+		case req.BlindDateSessionID != "":
+			if targetID != "" {
+				httpjson.Error(w, http.StatusBadRequest, errors.New("give either userId or blindDateSessionId"))
+				return
+			}
+			partnerID, status, err := h.sessionPartner(req.BlindDateSessionID, userID)
+			if err != nil {
+				httpjson.Error(w, status, err)
+				return
+			}
+			targetID, source = partnerID, SourceBlindDate
+		case source == "":
+			source = SourceProfile
+		case source != SourceProfile && source != SourceChat && source != SourceRoom:
+			httpjson.Error(w, http.StatusBadRequest, errors.New("source must be profile, chat or room"))
+			return
+		}
+		if status, err := h.checkTarget(userID, targetID); err != nil {
+			httpjson.Error(w, status, err)
+			return
+		}
+
+		b, created, err := h.block(userID, targetID, source, req.BlindDateSessionID)
+		if err != nil {
+			h.logger.Printf("Block error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to block user"))
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		httpjson.Write(w, status, map[string]any{"block": h.view(*b)})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// Unblock handles DELETE /v1/blocks/{userId}. Unblocking doesn't restore a
+// match that the block ended.
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err := h.store.Unblock(userID, r.PathValue("userId")); err != nil {
+		h.logger.Printf("Unblock error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to unblock user"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
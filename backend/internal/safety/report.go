@@ -0,0 +1,110 @@
+package safety
+
+import (
+	"errors"
+	"time"
+)
+
+// Report categories.
+const (
+	CategorySpam                 = "spam"
+	CategoryFakeProfile          = "fake_profile"
+	CategoryInappropriateContent = "inappropriate_content"
+	CategoryHarassment           = "harassment"
+	CategoryHateSpeech           = "hate_speech"
+	CategoryScam                 = "scam"
+	CategoryUnderage             = "underage"
+	CategorySafetyConcern        = "safety_concern"
+	CategoryOther                = "other"
+	// CategoryVerification cases are selfies waiting for manual review.
+	// They are filed by verification, never by users.
+	CategoryVerification = "verification"
+)
+
+// Moderation priorities; the queue serves higher ones first.
+const (
+	PriorityLow    = 0
+	PriorityNormal = 1
+	PriorityHigh   = 2
+)
+
+// categoryPriority ranks categories by how urgently a moderator should look.
+// Possible minors and threats to someone's safety jump the queue.
+var categoryPriority = map[string]int{
+	CategorySpam:                 PriorityLow,
+	CategoryFakeProfile:          PriorityLow,
+	CategoryOther:                PriorityLow,
+	CategoryInappropriateContent: PriorityNormal,
+	CategoryHarassment:           PriorityNormal,
+	CategoryHateSpeech:           PriorityNormal,
+	CategoryScam:                 PriorityNormal,
+	CategoryUnderage:             PriorityHigh,
+	CategorySafetyConcern:        PriorityHigh,
+}
+
+// Report statuses. Open reports wait in the moderation queue; a moderator
+// resolves each one by dismissing it or acting on it.
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusActioned  = "actioned"
+)
+
+var (
+	// ErrReportNotFound is returned for unknown report IDs.
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportResolved is returned when resolving a report twice.
+	ErrReportResolved = errors.New("report already resolved")
+)
+
+// Report is a complaint about a user, with evidence captured when it was
+// filed so later edits or deletions don't erase it.
+type Report struct {
+	ID             string `json:"id"`
+	ReporterID     string `json:"reporterId,omitempty"` // empty when filed by automated screening
+	ReportedUserID string `json:"reportedUserId"`
+	Category       string `json:"category"`
+	Details        string `json:"details,omitempty"`
+	// Context is where the report was made (one of the Source constants)
+	// and ContextID the conversation, room or blind date session.
+	Context   string            `json:"context"`
+	ContextID string            `json:"contextId,omitempty"`
+	Messages  []ReportedMessage `json:"messages"`
+	PhotoIDs  []string          `json:"photoIds"`
+	Priority  int               `json:"priority"`
+	Status    string            `json:"status"`
+	CreatedAt time.Time         `json:"createdAt"`
+	// ResolvedBy is the moderator who closed the report.
+	ResolvedBy string     `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// ReportedMessage is a snapshot of an offending message.
+type ReportedMessage struct {
+	MessageID string `json:"messageId"`
+	Kind      string `json:"kind"`
+	Text      string `json:"text,omitempty"`
+	// MediaKey locates a voice note or photo in blob storage.
+	MediaKey string    `json:"mediaKey,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// ReportStore persists reports and serves the moderation queue.
+type ReportStore interface {
+	// CreateReport stores a new report.
+	CreateReport(r Report) (*Report, error)
+	// GetReport returns one report, or ErrReportNotFound.
+	GetReport(id string) (*Report, error)
+	// Queue lists open reports for moderators, highest priority first and
+	// oldest first within a priority.
+	Queue(limit int) ([]Report, error)
+	// Resolve closes an open report with status, recording who closed it.
+	// It returns ErrReportResolved if the report was already closed.
+	Resolve(id, status, resolvedBy string, at time.Time) (*Report, error)
+	// ReportsAgainst lists reports about userID in any status, newest first.
+	ReportsAgainst(userID string, limit int) ([]Report, error)
+	// DeleteUser forgets userID as a reporter: reports they filed stay in
+	// the queue without one. Reports about them are kept as they are, so
+	// their moderation history survives the account's deletion.
+	DeleteUser(userID string) error
+}
@@ -0,0 +1,276 @@
+package safety
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/blinddate"
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/rooms"
+)
+
+const (
+	maxDetailsLength  = 2000
+	maxReportMessages = 20
+	maxReportPhotos   = 10
+	maxPhotoIDLength  = 128
+)
+
+type reportRequest struct {
+	// ReportedUserID is omitted for blind dates, where the partner is
+	// resolved from the session.
+	ReportedUserID string   `json:"reportedUserId"`
+	Category       string   `json:"category"`
+	Details        string   `json:"details"`
+	Context        string   `json:"context"`
+	ContextID      string   `json:"contextId"`
+	MessageIDs     []string `json:"messageIds"`
+	PhotoIDs       []string `json:"photoIds"`
+	// Block also blocks the reported user.
+	Block bool `json:"block"`
+}
+
+// reportReceipt is what the reporter gets back. It never echoes who was
+// reported, which matters for blind dates.
+type reportReceipt struct {
+	ID        string    `json:"id"`
+	Category  string    `json:"category"`
+	Status    string    `json:"status"`
+	Blocked   bool      `json:"blocked"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// requestError carries the HTTP status for a rejected report.
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+func badRequest(msg string) error {
+	return &requestError{status: http.StatusBadRequest, err: errors.New(msg)}
+}
+
+func notFound(err error) error {
+	return &requestError{status: http.StatusNotFound, err: err}
+}
+
+// validateReport normalises the free-form parts of a report request.
+func validateReport(req *reportRequest) error {
+	if _, ok := categoryPriority[req.Category]; !ok {
+		return badRequest("unknown category")
+	}
+	req.Details = strings.TrimSpace(req.Details)
+	if utf8.RuneCountInString(req.Details) > maxDetailsLength {
+		return badRequest("details is too long")
+	}
+	if req.Category == CategoryOther && req.Details == "" {
+		return badRequest("details is required for category other")
+	}
+	if req.Context == "" {
+		req.Context = SourceProfile
+	}
+	switch req.Context {
+	case SourceProfile:
+		if req.ContextID != "" || len(req.MessageIDs) > 0 {
+			return badRequest("profile reports take no contextId or messageIds")
+		}
+	case SourceChat, SourceRoom, SourceBlindDate:
+		if req.ContextID == "" {
+			return badRequest("contextId is required")
+		}
+	default:
+		return badRequest("context must be profile, chat, room or blind_date")
+	}
+
+	if len(req.MessageIDs) > maxReportMessages {
+		return badRequest("too many messageIds")
+	}
+	req.MessageIDs = slices.Compact(slices.Sorted(slices.Values(req.MessageIDs)))
+	if len(req.PhotoIDs) > maxReportPhotos {
+		return badRequest("too many photoIds")
+	}
+	for _, id := range req.PhotoIDs {
+		if id == "" || len(id) > maxPhotoIDLength {
+			return badRequest("invalid photoId")
+		}
+	}
+	if req.PhotoIDs == nil {
+		req.PhotoIDs = []string{}
+	}
+	return nil
+}
+
+// evidence resolves who is reported and snapshots the referenced messages.
+// Only messages the reported user sent can be attached.
+func (h *Handler) evidence(userID string, req *reportRequest) (string, []ReportedMessage, error) {
+	reportedID := strings.TrimSpace(req.ReportedUserID)
+	out := []ReportedMessage{}
+
+	switch req.Context {
+	case SourceChat:
+		m, err := h.matches.Get(req.ContextID)
+		if errors.Is(err, matches.ErrNotFound) || (err == nil && !m.Has(userID)) {
+			return "", nil, notFound(errors.New("conversation not found"))
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		other := m.Other(userID)
+		if reportedID == "" {
+			reportedID = other
+		}
+		if reportedID != other {
+			return "", nil, badRequest("reportedUserId is not in this conversation")
+		}
+		for _, id := range req.MessageIDs {
+			msg, err := h.messages.Get(m.ID, id)
+			if errors.Is(err, chat.ErrMessageNotFound) || (err == nil && msg.SenderID != reportedID) {
+				return "", nil, badRequest("message " + id + " not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			rm := ReportedMessage{MessageID: msg.ID, Kind: msg.Kind, Text: msg.Text, SentAt: msg.SentAt}
+			if msg.Attachment != nil {
+				rm.MediaKey = msg.Attachment.Key
+			}
+			out = append(out, rm)
+		}
+
+	case SourceRoom:
+		if reportedID == "" {
+			return "", nil, badRequest("reportedUserId is required")
+		}
+		if _, err := h.rooms.Get(req.ContextID); err != nil {
+			if errors.Is(err, rooms.ErrRoomNotFound) {
+				return "", nil, notFound(err)
+			}
+			return "", nil, err
+		}
+		for _, id := range req.MessageIDs {
+			msg, err := h.rooms.GetMessage(req.ContextID, id)
+			if errors.Is(err, rooms.ErrMessageNotFound) || (err == nil && msg.SenderID != reportedID) {
+				return "", nil, badRequest("message " + id + " not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			out = append(out, ReportedMessage{MessageID: msg.ID, Kind: chat.KindText, Text: msg.Text, SentAt: msg.SentAt})
+		}
+
+	case SourceBlindDate:
+		partnerID, status, err := h.sessionPartner(req.ContextID, userID)
+		if err != nil {
+			return "", nil, &requestError{status: status, err: err}
+		}
+		if reportedID != "" && reportedID != partnerID {
+			return "", nil, badRequest("reportedUserId is not in this session")
+		}
+		reportedID = partnerID
+		if len(req.MessageIDs) > 0 {
+			msgs, err := h.sessions.Messages(req.ContextID, userID)
+			if err != nil {
+				return "", nil, err
+			}
+			for _, id := range req.MessageIDs {
+				i := slices.IndexFunc(msgs, func(m blinddate.MessageView) bool { return m.ID == id && !m.Mine })
+				if i < 0 {
+					return "", nil, badRequest("message " + id + " not found")
+				}
+				out = append(out, ReportedMessage{MessageID: id, Kind: chat.KindText, Text: msgs[i].Text, SentAt: msgs[i].SentAt})
+			}
+		}
+	}
+	return reportedID, out, nil
+}
+
+// Reports handles POST /v1/reports
+//
+// A report lands in the moderation queue with a priority taken from its
+// category. With "block": true the reported user is blocked in the same
+// call.
+func (h *Handler) Reports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req reportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateReport(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	reportedID, messages, err := h.evidence(userID, &req)
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			httpjson.Error(w, reqErr.status, reqErr.err)
+			return
+		}
+		h.logger.Printf("Report evidence error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to file report"))
+		return
+	}
+	if status, err := h.checkTarget(userID, reportedID); err != nil {
+		if reportedID == "" {
+			err = errors.New("reportedUserId is required")
+		}
+		httpjson.Error(w, status, err)
+		return
+	}
+
+	report, err := h.reports.CreateReport(Report{
+		ID:             idgen.New(),
+		ReporterID:     userID,
+		ReportedUserID: reportedID,
+		Category:       req.Category,
+		Details:        req.Details,
+		Context:        req.Context,
+		ContextID:      req.ContextID,
+		Messages:       messages,
+		PhotoIDs:       req.PhotoIDs,
+		Priority:       categoryPriority[req.Category],
+		Status:         ReportStatusOpen,
+		CreatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		h.logger.Printf("Create report error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to file report"))
+		return
+	}
+
+	receipt := reportReceipt{ID: report.ID, Category: report.Category, Status: report.Status, CreatedAt: report.CreatedAt}
+	if req.Block {
+		var sessionID string
+		if req.Context == SourceBlindDate {
+			sessionID = req.ContextID
+		}
+		if _, _, err := h.block(userID, reportedID, req.Context, sessionID); err != nil {
+			// The report is filed; the client can retry the block on its own.
+			h.logger.Printf("Report block error: %v", err)
+		} else {
+			receipt.Blocked = true
+		}
+	}
+	httpjson.Write(w, http.StatusCreated, map[string]any{"report": receipt})
+}
@@ -0,0 +1,118 @@
+package safety
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryReportStore struct {
+	mu      sync.Mutex
+	reports map[string]*Report
+}
+
+// NewInMemoryReportStore returns an in-memory ReportStore.
+func NewInMemoryReportStore() ReportStore {
+	return &memoryReportStore{reports: make(map[string]*Report)}
+}
+
+func cloneReport(r *Report) *Report {
+	cp := *r
+	cp.Messages = slices.Clone(r.Messages)
+	cp.PhotoIDs = slices.Clone(r.PhotoIDs)
+	if r.ResolvedAt != nil {
+		at := *r.ResolvedAt
+		cp.ResolvedAt = &at
+	}
+	return &cp
+}
+
+func (s *memoryReportStore) CreateReport(r Report) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[r.ID] = cloneReport(&r)
+	return cloneReport(&r), nil
+}
+
+func (s *memoryReportStore) GetReport(id string) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.reports[id]
+	if !ok {
+		return nil, ErrReportNotFound
+	}
+	return cloneReport(r), nil
+}
+
+func (s *memoryReportStore) Queue(limit int) ([]Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Report
+	for _, r := range s.reports {
+		if r.Status == ReportStatusOpen {
+			out = append(out, *cloneReport(r))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority > out[j].Priority
+		}
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryReportStore) Resolve(id, status, resolvedBy string, at time.Time) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.reports[id]
+	if !ok {
+		return nil, ErrReportNotFound
+	}
+	if r.Status != ReportStatusOpen {
+		return nil, ErrReportResolved
+	}
+	r.Status = status
+	r.ResolvedBy = resolvedBy
+	r.ResolvedAt = &at
+	return cloneReport(r), nil
+}
+
+func (s *memoryReportStore) ReportsAgainst(userID string, limit int) ([]Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Report
+	for _, r := range s.reports {
+		if r.ReportedUserID == userID {
+			out = append(out, *cloneReport(r))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryReportStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.reports {
+		if r.ReporterID == userID {
+			r.ReporterID = ""
+		}
+	}
+	return nil
+}
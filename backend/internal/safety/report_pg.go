@@ -0,0 +1,132 @@
+package safety
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// pgReportStore keeps reports in the reports table (sql/0018_safety.sql, with
+// the resolution columns from sql/0019_moderation.sql). Screening reports
+// have no reporter and store NULL (sql/0020_screening.sql).
+// Evidence is stored as JSONB alongside the report.
+type pgReportStore struct {
+	db *sql.DB
+}
+
+// NewPGReportStore constructs a ReportStore backed by Postgres.
+func NewPGReportStore(db *sql.DB) ReportStore {
+	return &pgReportStore{db: db}
+}
+
+const reportColumns = `id, COALESCE(reporter_id, ''), reported_user_id, category, details, context, context_id,
+	messages, photo_ids, priority, status, created_at, resolved_by, resolved_at`
+
+func scanReport(row interface{ Scan(...any) error }) (*Report, error) {
+	var (
+		r                Report
+		messages, photos []byte
+		resolvedAt       sql.NullTime
+	)
+	if err := row.Scan(&r.ID, &r.ReporterID, &r.ReportedUserID, &r.Category, &r.Details, &r.Context,
+		&r.ContextID, &messages, &photos, &r.Priority, &r.Status, &r.CreatedAt,
+		&r.ResolvedBy, &resolvedAt); err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		r.ResolvedAt = &resolvedAt.Time
+	}
+	if err := json.Unmarshal(messages, &r.Messages); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(photos, &r.PhotoIDs); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *pgReportStore) CreateReport(r Report) (*Report, error) {
+	messages, err := json.Marshal(r.Messages)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := json.Marshal(r.PhotoIDs)
+	if err != nil {
+		return nil, err
+	}
+	return scanReport(s.db.QueryRowContext(context.Background(), `
+		INSERT INTO reports (id, reporter_id, reported_user_id, category, details, context, context_id,
+			messages, photo_ids, priority, status, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING `+reportColumns,
+		r.ID, r.ReporterID, r.ReportedUserID, r.Category, r.Details, r.Context, r.ContextID,
+		messages, photos, r.Priority, r.Status, r.CreatedAt))
+}
+
+func (s *pgReportStore) GetReport(id string) (*Report, error) {
+	r, err := scanReport(s.db.QueryRowContext(context.Background(), `
+		SELECT `+reportColumns+` FROM reports WHERE id = $1
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	return r, err
+}
+
+func (s *pgReportStore) Queue(limit int) ([]Report, error) {
+	return s.list(`
+		SELECT `+reportColumns+` FROM reports
+		WHERE status = $1
+		ORDER BY priority DESC, created_at ASC, id ASC
+		LIMIT $2
+	`, ReportStatusOpen, limit)
+}
+
+func (s *pgReportStore) ReportsAgainst(userID string, limit int) ([]Report, error) {
+	return s.list(`
+		SELECT `+reportColumns+` FROM reports
+		WHERE reported_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+}
+
+func (s *pgReportStore) Resolve(id, status, resolvedBy string, at time.Time) (*Report, error) {
+	r, err := scanReport(s.db.QueryRowContext(context.Background(), `
+		UPDATE reports SET status = $2, resolved_by = $3, resolved_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+reportColumns,
+		id, status, resolvedBy, at, ReportStatusOpen))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return r, err
+	}
+	// Either there is no such report or it was already closed.
+	if _, err := s.GetReport(id); err != nil {
+		return nil, err
+	}
+	return nil, ErrReportResolved
+}
+
+func (s *pgReportStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `UPDATE reports SET reporter_id = NULL WHERE reporter_id = $1`, userID)
+	return err
+}
+
+func (s *pgReportStore) list(query string, args ...any) ([]Report, error) {
+	rows, err := s.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Report
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
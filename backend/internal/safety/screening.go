@@ -0,0 +1,68 @@
+package safety
+
+import (
+	"strings"
+
+	"github.com/rijey/kindl/backend/internal/chat"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// screeningContexts maps screened content to the place a report about it
+// would have been made.
+var screeningContexts = map[string]string{
+	screening.KindDisplayName:      SourceProfile,
+	screening.KindSparkNote:        SourceProfile,
+	screening.KindChatMessage:      SourceChat,
+	screening.KindRoomMessage:      SourceRoom,
+	screening.KindBlindDateMessage: SourceBlindDate,
+}
+
+// ScreeningReviews files content flagged by automated screening as reports
+// with no reporter, so it joins the moderation queue with everything else.
+type ScreeningReviews struct {
+	reports ReportStore
+}
+
+func NewScreeningReviews(reports ReportStore) *ScreeningReviews {
+	return &ScreeningReviews{reports: reports}
+}
+
+// Flag implements screening.ReviewQueue.
+func (q *ScreeningReviews) Flag(f screening.Flag) error {
+	category := f.Result.Category
+	if _, ok := categoryPriority[category]; !ok {
+		category = CategoryOther
+	}
+	r := Report{
+		ID:             idgen.New(),
+		ReportedUserID: f.Content.AuthorID,
+		Category:       category,
+		Details:        "Automated screening: " + strings.Join(f.Result.Reasons, ", "),
+		Context:        screeningContexts[f.Content.Kind],
+		Messages:       []ReportedMessage{},
+		PhotoIDs:       []string{},
+		Priority:       categoryPriority[category],
+		Status:         ReportStatusOpen,
+		CreatedAt:      f.At,
+	}
+	if r.Context == "" {
+		r.Context = SourceProfile
+	}
+	// Spark notes are addressed to a person, not a place.
+	if r.Context != SourceProfile {
+		r.ContextID = f.Content.ContextID
+	}
+	if f.Content.ID != "" {
+		r.Messages = append(r.Messages, ReportedMessage{
+			MessageID: f.Content.ID,
+			Kind:      chat.KindText,
+			Text:      f.Content.Text,
+			SentAt:    f.At,
+		})
+	} else {
+		r.Details += "\n" + f.Content.Text
+	}
+	_, err := q.reports.CreateReport(r)
+	return err
+}
@@ -0,0 +1,45 @@
+// Package safety holds blocks and reports: the tools people use to keep
+// someone away from them and to flag them for moderation.
+package safety
+
+import "time"
+
+// Block sources, recording where a block was made. Blocks made from a
+// blind date keep the partner anonymous in listings.
+const (
+	SourceProfile   = "profile"
+	SourceChat      = "chat"
+	SourceRoom      = "room"
+	SourceBlindDate = "blind_date"
+	// SourceVerification is the context of verification cases; their
+	// ContextID is the verification attempt.
+	SourceVerification = "verification"
+)
+
+// Block is one user's block of another. Blocks hide the pair from each
+// other in both directions.
+type Block struct {
+	BlockerID string    `json:"-"`
+	BlockedID string    `json:"userId"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store persists blocks.
+type Store interface {
+	// Block records that blockerID blocked blockedID. Blocking twice is a
+	// no-op that returns the original block with created=false.
+	Block(blockerID, blockedID, source string) (b *Block, created bool, err error)
+	// Unblock removes blockerID's block of blockedID, if any. It never lifts
+	// a block the other person made.
+	Unblock(blockerID, blockedID string) error
+	// ListBlocks returns the blocks blockerID made, newest first.
+	ListBlocks(blockerID string) ([]Block, error)
+	// Blocked reports whether either user has blocked the other.
+	Blocked(a, b string) (bool, error)
+	// ExcludedUserIDs returns everyone userID blocked or was blocked by, so
+	// the store can act as a discovery exclusion source.
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+	// DeleteUser removes every block userID made or received.
+	DeleteUser(userID string) error
+}
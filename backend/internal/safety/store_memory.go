@@ -0,0 +1,87 @@
+package safety
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu sync.Mutex
+	// blocks is keyed by blocker, then blocked.
+	blocks map[string]map[string]*Block
+}
+
+// NewInMemoryStore returns an in-memory block store.
+func NewInMemoryStore() Store {
+	return &memoryStore{blocks: make(map[string]map[string]*Block)}
+}
+
+func (s *memoryStore) Block(blockerID, blockedID, source string) (*Block, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byBlocked := s.blocks[blockerID]
+	if byBlocked == nil {
+		byBlocked = make(map[string]*Block)
+		s.blocks[blockerID] = byBlocked
+	}
+	if b, ok := byBlocked[blockedID]; ok {
+		cp := *b
+		return &cp, false, nil
+	}
+	b := &Block{BlockerID: blockerID, BlockedID: blockedID, Source: source, CreatedAt: time.Now().UTC()}
+	byBlocked[blockedID] = b
+	cp := *b
+	return &cp, true, nil
+}
+
+func (s *memoryStore) Unblock(blockerID, blockedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks[blockerID], blockedID)
+	return nil
+}
+
+func (s *memoryStore) ListBlocks(blockerID string) ([]Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Block, 0, len(s.blocks[blockerID]))
+	for _, b := range s.blocks[blockerID] {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) Blocked(a, b string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ab := s.blocks[a][b]
+	_, ba := s.blocks[b][a]
+	return ab || ba, nil
+}
+
+func (s *memoryStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]struct{})
+	for id := range s.blocks[userID] {
+		out[id] = struct{}{}
+	}
+	for blocker, byBlocked := range s.blocks {
+		if _, ok := byBlocked[userID]; ok {
+			out[blocker] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks, userID)
+	for _, byBlocked := range s.blocks {
+		delete(byBlocked, userID)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package safety
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// pgStore keeps blocks in the blocks table (sql/0002_discovery.sql, with the
+// source column from sql/0018_safety.sql), which discovery's SQL already
+// filters on.
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a block Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+func (s *pgStore) Block(blockerID, blockedID, source string) (*Block, bool, error) {
+	ctx := context.Background()
+	b := Block{BlockerID: blockerID, BlockedID: blockedID}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO blocks (blocker_id, blocked_id, source)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+		RETURNING source, created_at
+	`, blockerID, blockedID, source).Scan(&b.Source, &b.CreatedAt)
+	if err == nil {
+		return &b, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	// Already blocked; return the existing record.
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT source, created_at FROM blocks WHERE blocker_id = $1 AND blocked_id = $2
+	`, blockerID, blockedID).Scan(&b.Source, &b.CreatedAt); err != nil {
+		return nil, false, err
+	}
+	return &b, false, nil
+}
+
+func (s *pgStore) Unblock(blockerID, blockedID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2
+	`, blockerID, blockedID)
+	return err
+}
+
+func (s *pgStore) ListBlocks(blockerID string) ([]Block, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT blocked_id, source, created_at FROM blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at DESC
+	`, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Block{}
+	for rows.Next() {
+		b := Block{BlockerID: blockerID}
+		if err := rows.Scan(&b.BlockedID, &b.Source, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Blocked(a, b string) (bool, error) {
+	var blocked bool
+	err := s.db.QueryRowContext(context.Background(), `
+		SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`, a, b).Scan(&blocked)
+	return blocked, err
+}
+
+func (s *pgStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT blocked_id FROM blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = struct{}{}
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1
+	`, userID)
+	return err
+}
@@ -0,0 +1,40 @@
+package safety
+
+import (
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/verification"
+)
+
+// VerificationReviews files selfies for manual review as cases in the
+// moderation queue, where moderators approve or reject them.
+type VerificationReviews struct {
+	reports ReportStore
+}
+
+func NewVerificationReviews(reports ReportStore) *VerificationReviews {
+	return &VerificationReviews{reports: reports}
+}
+
+// QueueVerification implements verification.ReviewQueue.
+func (q *VerificationReviews) QueueVerification(a *verification.Attempt) error {
+	at := time.Now().UTC()
+	if a.SubmittedAt != nil {
+		at = *a.SubmittedAt
+	}
+	_, err := q.reports.CreateReport(Report{
+		ID:             idgen.New(),
+		ReportedUserID: a.UserID,
+		Category:       CategoryVerification,
+		Details:        "Selfie verification: pose " + a.Pose + ", primary photo " + a.PhotoID,
+		Context:        SourceVerification,
+		ContextID:      a.ID,
+		Messages:       []ReportedMessage{},
+		PhotoIDs:       []string{},
+		Priority:       PriorityLow,
+		Status:         ReportStatusOpen,
+		CreatedAt:      at,
+	})
+	return err
+}
@@ -0,0 +1,267 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/intents"
+)
+
+// Profile is the subset of a user's profile that scoring looks at.
+type Profile struct {
+	UserID          string
+	Intent          string
+	ConnectionStyle string
+	Drinks          string
+	Smokes          string
+	ExerciseLevel   string
+	Interests       []string
+	// LastActiveAt drives recency, which favours people who used the app
+	// lately and so are likely to answer. Zero means unknown.
+	LastActiveAt time.Time
+	// IntentAffinity is how drawn the user has been to each intent, in
+	// [0, 1], from their behaviour events. Only the viewer's is used; nil
+	// means no data.
+	IntentAffinity map[string]float64
+}
+
+// Component is one weighted signal in a score.
+type Component struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"` // normalised to [0, 1]
+	Weight float64 `json:"weight"`
+}
+
+// Reason is a human-readable explanation for a high-scoring component.
+type Reason struct {
+	Code string `json:"code"`
+	Text string `json:"text"`
+}
+
+// Result is an explainable compatibility score in [0, 1].
+type Result struct {
+	Score      float64     `json:"score"`
+	Components []Component `json:"components"`
+	Reasons    []Reason    `json:"reasons"`
+}
+
+// Component names.
+const (
+	ComponentInterests       = "interests"
+	ComponentIntent          = "intent"
+	ComponentConnectionStyle = "connectionStyle"
+	ComponentLifestyle       = "lifestyle"
+	ComponentDistance        = "distance"
+	ComponentRecency         = "recency"
+	ComponentIntentAffinity  = "intentAffinity"
+)
+
+// maxReasons caps how many reasons Score attaches to a result.
+const maxReasons = 3
+
+// Scorer combines profile signals into a compatibility score.
+type Scorer struct {
+	weights Weights
+	now     func() time.Time
+}
+
+// New returns a Scorer using the given weights.
+func New(w Weights) *Scorer {
+	return &Scorer{weights: w, now: time.Now}
+}
+
+// Score rates candidate for viewer. distanceKm is nil when either side has
+// not shared a location; unknown signals are left out rather than counted as
+// zero, so the score is a weighted mean over what we actually know.
+func (s *Scorer) Score(viewer, candidate Profile, distanceKm *float64) Result {
+	return s.ScoreAt(s.now(), viewer, candidate, distanceKm)
+}
+
+// ScoreAt is Score as of now, for callers that must score several batches
+// against the same clock.
+func (s *Scorer) ScoreAt(now time.Time, viewer, candidate Profile, distanceKm *float64) Result {
+	var (
+		components []Component
+		reasons    []scoredReason
+	)
+	add := func(name string, value, weight float64, reason *Reason) {
+		components = append(components, Component{Name: name, Value: value, Weight: weight})
+		if reason != nil {
+			reasons = append(reasons, scoredReason{Reason: *reason, weight: value * weight})
+		}
+	}
+
+	shared := sharedCount(viewer.Interests, candidate.Interests)
+	if len(viewer.Interests) > 0 && len(candidate.Interests) > 0 {
+		value := math.Min(1, float64(shared)/float64(min(len(viewer.Interests), len(candidate.Interests))))
+		var reason *Reason
+		if shared > 0 {
+			reason = &Reason{Code: ComponentInterests, Text: pluralise(shared, "shared interest")}
+		}
+		add(ComponentInterests, value, s.weights.Interests, reason)
+	}
+
+	if viewer.Intent != "" && candidate.Intent != "" {
+		value := intentAlignment(viewer.Intent, candidate.Intent)
+		var reason *Reason
+		if value == 1 {
+			reason = &Reason{Code: ComponentIntent, Text: "Looking for the same thing"}
+		}
+		add(ComponentIntent, value, s.weights.Intent, reason)
+	}
+
+	if viewer.ConnectionStyle != "" && candidate.ConnectionStyle != "" {
+		value := connectionAlignment(viewer.ConnectionStyle, candidate.ConnectionStyle)
+		var reason *Reason
+		if value == 1 {
+			reason = &Reason{Code: ComponentConnectionStyle, Text: "Connects the way you do"}
+		}
+		add(ComponentConnectionStyle, value, s.weights.ConnectionStyle, reason)
+	}
+
+	if value, ok := lifestyleCompatibility(viewer, candidate); ok {
+		var reason *Reason
+		if value >= 0.8 {
+			reason = &Reason{Code: ComponentLifestyle, Text: "Similar lifestyle"}
+		}
+		add(ComponentLifestyle, value, s.weights.Lifestyle, reason)
+	}
+
+	if distanceKm != nil {
+		value := decay(*distanceKm, s.weights.DistanceHalfLifeKm)
+		var reason *Reason
+		if *distanceKm <= 5 {
+			reason = &Reason{Code: ComponentDistance, Text: "Lives nearby"}
+		}
+		add(ComponentDistance, value, s.weights.Distance, reason)
+	}
+
+	if !candidate.LastActiveAt.IsZero() {
+		idle := now.Sub(candidate.LastActiveAt).Hours()
+		value := decay(math.Max(0, idle), s.weights.RecencyHalfLifeHours)
+		var reason *Reason
+		if idle <= 24 {
+			reason = &Reason{Code: ComponentRecency, Text: "Active today"}
+		}
+		add(ComponentRecency, value, s.weights.Recency, reason)
+	}
+
+	if viewer.IntentAffinity != nil && candidate.Intent != "" {
+		value := viewer.IntentAffinity[candidate.Intent]
+		var reason *Reason
+		if value >= 0.6 {
+			reason = &Reason{Code: ComponentIntentAffinity, Text: "Into the vibes you've been exploring"}
+		}
+		add(ComponentIntentAffinity, value, s.weights.IntentAffinity, reason)
+	}
+
+	var total, weightSum float64
+	for _, c := range components {
+		total += c.Value * c.Weight
+		weightSum += c.Weight
+	}
+	res := Result{Components: components, Reasons: []Reason{}}
+	if weightSum > 0 {
+		res.Score = total / weightSum
+	}
+
+	sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].weight > reasons[j].weight })
+	for i := 0; i < len(reasons) && i < maxReasons; i++ {
+		res.Reasons = append(res.Reasons, reasons[i].Reason)
+	}
+	return res
+}
+
+type scoredReason struct {
+	Reason
+	weight float64
+}
+
+func sharedCount(a, b []string) int {
+	n := 0
+	for _, x := range a {
+		if slices.Contains(b, x) {
+			n++
+		}
+	}
+	return n
+}
+
+func pluralise(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// decay halves every halfLife units.
+func decay(x, halfLife float64) float64 {
+	return math.Exp(-math.Ln2 * x / halfLife)
+}
+
+// Intent IDs from the intents catalogue. Being open to connection partly
+// aligns with anything.
+func intentAlignment(a, b string) float64 {
+	switch {
+	case a == b:
+		return 1
+	case isOpenIntent(a) || isOpenIntent(b):
+		return 0.5
+	}
+	return 0
+}
+
+func isOpenIntent(intent string) bool {
+	return intent == intents.OpenToConnection
+}
+
+// Connection styles from HowDoYouConnectScreen.
+func connectionAlignment(a, b string) float64 {
+	switch {
+	case a == b:
+		return 1
+	case a == "notSure" || b == "notSure":
+		return 0.5
+	}
+	return 0.25
+}
+
+// Ordinal scales from LifestyleBasicsScreen, least to most.
+var lifestyleScales = struct {
+	drinks, smokes, exercise []string
+}{
+	drinks:   []string{"never", "socially", "yes"},
+	smokes:   []string{"no", "occasionally", "yes"},
+	exercise: []string{"rarely", "sometimes", "often", "actively"},
+}
+
+// lifestyleCompatibility averages how close two people sit on each lifestyle
+// scale. It reports false when no attribute is known for both.
+func lifestyleCompatibility(a, b Profile) (float64, bool) {
+	pairs := []struct {
+		scale []string
+		x, y  string
+	}{
+		{lifestyleScales.drinks, a.Drinks, b.Drinks},
+		{lifestyleScales.smokes, a.Smokes, b.Smokes},
+		{lifestyleScales.exercise, a.ExerciseLevel, b.ExerciseLevel},
+	}
+
+	var sum float64
+	var n int
+	for _, p := range pairs {
+		i, j := slices.Index(p.scale, p.x), slices.Index(p.scale, p.y)
+		if i < 0 || j < 0 {
+			continue
+		}
+		sum += 1 - math.Abs(float64(i-j))/float64(len(p.scale)-1)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
@@ -0,0 +1,70 @@
+package scoring
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Weights controls how much each signal contributes to a compatibility score.
+// Component scores are normalised to [0, 1] before weighting, so the weights
+// are relative to one another and need not sum to 1.
+type Weights struct {
+	Interests       float64 `json:"interests"`
+	Intent          float64 `json:"intent"`
+	ConnectionStyle float64 `json:"connectionStyle"`
+	Lifestyle       float64 `json:"lifestyle"`
+	Distance        float64 `json:"distance"`
+	Recency         float64 `json:"recency"`
+	IntentAffinity  float64 `json:"intentAffinity"`
+
+	// DistanceHalfLifeKm is the distance at which the distance score halves.
+	DistanceHalfLifeKm float64 `json:"distanceHalfLifeKm"`
+	// RecencyHalfLifeHours is the time since the candidate was last active
+	// at which the recency score halves.
+	RecencyHalfLifeHours float64 `json:"recencyHalfLifeHours"`
+}
+
+// DefaultWeights favours shared interests and intent, with distance and
+// activity acting as tie-breakers.
+func DefaultWeights() Weights {
+	return Weights{
+		Interests:            0.30,
+		Intent:               0.20,
+		ConnectionStyle:      0.10,
+		Lifestyle:            0.15,
+		Distance:             0.15,
+		Recency:              0.10,
+		IntentAffinity:       0.10,
+		DistanceHalfLifeKm:   10,
+		RecencyHalfLifeHours: 72,
+	}
+}
+
+// WeightsFromJSON overlays a JSON object (e.g. from the SCORING_WEIGHTS
+// environment variable) on top of DefaultWeights. Omitted fields keep their
+// defaults.
+func WeightsFromJSON(raw string) (Weights, error) {
+	w := DefaultWeights()
+	if raw == "" {
+		return w, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return w, err
+	}
+	if err := w.validate(); err != nil {
+		return w, err
+	}
+	return w, nil
+}
+
+func (w Weights) validate() error {
+	for _, v := range []float64{w.Interests, w.Intent, w.ConnectionStyle, w.Lifestyle, w.Distance, w.Recency, w.IntentAffinity} {
+		if v < 0 {
+			return errors.New("scoring weights must not be negative")
+		}
+	}
+	if w.DistanceHalfLifeKm <= 0 || w.RecencyHalfLifeHours <= 0 {
+		return errors.New("scoring half-lives must be positive")
+	}
+	return nil
+}
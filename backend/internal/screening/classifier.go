@@ -0,0 +1,181 @@
+// Package screening checks user-written text as it is stored. A
+// ContentClassifier decides what happens to each piece of content: let it
+// through, flag it for human review, soft-hide it or block it outright.
+package screening
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Kinds of content that are screened.
+const (
+	KindDisplayName      = "display_name"
+	KindSparkNote        = "spark_note"
+	KindChatMessage      = "chat_message"
+	KindRoomMessage      = "room_message"
+	KindBlindDateMessage = "blind_date_message"
+)
+
+// Action is what should happen to a piece of content, from least to most
+// severe.
+type Action string
+
+const (
+	// ActionAllow stores the content as written.
+	ActionAllow Action = "allow"
+	// ActionFlag stores the content and queues it for human review.
+	ActionFlag Action = "flag"
+	// ActionSoftHide stores the content but delivers it collapsed behind a
+	// notice the recipient has to tap through, and queues it for review.
+	// Only messages can be soft-hidden; elsewhere it escalates to a block.
+	ActionSoftHide Action = "soft_hide"
+	// ActionBlock refuses the content.
+	ActionBlock Action = "block"
+)
+
+func (a Action) severity() int {
+	switch a {
+	case ActionFlag:
+		return 1
+	case ActionSoftHide:
+		return 2
+	case ActionBlock:
+		return 3
+	}
+	return 0
+}
+
+func (a Action) valid() bool {
+	return a == ActionAllow || a.severity() > 0
+}
+
+// Reasons reported by the built-in rules. External classifiers may add
+// their own.
+const (
+	ReasonPhoneNumber = "phone_number"
+	ReasonURL         = "url"
+	ReasonHandle      = "handle"
+	ReasonBlockedWord = "blocked_word"
+	ReasonFlaggedWord = "flagged_word"
+	ReasonRepeated    = "repeated"
+)
+
+// Categories match safety's report categories, so flagged content lands in
+// the moderation queue at the right priority.
+const (
+	CategorySpam                 = "spam"
+	CategoryScam                 = "scam"
+	CategoryHarassment           = "harassment"
+	CategoryInappropriateContent = "inappropriate_content"
+)
+
+// Content is one piece of user-written text.
+type Content struct {
+	Kind     string `json:"kind"`
+	AuthorID string `json:"authorId"`
+	// ContextID is the conversation, room, blind date session or spark
+	// recipient the text is for; empty for profile fields.
+	ContextID string `json:"contextId,omitempty"`
+	// ID is the stored message's ID, once there is one.
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+}
+
+// Result is a classifier's decision.
+type Result struct {
+	Action   Action   `json:"action"`
+	Category string   `json:"category,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// Allowed is the result for content nothing objected to.
+var Allowed = Result{Action: ActionAllow}
+
+// NeedsReview reports whether a moderator should look at stored content.
+func (r Result) NeedsReview() bool {
+	return r.Action == ActionFlag || r.Action == ActionSoftHide
+}
+
+// Label names why content was soft-hidden or blocked in terms a client can
+// show: contact_details, spam or inappropriate.
+func (r Result) Label() string {
+	for _, reason := range r.Reasons {
+		switch reason {
+		case ReasonPhoneNumber, ReasonURL, ReasonHandle:
+			return "contact_details"
+		}
+	}
+	if r.Category == CategorySpam || slices.Contains(r.Reasons, ReasonRepeated) {
+		return "spam"
+	}
+	return "inappropriate"
+}
+
+// BlockedError is returned by write paths when screening refused content.
+// Its message is safe to show the author.
+type BlockedError struct {
+	Result Result
+}
+
+func (e *BlockedError) Error() string {
+	switch e.Result.Label() {
+	case "contact_details":
+		return "contact details and links can't be shared here"
+	case "spam":
+		return "this looks like spam and wasn't sent"
+	}
+	return "this content isn't allowed"
+}
+
+// Err returns a *BlockedError for r.
+func (r Result) Err() error {
+	return &BlockedError{Result: r}
+}
+
+// IsBlocked reports whether err is, or wraps, a *BlockedError.
+func IsBlocked(err error) bool {
+	var blocked *BlockedError
+	return errors.As(err, &blocked)
+}
+
+// merge combines two results; the more severe action and its category win.
+func merge(a, b Result) Result {
+	out := a
+	if b.Action.severity() > a.Action.severity() {
+		out.Action, out.Category = b.Action, b.Category
+	} else if out.Category == "" {
+		out.Category = b.Category
+	}
+	out.Reasons = append(slices.Clone(a.Reasons), b.Reasons...)
+	return out
+}
+
+// ContentClassifier decides what to do with a piece of content.
+type ContentClassifier interface {
+	Classify(ctx context.Context, c Content) (Result, error)
+}
+
+type chain []ContentClassifier
+
+// Chain runs every classifier and merges their results. A failing
+// classifier doesn't stop the others: the merged result of those that
+// succeeded is returned together with the errors.
+func Chain(classifiers ...ContentClassifier) ContentClassifier {
+	return chain(classifiers)
+}
+
+func (c chain) Classify(ctx context.Context, content Content) (Result, error) {
+	out := Allowed
+	var errs []error
+	for _, cl := range c {
+		r, err := cl.Classify(ctx, content)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out = merge(out, r)
+	}
+	return out, errors.Join(errs...)
+}
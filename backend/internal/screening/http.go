@@ -0,0 +1,62 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultHTTPTimeout bounds a call to an external classifier; screening
+// sits on the write path.
+const defaultHTTPTimeout = 2 * time.Second
+
+type httpClassifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPClassifier returns a classifier that asks an external service.
+// Each Content is POSTed to url as JSON and the service answers with a
+// Result: {"action": "allow|flag|soft_hide|block", "category": "...",
+// "reasons": [...]}. A nil client uses one with a short timeout.
+func NewHTTPClassifier(url string, client *http.Client) ContentClassifier {
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &httpClassifier{url: url, client: client}
+}
+
+func (c *httpClassifier) Classify(ctx context.Context, content Content) (Result, error) {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("classifier request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Result{}, fmt.Errorf("classifier returned %s", resp.Status)
+	}
+
+	var r Result
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&r); err != nil {
+		return Result{}, fmt.Errorf("classifier response: %w", err)
+	}
+	if !r.Action.valid() {
+		return Result{}, fmt.Errorf("classifier returned unknown action %q", r.Action)
+	}
+	return r, nil
+}
@@ -0,0 +1,183 @@
+package screening
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Patterns for moving a conversation off the platform.
+var (
+	// phonePattern matches nine or more digits with the usual separators.
+	phonePattern  = regexp.MustCompile(`\+?\d(?:[\s\-.()]*\d){8,}`)
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|io|me|co|app|link|ly|gg|xyz)\b`)
+	handlePattern = regexp.MustCompile(`(?i)(?:^|\s)@[a-z0-9_.]{3,30}|\b(?:snap(?:chat)?|insta(?:gram)?|ig|telegram|whats\s?app|kik|wechat|signal|discord)\s*[:@]\s*@?[a-z0-9_.\-]{3,}`)
+)
+
+// Rules configure the rule-based classifier.
+type Rules struct {
+	// BlockWords are refused outright; FlagWords are let through and
+	// flagged. Both match whole words or phrases, case-insensitively.
+	BlockWords []string
+	FlagWords  []string
+	// The same text sent to RepeatSoftHide different conversations, rooms
+	// or people within RepeatWindow is soft-hidden as spam; at RepeatBlock
+	// it is refused. Short texts such as "hi" never count.
+	RepeatWindow   time.Duration
+	RepeatSoftHide int
+	RepeatBlock    int
+}
+
+// DefaultRules returns the built-in word lists and spam thresholds.
+func DefaultRules() Rules {
+	return Rules{
+		BlockWords: []string{"kill yourself", "kys", "go die"},
+		FlagWords: []string{
+			"cashapp", "cash app", "venmo", "paypal", "western union", "gift card",
+			"bitcoin", "crypto", "investment opportunity", "sugar daddy", "sugar baby",
+		},
+		RepeatWindow:   10 * time.Minute,
+		RepeatSoftHide: 3,
+		RepeatBlock:    5,
+	}
+}
+
+// minRepeatLength is the shortest normalised text that counts as a repeat.
+const minRepeatLength = 12
+
+type sighting struct {
+	contextID string
+	at        time.Time
+}
+
+type ruleClassifier struct {
+	rules Rules
+	block []string
+	flag  []string
+
+	// recent remembers, per author and normalised text, where it was sent.
+	// It is per instance: spam spread across instances is caught later.
+	mu        sync.Mutex
+	recent    map[string]map[string][]sighting
+	lastSweep time.Time
+}
+
+// NewRuleClassifier returns the built-in classifier: word lists, patterns
+// for phone numbers, handles and URLs, and repeated-message detection.
+func NewRuleClassifier(rules Rules) ContentClassifier {
+	c := &ruleClassifier{rules: rules, recent: make(map[string]map[string][]sighting)}
+	for _, w := range rules.BlockWords {
+		c.block = append(c.block, normalize(w))
+	}
+	for _, w := range rules.FlagWords {
+		c.flag = append(c.flag, normalize(w))
+	}
+	return c
+}
+
+// normalize lowercases text and reduces everything but letters and digits
+// to single spaces, padded so phrases can be matched as " word ".
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(fields, " ") + " "
+}
+
+func containsAny(normalized string, phrases []string) bool {
+	for _, p := range phrases {
+		if p != "  " && strings.Contains(normalized, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ruleClassifier) Classify(_ context.Context, content Content) (Result, error) {
+	norm := normalize(content.Text)
+	out := Allowed
+
+	if containsAny(norm, c.block) {
+		out = merge(out, Result{Action: ActionBlock, Category: CategoryHarassment, Reasons: []string{ReasonBlockedWord}})
+	}
+	if containsAny(norm, c.flag) {
+		out = merge(out, Result{Action: ActionFlag, Category: CategoryScam, Reasons: []string{ReasonFlaggedWord}})
+	}
+
+	var contact []string
+	if phonePattern.MatchString(content.Text) {
+		contact = append(contact, ReasonPhoneNumber)
+	}
+	if urlPattern.MatchString(content.Text) {
+		contact = append(contact, ReasonURL)
+	}
+	if handlePattern.MatchString(content.Text) {
+		contact = append(contact, ReasonHandle)
+	}
+	if len(contact) > 0 {
+		out = merge(out, Result{Action: ActionSoftHide, Category: CategoryScam, Reasons: contact})
+	}
+
+	if n := c.repeats(content, norm, time.Now()); n >= c.rules.RepeatBlock && c.rules.RepeatBlock > 0 {
+		out = merge(out, Result{Action: ActionBlock, Category: CategorySpam, Reasons: []string{ReasonRepeated}})
+	} else if n >= c.rules.RepeatSoftHide && c.rules.RepeatSoftHide > 0 {
+		out = merge(out, Result{Action: ActionSoftHide, Category: CategorySpam, Reasons: []string{ReasonRepeated}})
+	}
+	return out, nil
+}
+
+// repeats records this sighting and returns how many different contexts
+// the author sent the same text to within the window.
+func (c *ruleClassifier) repeats(content Content, norm string, now time.Time) int {
+	if content.ContextID == "" || len(norm) < minRepeatLength+2 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := now.Add(-c.rules.RepeatWindow)
+	if now.Sub(c.lastSweep) > c.rules.RepeatWindow {
+		// Forget authors who went quiet.
+		for author, byText := range c.recent {
+			prune(byText, cutoff)
+			if len(byText) == 0 {
+				delete(c.recent, author)
+			}
+		}
+		c.lastSweep = now
+	}
+	byText := c.recent[content.AuthorID]
+	if byText == nil {
+		byText = make(map[string][]sighting)
+		c.recent[content.AuthorID] = byText
+	}
+	prune(byText, cutoff)
+
+	seen := append(byText[norm], sighting{contextID: content.ContextID, at: now})
+	byText[norm] = seen
+	contexts := make(map[string]struct{}, len(seen))
+	for _, s := range seen {
+		contexts[s.contextID] = struct{}{}
+	}
+	return len(contexts)
+}
+
+// prune drops sightings at or before cutoff.
+func prune(byText map[string][]sighting, cutoff time.Time) {
+	for text, seen := range byText {
+		kept := seen[:0]
+		for _, s := range seen {
+			if s.at.After(cutoff) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			delete(byText, text)
+		} else {
+			byText[text] = kept
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package screening
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// softHideKinds are the kinds that can be delivered collapsed. Anything
+// else a classifier wants soft-hidden is blocked instead.
+var softHideKinds = map[string]bool{
+	KindChatMessage: true,
+	KindRoomMessage: true,
+}
+
+// Flag is stored content a moderator should look at.
+type Flag struct {
+	Content Content
+	Result  Result
+	At      time.Time
+}
+
+// ReviewQueue takes flags for human review; safety.ScreeningReviews files
+// them as reports.
+type ReviewQueue interface {
+	Flag(f Flag) error
+}
+
+// Screener is what write paths call: it classifies content before it is
+// stored and queues what needs a human afterwards.
+type Screener struct {
+	logger     *log.Logger
+	classifier ContentClassifier
+	reviews    ReviewQueue
+}
+
+func NewScreener(logger *log.Logger, classifier ContentClassifier, reviews ReviewQueue) *Screener {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Screener{logger: logger, classifier: classifier, reviews: reviews}
+}
+
+// Check classifies c. Classifier failures fail open: whatever succeeded
+// decides and the error is logged, so an outage never stops people from
+// talking.
+func (s *Screener) Check(ctx context.Context, c Content) Result {
+	r, err := s.classifier.Classify(ctx, c)
+	if err != nil {
+		s.logger.Printf("screening: classify %s: %v", c.Kind, err)
+	}
+	if !r.Action.valid() {
+		r.Action = ActionAllow
+	}
+	if r.Action == ActionSoftHide && !softHideKinds[c.Kind] {
+		r.Action = ActionBlock
+	}
+	return r
+}
+
+// Review queues content that was stored with r for a moderator, if r asks
+// for it. Set c.ID to the stored message first.
+func (s *Screener) Review(c Content, r Result) {
+	if !r.NeedsReview() {
+		return
+	}
+	if err := s.reviews.Flag(Flag{Content: c, Result: r, At: time.Now().UTC()}); err != nil {
+		s.logger.Printf("screening: queue %s for review: %v", c.Kind, err)
+	}
+}
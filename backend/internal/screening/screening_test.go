@@ -0,0 +1,222 @@
+package screening
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"testing"
+)
+
+type stubClassifier struct {
+	result Result
+	err    error
+}
+
+func (s stubClassifier) Classify(context.Context, Content) (Result, error) {
+	return s.result, s.err
+}
+
+type recordingQueue struct {
+	flags []Flag
+}
+
+func (q *recordingQueue) Flag(f Flag) error {
+	q.flags = append(q.flags, f)
+	return nil
+}
+
+func TestRuleClassifierActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		action   Action
+		category string
+		reasons  []string
+		label    string
+	}{
+		{"plain", "Fancy a coffee on Sunday?", ActionAllow, "", nil, ""},
+		{"blocked phrase", "just go die already", ActionBlock, CategoryHarassment, []string{ReasonBlockedWord}, "inappropriate"},
+		{"blocked word case-insensitive", "KYS", ActionBlock, CategoryHarassment, []string{ReasonBlockedWord}, "inappropriate"},
+		{"word inside another word", "my skills are great", ActionAllow, "", nil, ""},
+		{"flagged word", "send it over venmo", ActionFlag, CategoryScam, []string{ReasonFlaggedWord}, ""},
+		{"phone number", "call me on +49 170 1234 5678", ActionSoftHide, CategoryScam, []string{ReasonPhoneNumber}, "contact_details"},
+		{"url", "see www.example.com", ActionSoftHide, CategoryScam, []string{ReasonURL}, "contact_details"},
+		{"bare domain", "my page is kindl.io", ActionSoftHide, CategoryScam, []string{ReasonURL}, "contact_details"},
+		{"handle", "add me @sunny_days", ActionSoftHide, CategoryScam, []string{ReasonHandle}, "contact_details"},
+		{"named app", "snap: sunnydays", ActionSoftHide, CategoryScam, []string{ReasonHandle}, "contact_details"},
+		{"short number", "I have 2 cats and 3 dogs", ActionAllow, "", nil, ""},
+		{"block beats soft-hide", "kys or call 0170 1234 5678", ActionBlock, CategoryHarassment, []string{ReasonBlockedWord, ReasonPhoneNumber}, "contact_details"},
+		{"soft-hide beats flag", "paypal me at www.pay.me", ActionSoftHide, CategoryScam, []string{ReasonFlaggedWord, ReasonURL}, "contact_details"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRuleClassifier(DefaultRules())
+			r, err := c.Classify(context.Background(), Content{Kind: KindChatMessage, AuthorID: "a", Text: tt.text})
+			if err != nil {
+				t.Fatalf("Classify: %v", err)
+			}
+			if r.Action != tt.action {
+				t.Errorf("action = %q, want %q", r.Action, tt.action)
+			}
+			if r.Category != tt.category {
+				t.Errorf("category = %q, want %q", r.Category, tt.category)
+			}
+			if !slices.Equal(r.Reasons, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", r.Reasons, tt.reasons)
+			}
+			if tt.label != "" && r.Label() != tt.label {
+				t.Errorf("label = %q, want %q", r.Label(), tt.label)
+			}
+		})
+	}
+}
+
+func TestRuleClassifierRepeats(t *testing.T) {
+	const text = "hey there, loved your profile!"
+	tests := []struct {
+		name     string
+		text     string
+		contexts []string
+		want     []Action
+	}{
+		{
+			name:     "escalates across contexts",
+			text:     text,
+			contexts: []string{"c1", "c2", "c3", "c4", "c5"},
+			want:     []Action{ActionAllow, ActionAllow, ActionSoftHide, ActionSoftHide, ActionBlock},
+		},
+		{
+			name:     "same context never escalates",
+			text:     text,
+			contexts: []string{"c1", "c1", "c1", "c1", "c1"},
+			want:     []Action{ActionAllow, ActionAllow, ActionAllow, ActionAllow, ActionAllow},
+		},
+		{
+			name:     "short texts never count",
+			text:     "hi!",
+			contexts: []string{"c1", "c2", "c3", "c4", "c5"},
+			want:     []Action{ActionAllow, ActionAllow, ActionAllow, ActionAllow, ActionAllow},
+		},
+		{
+			name:     "profile fields never count",
+			text:     text,
+			contexts: []string{"", "", "", "", ""},
+			want:     []Action{ActionAllow, ActionAllow, ActionAllow, ActionAllow, ActionAllow},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRuleClassifier(DefaultRules())
+			for i, ctxID := range tt.contexts {
+				r, err := c.Classify(context.Background(), Content{
+					Kind: KindChatMessage, AuthorID: "a", ContextID: ctxID, Text: tt.text,
+				})
+				if err != nil {
+					t.Fatalf("Classify: %v", err)
+				}
+				if r.Action != tt.want[i] {
+					t.Fatalf("send %d: action = %q, want %q", i+1, r.Action, tt.want[i])
+				}
+				if r.Action != ActionAllow && r.Label() != "spam" {
+					t.Errorf("send %d: label = %q, want spam", i+1, r.Label())
+				}
+			}
+		})
+	}
+}
+
+func TestRuleClassifierRepeatsPerAuthor(t *testing.T) {
+	c := NewRuleClassifier(DefaultRules())
+	const text = "hey there, loved your profile!"
+	for i := range 5 {
+		author := fmt.Sprintf("user-%d", i)
+		r, _ := c.Classify(context.Background(), Content{
+			Kind: KindChatMessage, AuthorID: author, ContextID: fmt.Sprintf("c%d", i), Text: text,
+		})
+		if r.Action != ActionAllow {
+			t.Fatalf("%s: action = %q, want allow", author, r.Action)
+		}
+	}
+}
+
+func TestScreenerCheck(t *testing.T) {
+	softHide := Result{Action: ActionSoftHide, Category: CategoryScam, Reasons: []string{ReasonURL}}
+	tests := []struct {
+		name       string
+		classifier ContentClassifier
+		kind       string
+		want       Action
+	}{
+		{"allow", stubClassifier{result: Allowed}, KindChatMessage, ActionAllow},
+		{"soft-hide chat message", stubClassifier{result: softHide}, KindChatMessage, ActionSoftHide},
+		{"soft-hide room message", stubClassifier{result: softHide}, KindRoomMessage, ActionSoftHide},
+		{"soft-hide display name escalates", stubClassifier{result: softHide}, KindDisplayName, ActionBlock},
+		{"soft-hide spark note escalates", stubClassifier{result: softHide}, KindSparkNote, ActionBlock},
+		{"soft-hide blind date message escalates", stubClassifier{result: softHide}, KindBlindDateMessage, ActionBlock},
+		{"unknown action allows", stubClassifier{result: Result{Action: "quarantine"}}, KindChatMessage, ActionAllow},
+		{"failure fails open", stubClassifier{err: errors.New("down")}, KindChatMessage, ActionAllow},
+		{
+			"failure keeps other verdicts",
+			Chain(stubClassifier{err: errors.New("down")}, stubClassifier{result: Result{Action: ActionFlag, Category: CategoryScam}}),
+			KindChatMessage,
+			ActionFlag,
+		},
+		{
+			"chain takes the most severe",
+			Chain(stubClassifier{result: Result{Action: ActionFlag}}, stubClassifier{result: softHide}, stubClassifier{result: Allowed}),
+			KindChatMessage,
+			ActionSoftHide,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScreener(log.New(io.Discard, "", 0), tt.classifier, &recordingQueue{})
+			r := s.Check(context.Background(), Content{Kind: tt.kind, AuthorID: "a", Text: "x"})
+			if r.Action != tt.want {
+				t.Errorf("action = %q, want %q", r.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreenerReview(t *testing.T) {
+	tests := []struct {
+		action Action
+		queued bool
+	}{
+		{ActionAllow, false},
+		{ActionFlag, true},
+		{ActionSoftHide, true},
+		{ActionBlock, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			q := &recordingQueue{}
+			s := NewScreener(log.New(io.Discard, "", 0), stubClassifier{}, q)
+			c := Content{Kind: KindChatMessage, AuthorID: "a", ID: "m1", Text: "x"}
+			s.Review(c, Result{Action: tt.action})
+			if got := len(q.flags) == 1; got != tt.queued {
+				t.Fatalf("queued = %v, want %v", got, tt.queued)
+			}
+			if tt.queued && q.flags[0].Content.ID != "m1" {
+				t.Errorf("flagged content ID = %q, want m1", q.flags[0].Content.ID)
+			}
+		})
+	}
+}
+
+func TestBlockedError(t *testing.T) {
+	err := fmt.Errorf("send: %w", Result{Action: ActionBlock, Reasons: []string{ReasonRepeated}}.Err())
+	if !IsBlocked(err) {
+		t.Fatal("IsBlocked = false for a wrapped BlockedError")
+	}
+	if IsBlocked(errors.New("other")) {
+		t.Fatal("IsBlocked = true for an unrelated error")
+	}
+	if got, want := errors.Unwrap(err).Error(), "this looks like spam and wasn't sent"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package snooze
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+)
+
+// Handler exposes a user's snooze over HTTP.
+type Handler struct {
+	logger  *log.Logger
+	service *Service
+}
+
+func NewHandler(logger *log.Logger, service *Service) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{logger: logger, service: service}
+}
+
+// --- Request payloads ---
+
+type snoozeRequest struct {
+	Until *time.Time `json:"until"`
+}
+
+// --- Handlers ---
+
+// Snooze handles /v1/snooze
+//
+//	GET     the caller's snooze
+//	PUT     {"until":"2027-01-01T09:00:00Z"} snoozes until then; an empty
+//	        body or no until snoozes until DELETE
+//	DELETE  wakes the caller up
+func (h *Handler) Snooze(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		st, err := h.service.Status(userID)
+		if err != nil {
+			h.logger.Printf("Get snooze error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load snooze"))
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"snooze": st})
+
+	case http.MethodPut:
+		var req snoozeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			httpjson.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		st, err := h.service.Snooze(userID, req.Until)
+		switch {
+		case errors.Is(err, ErrInvalidUntil):
+			httpjson.Error(w, http.StatusBadRequest, err)
+		case errors.Is(err, ErrNotOnboarded):
+			httpjson.Error(w, http.StatusConflict, err)
+		case err != nil:
+			h.logger.Printf("Snooze error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to snooze"))
+		default:
+			httpjson.Write(w, http.StatusOK, map[string]any{"snooze": st})
+		}
+
+	case http.MethodDelete:
+		if err := h.service.Wake(userID); err != nil {
+			h.logger.Printf("Wake error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to wake up"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
@@ -0,0 +1,138 @@
+// Package snooze lets people take a break: a snoozed user disappears from
+// discovery, blind dates and room member lists while their matches and
+// conversations keep working. A snooze lasts until a chosen time or until
+// the user wakes up.
+package snooze
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// EventEnded tells a user, over the chat socket (/v1/ws), that their
+// snooze ran out.
+const EventEnded = "snooze.ended"
+
+// wakeBatch bounds how many snoozes one pass of Run ends.
+const wakeBatch = 100
+
+var (
+	// ErrNotOnboarded is returned when snoozing without a profile.
+	ErrNotOnboarded = errors.New("complete onboarding before snoozing")
+	// ErrInvalidUntil is returned for a snooze that would end in the past.
+	ErrInvalidUntil = errors.New("until must be in the future")
+)
+
+// Queue drops users from matchmaking; *blinddate.Queue implements it.
+type Queue interface {
+	Leave(userID string) error
+}
+
+// Notifier delivers frames to users' live connections; *chat.Hub
+// implements it.
+type Notifier interface {
+	SendFrame(v any, userIDs ...string)
+}
+
+// Status is a user's snooze.
+type Status struct {
+	Snoozed   bool       `json:"snoozed"`
+	SnoozedAt *time.Time `json:"snoozedAt,omitempty"`
+	// Until is when the snooze ends; unset while it lasts until the user
+	// wakes up.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type endedEvent struct {
+	Type string `json:"type"`
+}
+
+// Service snoozes and wakes users.
+type Service struct {
+	logger   *log.Logger
+	profiles onboarding.Store
+	queue    Queue
+	notifier Notifier
+}
+
+func NewService(logger *log.Logger, profiles onboarding.Store, queue Queue, notifier Notifier) *Service {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Service{
+		logger:   logger,
+		profiles: profiles,
+		queue:    queue,
+		notifier: notifier,
+	}
+}
+
+// Status returns userID's snooze.
+func (s *Service) Status(userID string) (*Status, error) {
+	p, err := s.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return &Status{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !p.Snoozed(time.Now()) {
+		return &Status{}, nil
+	}
+	return &Status{Snoozed: true, SnoozedAt: p.SnoozedAt, Until: p.SnoozedUntil}, nil
+}
+
+// Snooze hides userID until until, or until they wake up when until is
+// nil, and takes them out of the blind-date queue. Snoozing again changes
+// when the snooze ends.
+func (s *Service) Snooze(userID string, until *time.Time) (*Status, error) {
+	now := time.Now().UTC()
+	if until != nil && !until.After(now) {
+		return nil, ErrInvalidUntil
+	}
+	err := s.profiles.Snooze(userID, now, until)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, ErrNotOnboarded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.queue.Leave(userID); err != nil {
+		return nil, err
+	}
+	return s.Status(userID)
+}
+
+// Wake ends userID's snooze.
+func (s *Service) Wake(userID string) error {
+	return s.profiles.Wake(userID)
+}
+
+// Run wakes users whose snooze has ended every interval. It blocks until
+// ctx is cancelled.
+func (s *Service) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		woken, err := s.profiles.WakeExpired(time.Now(), wakeBatch)
+		if err != nil {
+			s.logger.Printf("snooze wake-up error: %v", err)
+		}
+		for _, id := range woken {
+			s.notifier.SendFrame(endedEvent{Type: EventEnded}, id)
+		}
+		if len(woken) > 0 {
+			s.logger.Printf("woke %d snoozed users", len(woken))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
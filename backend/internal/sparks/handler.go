@@ -0,0 +1,446 @@
+package sparks
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/matches"
+	"github.com/rijey/kindl/backend/internal/notifications"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+	"github.com/rijey/kindl/backend/internal/screening"
+)
+
+// Store persists sparks.
+type Store interface {
+	// Create records a new spark. It fails with ErrQuotaExceeded when the
+	// sender already sent dailyLimit sparks since the start of the UTC day,
+	// with ErrDuplicate when a pending spark to the same user exists, and
+	// with ErrCooldown when the recipient declined one less than
+	// DeclineCooldown ago. Those checks come first, so a resend never
+	// reports the quota.
+	Create(in CreateInput, dailyLimit int) (*Spark, error)
+	// Inbox lists pending sparks received by userID, newest first.
+	Inbox(userID string, limit int) ([]Spark, error)
+	// Sent lists sparks sent by userID, newest first.
+	Sent(userID string, limit int) ([]Spark, error)
+	// Respond moves a pending spark addressed to recipientID to status.
+	// Repeating the same response is a no-op; a different one fails with
+	// ErrAlreadyResponded.
+	Respond(id, recipientID, status string) (*Spark, error)
+	// SentSince counts sparks sent by userID at or after since.
+	SentSince(userID string, since time.Time) (int, error)
+	// ExcludedUserIDs returns everyone userID has sparked, so the store can
+	// act as a discovery exclusion source.
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+	// DeleteUser removes every spark sent or received by userID.
+	DeleteUser(userID string) error
+}
+
+var (
+	ErrNotFound         = errors.New("spark not found")
+	ErrQuotaExceeded    = errors.New("daily spark limit reached")
+	ErrDuplicate        = errors.New("your last spark to this person is still pending")
+	ErrCooldown         = errors.New("this person passed on your last spark; try again later")
+	ErrAlreadyResponded = errors.New("spark was already answered")
+)
+
+// Spark statuses.
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+)
+
+// Item types a spark can target, matching SparkBottomSheet's contentType.
+const (
+	ItemPhoto  = "photo"
+	ItemPrompt = "prompt"
+)
+
+// Spark is a like with a short note attached to a specific profile item.
+type Spark struct {
+	ID          string     `json:"id"`
+	FromUserID  string     `json:"fromUserId"`
+	ToUserID    string     `json:"toUserId"`
+	ItemType    string     `json:"itemType"`
+	ItemID      string     `json:"itemId"`
+	Note        string     `json:"note"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+}
+
+// CreateInput is the data needed to record a spark.
+type CreateInput struct {
+	FromUserID string
+	ToUserID   string
+	ItemType   string
+	ItemID     string
+	Note       string
+}
+
+const (
+	// MaxNoteWords mirrors the limit enforced by SparkBottomSheet.
+	MaxNoteWords = 50
+	// maxNoteChars stops a "50 word" note made of very long words.
+	maxNoteChars = 500
+	// DefaultDailyQuota is how many sparks a user may send per UTC day.
+	DefaultDailyQuota = 5
+	// DeclineCooldown is how long a sender must wait after a decline before
+	// sparking the same person again, so a "no" isn't met with a new spark
+	// every day.
+	DeclineCooldown = 30 * 24 * time.Hour
+
+	listLimit = 100
+)
+
+// BlockList reports blocks between users in either direction;
+// safety.Store implements it.
+type BlockList interface {
+	Blocked(a, b string) (bool, error)
+	ExcludedUserIDs(userID string) (map[string]struct{}, error)
+}
+
+// Handler exposes sparks over HTTP.
+type Handler struct {
+	logger     *log.Logger
+	store      Store
+	matches    matches.Store
+	profiles   onboarding.Store
+	blocks     BlockList
+	screener   *screening.Screener
+	push       *notifications.Service
+	dailyQuota int
+}
+
+func NewHandler(logger *log.Logger, store Store, matchStore matches.Store, profiles onboarding.Store, blocks BlockList,
+	screener *screening.Screener, push *notifications.Service, dailyQuota int) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if dailyQuota <= 0 {
+		dailyQuota = DefaultDailyQuota
+	}
+	return &Handler{
+		logger:     logger,
+		store:      store,
+		matches:    matchStore,
+		profiles:   profiles,
+		blocks:     blocks,
+		screener:   screener,
+		push:       push,
+		dailyQuota: dailyQuota,
+	}
+}
+
+// --- Request payloads ---
+
+type sparkRequest struct {
+	TargetUserID string `json:"targetUserId"`
+	ItemType     string `json:"itemType"`
+	ItemID       string `json:"itemId"`
+	Note         string `json:"note"`
+}
+
+type userSummary struct {
+	UserID      string     `json:"userId"`
+	DisplayName string     `json:"displayName"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+}
+
+type inboxSpark struct {
+	Spark
+	From userSummary `json:"from"`
+}
+
+type respondResponse struct {
+	Spark *Spark         `json:"spark"`
+	Match *matches.Match `json:"match,omitempty"`
+}
+
+// --- Helpers ---
+
+// validateNote applies the same word counting as SparkBottomSheet.
+func validateNote(note string) error {
+	if note == "" {
+		return errors.New("note is required")
+	}
+	if len(strings.Fields(note)) > MaxNoteWords {
+		return errors.New("note must be 50 words or fewer")
+	}
+	if len(note) > maxNoteChars {
+		return errors.New("note is too long")
+	}
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (h *Handler) remainingToday(userID string) (int, error) {
+	n, err := h.store.SentSince(userID, startOfDay(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return max(0, h.dailyQuota-n), nil
+}
+
+// --- Handlers ---
+
+// Send handles POST /v1/sparks
+func (h *Handler) Send(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var req sparkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.TargetUserID == "" {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("targetUserId is required"))
+		return
+	}
+	if req.TargetUserID == userID {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("cannot spark yourself"))
+		return
+	}
+	if req.ItemType != ItemPhoto && req.ItemType != ItemPrompt {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("itemType must be photo or prompt"))
+		return
+	}
+	if req.ItemID == "" {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("itemId is required"))
+		return
+	}
+	if err := validateNote(req.Note); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := h.profiles.GetProfile(req.TargetUserID); err != nil {
+		if errors.Is(err, onboarding.ErrProfileNotFound) {
+			httpjson.Error(w, http.StatusNotFound, errors.New("user not found"))
+			return
+		}
+		h.logger.Printf("Send spark profile error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load user"))
+		return
+	}
+	// Blocked users look like they don't exist, whichever side blocked.
+	if blocked, err := h.blocks.Blocked(userID, req.TargetUserID); err != nil || blocked {
+		if err != nil {
+			h.logger.Printf("Send spark block error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load user"))
+			return
+		}
+		httpjson.Error(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+
+	note := screening.Content{Kind: screening.KindSparkNote, AuthorID: userID, ContextID: req.TargetUserID, Text: req.Note}
+	verdict := screening.Allowed
+	if req.Note != "" {
+		verdict = h.screener.Check(r.Context(), note)
+		if verdict.Action == screening.ActionBlock {
+			httpjson.Error(w, http.StatusUnprocessableEntity, verdict.Err())
+			return
+		}
+	}
+
+	spark, err := h.store.Create(CreateInput{
+		FromUserID: userID,
+		ToUserID:   req.TargetUserID,
+		ItemType:   req.ItemType,
+		ItemID:     req.ItemID,
+		Note:       req.Note,
+	}, h.dailyQuota)
+	switch {
+	case errors.Is(err, ErrQuotaExceeded):
+		httpjson.Error(w, http.StatusTooManyRequests, err)
+		return
+	case errors.Is(err, ErrDuplicate), errors.Is(err, ErrCooldown):
+		httpjson.Error(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		h.logger.Printf("Send spark error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to send spark"))
+		return
+	}
+	h.screener.Review(note, verdict)
+	h.push.Notify(notifications.Notification{
+		Kind:      notifications.KindSpark,
+		UserID:    spark.ToUserID,
+		ActorID:   userID,
+		SubjectID: spark.ID,
+		Text:      spark.Note,
+	})
+
+	remaining, err := h.remainingToday(userID)
+	if err != nil {
+		h.logger.Printf("Send spark quota error: %v", err)
+	}
+
+	httpjson.Write(w, http.StatusCreated, map[string]any{
+		"spark":          spark,
+		"remainingToday": remaining,
+	})
+}
+
+// Inbox handles GET /v1/sparks/inbox
+func (h *Handler) Inbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	list, err := h.store.Inbox(userID, listLimit)
+	if err != nil {
+		h.logger.Printf("Spark inbox error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load sparks"))
+		return
+	}
+	blocked, err := h.blocks.ExcludedUserIDs(userID)
+	if err != nil {
+		h.logger.Printf("Spark inbox block error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load sparks"))
+		return
+	}
+
+	out := make([]inboxSpark, 0, len(list))
+	for _, sp := range list {
+		if _, skip := blocked[sp.FromUserID]; skip {
+			continue
+		}
+		item := inboxSpark{Spark: sp, From: userSummary{UserID: sp.FromUserID}}
+		if p, err := h.profiles.GetProfile(sp.FromUserID); err == nil {
+			item.From.DisplayName = p.DisplayName
+			item.From.VerifiedAt = p.VerifiedAt
+		}
+		out = append(out, item)
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{"sparks": out})
+}
+
+// Sent handles GET /v1/sparks/sent
+func (h *Handler) Sent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	list, err := h.store.Sent(userID, listLimit)
+	if err != nil {
+		h.logger.Printf("Sent sparks error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load sparks"))
+		return
+	}
+	if list == nil {
+		list = []Spark{}
+	}
+
+	remaining, err := h.remainingToday(userID)
+	if err != nil {
+		h.logger.Printf("Sent sparks quota error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to load sparks"))
+		return
+	}
+
+	httpjson.Write(w, http.StatusOK, map[string]any{
+		"sparks":         list,
+		"remainingToday": remaining,
+	})
+}
+
+// Accept handles POST /v1/sparks/{id}/accept
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, StatusAccepted)
+}
+
+// Decline handles POST /v1/sparks/{id}/decline
+func (h *Handler) Decline(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, StatusDeclined)
+}
+
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, status string) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	spark, err := h.store.Respond(r.PathValue("id"), userID, status)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpjson.Error(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, ErrAlreadyResponded):
+		httpjson.Error(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		h.logger.Printf("Respond spark error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to answer spark"))
+		return
+	}
+
+	resp := respondResponse{Spark: spark}
+	blocked, err := h.blocks.Blocked(spark.FromUserID, spark.ToUserID)
+	if err != nil {
+		h.logger.Printf("Respond spark block error: %v", err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to answer spark"))
+		return
+	}
+	if spark.Status == StatusAccepted && !blocked {
+		// Idempotent, so retrying an accept never creates a second match.
+		m, created, err := h.matches.Create(spark.FromUserID, spark.ToUserID, matches.SourceSpark)
+		if err != nil {
+			h.logger.Printf("Accept spark match error: %v", err)
+			httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to create match"))
+			return
+		}
+		if m.State == matches.StateActive {
+			// An unmatched pair stays unmatched; don't resurface it.
+			resp.Match = m
+		}
+		if created {
+			h.push.Notify(notifications.Notification{
+				Kind:      notifications.KindMatch,
+				UserID:    spark.FromUserID,
+				ActorID:   spark.ToUserID,
+				SubjectID: m.ID,
+			})
+		}
+	}
+
+	httpjson.Write(w, http.StatusOK, resp)
+}
@@ -0,0 +1,156 @@
+package sparks
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	sparks map[string]*Spark
+	byPair map[[2]string]string // (from, to) -> pending spark ID
+}
+
+// NewInMemoryStore returns an in-memory spark store.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		sparks: make(map[string]*Spark),
+		byPair: make(map[[2]string]string),
+	}
+}
+
+func (s *memoryStore) Create(in CreateInput, dailyLimit int) (*Spark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byPair[[2]string{in.FromUserID, in.ToUserID}]; ok {
+		return nil, ErrDuplicate
+	}
+	if s.declinedSinceLocked(in.FromUserID, in.ToUserID, time.Now().Add(-DeclineCooldown)) {
+		return nil, ErrCooldown
+	}
+	if s.sentSinceLocked(in.FromUserID, startOfDay(time.Now())) >= dailyLimit {
+		return nil, ErrQuotaExceeded
+	}
+
+	sp := &Spark{
+		ID:         idgen.New(),
+		FromUserID: in.FromUserID,
+		ToUserID:   in.ToUserID,
+		ItemType:   in.ItemType,
+		ItemID:     in.ItemID,
+		Note:       in.Note,
+		Status:     StatusPending,
+		CreatedAt:  time.Now(),
+	}
+	s.sparks[sp.ID] = sp
+	s.byPair[[2]string{in.FromUserID, in.ToUserID}] = sp.ID
+	cp := *sp
+	return &cp, nil
+}
+
+func (s *memoryStore) list(limit int, keep func(*Spark) bool) []Spark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Spark
+	for _, sp := range s.sparks {
+		if keep(sp) {
+			out = append(out, *sp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (s *memoryStore) Inbox(userID string, limit int) ([]Spark, error) {
+	return s.list(limit, func(sp *Spark) bool {
+		return sp.ToUserID == userID && sp.Status == StatusPending
+	}), nil
+}
+
+func (s *memoryStore) Sent(userID string, limit int) ([]Spark, error) {
+	return s.list(limit, func(sp *Spark) bool {
+		return sp.FromUserID == userID
+	}), nil
+}
+
+func (s *memoryStore) Respond(id, recipientID, status string) (*Spark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sp, ok := s.sparks[id]
+	if !ok || sp.ToUserID != recipientID {
+		return nil, ErrNotFound
+	}
+	if sp.Status != StatusPending {
+		if sp.Status != status {
+			return nil, ErrAlreadyResponded
+		}
+		cp := *sp
+		return &cp, nil
+	}
+	now := time.Now()
+	sp.Status = status
+	sp.RespondedAt = &now
+	delete(s.byPair, [2]string{sp.FromUserID, sp.ToUserID})
+	cp := *sp
+	return &cp, nil
+}
+
+func (s *memoryStore) SentSince(userID string, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sentSinceLocked(userID, since), nil
+}
+
+func (s *memoryStore) sentSinceLocked(userID string, since time.Time) int {
+	n := 0
+	for _, sp := range s.sparks {
+		if sp.FromUserID == userID && !sp.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *memoryStore) declinedSinceLocked(fromUserID, toUserID string, since time.Time) bool {
+	for _, sp := range s.sparks {
+		if sp.FromUserID == fromUserID && sp.ToUserID == toUserID &&
+			sp.Status == StatusDeclined && sp.RespondedAt.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memoryStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]struct{})
+	for _, sp := range s.sparks {
+		if sp.FromUserID == userID {
+			out[sp.ToUserID] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sp := range s.sparks {
+		if sp.FromUserID == userID || sp.ToUserID == userID {
+			delete(s.sparks, id)
+			delete(s.byPair, [2]string{sp.FromUserID, sp.ToUserID})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package sparks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/idgen"
+)
+
+// pgStore persists sparks in the sparks table (sql/0005_sparks.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a spark Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const sparkColumns = `id, from_user_id, to_user_id, item_type, item_id, note, status, created_at, responded_at`
+
+func scanSpark(row interface{ Scan(...any) error }) (*Spark, error) {
+	var (
+		sp          Spark
+		respondedAt sql.NullTime
+	)
+	if err := row.Scan(&sp.ID, &sp.FromUserID, &sp.ToUserID, &sp.ItemType, &sp.ItemID,
+		&sp.Note, &sp.Status, &sp.CreatedAt, &respondedAt); err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		t := respondedAt.Time
+		sp.RespondedAt = &t
+	}
+	return &sp, nil
+}
+
+// Create serialises sends per user with an advisory lock so two concurrent
+// requests can't both squeeze under the daily quota.
+func (s *pgStore) Create(in CreateInput, dailyLimit int) (*Spark, error) {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "spark:"+in.FromUserID); err != nil {
+		return nil, err
+	}
+
+	var pending bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sparks WHERE from_user_id = $1 AND to_user_id = $2 AND status = 'pending')
+	`, in.FromUserID, in.ToUserID).Scan(&pending); err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrDuplicate
+	}
+
+	var cooling bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM sparks
+			WHERE from_user_id = $1 AND to_user_id = $2 AND status = 'declined' AND responded_at > $3
+		)
+	`, in.FromUserID, in.ToUserID, time.Now().Add(-DeclineCooldown)).Scan(&cooling); err != nil {
+		return nil, err
+	}
+	if cooling {
+		return nil, ErrCooldown
+	}
+
+	var sent int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT count(*) FROM sparks WHERE from_user_id = $1 AND created_at >= $2
+	`, in.FromUserID, startOfDay(time.Now())).Scan(&sent); err != nil {
+		return nil, err
+	}
+	if sent >= dailyLimit {
+		return nil, ErrQuotaExceeded
+	}
+
+	sp, err := scanSpark(tx.QueryRowContext(ctx, `
+		INSERT INTO sparks (id, from_user_id, to_user_id, item_type, item_id, note, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (from_user_id, to_user_id) WHERE status = 'pending' DO NOTHING
+		RETURNING `+sparkColumns,
+		idgen.New(), in.FromUserID, in.ToUserID, in.ItemType, in.ItemID, in.Note, StatusPending))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sp, tx.Commit()
+}
+
+func (s *pgStore) list(query string, args ...any) ([]Spark, error) {
+	rows, err := s.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Spark
+	for rows.Next() {
+		sp, err := scanSpark(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sp)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) Inbox(userID string, limit int) ([]Spark, error) {
+	return s.list(`
+		SELECT `+sparkColumns+` FROM sparks
+		WHERE to_user_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, userID, StatusPending, limit)
+}
+
+func (s *pgStore) Sent(userID string, limit int) ([]Spark, error) {
+	return s.list(`
+		SELECT `+sparkColumns+` FROM sparks
+		WHERE from_user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+}
+
+func (s *pgStore) Respond(id, recipientID, status string) (*Spark, error) {
+	ctx := context.Background()
+
+	sp, err := scanSpark(s.db.QueryRowContext(ctx, `
+		UPDATE sparks SET status = $3, responded_at = now()
+		WHERE id = $1 AND to_user_id = $2 AND status = 'pending'
+		RETURNING `+sparkColumns, id, recipientID, status))
+	if err == nil {
+		return sp, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Not pending any more (or not ours): work out which.
+	sp, err = scanSpark(s.db.QueryRowContext(ctx, `
+		SELECT `+sparkColumns+` FROM sparks WHERE id = $1 AND to_user_id = $2
+	`, id, recipientID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sp.Status != status {
+		return nil, ErrAlreadyResponded
+	}
+	return sp, nil
+}
+
+func (s *pgStore) SentSince(userID string, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(context.Background(), `
+		SELECT count(*) FROM sparks WHERE from_user_id = $1 AND created_at >= $2
+	`, userID, since).Scan(&n)
+	return n, err
+}
+
+func (s *pgStore) ExcludedUserIDs(userID string) (map[string]struct{}, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT to_user_id FROM sparks WHERE from_user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = struct{}{}
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM sparks WHERE from_user_id = $1 OR to_user_id = $1
+	`, userID)
+	return err
+}
@@ -0,0 +1,128 @@
+package verification
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/httpjson"
+	"github.com/rijey/kindl/backend/internal/media"
+)
+
+// maxSelfieBytes caps selfie uploads.
+const maxSelfieBytes = 10 << 20
+
+// Handler exposes verification to users. Moderators decide manual reviews
+// through the admin case API.
+type Handler struct {
+	logger  *log.Logger
+	service *Service
+}
+
+func NewHandler(logger *log.Logger, service *Service) *Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Handler{logger: logger, service: service}
+}
+
+// --- Helpers ---
+
+func (h *Handler) serviceError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpjson.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrNoPrimaryPhoto), errors.Is(err, ErrInProgress), errors.Is(err, ErrNotAwaitingSelfie):
+		httpjson.Error(w, http.StatusConflict, err)
+	case errors.Is(err, ErrExpired):
+		httpjson.Error(w, http.StatusGone, err)
+	default:
+		h.logger.Printf("verification %s error: %v", action, err)
+		httpjson.Error(w, http.StatusInternalServerError, errors.New("failed to "+action))
+	}
+}
+
+// --- Handlers ---
+
+// Verification handles /v1/verification
+//
+//	GET   the caller's verifiedAt and latest attempt
+//	POST  starts an attempt; the response names the pose to copy, and the
+//	      selfie is due at /v1/verification/{id}/selfie before expiresAt
+//
+// Starting needs a primary photo (PUT /v1/onboarding/primary-photo) and is
+// refused while an earlier selfie is in review.
+func (h *Handler) Verification(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status, err := h.service.Status(userID)
+		if err != nil {
+			h.serviceError(w, err, "load verification")
+			return
+		}
+		httpjson.Write(w, http.StatusOK, status)
+
+	case http.MethodPost:
+		a, err := h.service.Start(userID)
+		if err != nil {
+			h.serviceError(w, err, "start verification")
+			return
+		}
+		httpjson.Write(w, http.StatusCreated, map[string]any{"attempt": a})
+
+	default:
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// Selfie handles POST /v1/verification/{id}/selfie
+//
+// The request body is the raw JPEG or PNG. The attempt comes back approved
+// or rejected, or pending while a moderator reviews it; the outcome of a
+// review arrives as a verification.decided event on /v1/ws.
+func (h *Handler) Selfie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.Error(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	userID, err := auth.UserID(r)
+	if err != nil {
+		httpjson.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	if media.NormalizeImageType(r.Header.Get("Content-Type")) == "" {
+		httpjson.Error(w, http.StatusUnsupportedMediaType, errors.New("selfies must be image/jpeg or image/png"))
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSelfieBytes))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			httpjson.Error(w, http.StatusRequestEntityTooLarge, errors.New("selfie is too large"))
+			return
+		}
+		httpjson.Error(w, http.StatusBadRequest, errors.New("could not read upload"))
+		return
+	}
+	info, err := media.ProbeImage(data)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, errors.New("could not read selfie: "+err.Error()))
+		return
+	}
+
+	a, err := h.service.Submit(r.Context(), userID, r.PathValue("id"), data, info.ContentType)
+	if err != nil {
+		h.serviceError(w, err, "submit selfie")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, map[string]any{"attempt": a})
+}
@@ -0,0 +1,274 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/idgen"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+// EventDecided tells a user, over the chat socket (/v1/ws), that their
+// verification was decided.
+const EventDecided = "verification.decided"
+
+// Rejection reasons the service gives itself.
+const (
+	reasonUnavailable  = "We couldn't check your selfie just now. Please try again."
+	reasonPhotoChanged = "Your primary photo changed while your selfie was in review. Please verify again."
+)
+
+// Notifier delivers frames to users' live connections; *chat.Hub
+// implements it.
+type Notifier interface {
+	SendFrame(v any, userIDs ...string)
+}
+
+type decidedEvent struct {
+	Type       string     `json:"type"`
+	Attempt    *Attempt   `json:"attempt"`
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// Status is where a user stands: verified or not, and their latest
+// attempt.
+type Status struct {
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+	Attempt    *Attempt   `json:"attempt,omitempty"`
+}
+
+// Review is what a moderator sees of a verification case: the attempt,
+// with the selfie and the primary photo it should match.
+type Review struct {
+	Attempt   *Attempt `json:"attempt"`
+	SelfieURL string   `json:"selfieUrl,omitempty"`
+	// PrimaryPhotoID is the user's current primary photo; when it no
+	// longer matches Attempt.PhotoID, approving won't verify them.
+	PrimaryPhotoID string `json:"primaryPhotoId"`
+}
+
+// Service runs verification attempts from pose to decision.
+type Service struct {
+	logger   *log.Logger
+	store    Store
+	profiles onboarding.Store
+	accounts auth.AccountStore
+	blobs    media.BlobStore
+	signer   *media.Signer
+	verifier Verifier
+	notifier Notifier
+}
+
+func NewService(logger *log.Logger, store Store, profiles onboarding.Store, accounts auth.AccountStore,
+	blobs media.BlobStore, signer *media.Signer, verifier Verifier, notifier Notifier) *Service {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Service{
+		logger:   logger,
+		store:    store,
+		profiles: profiles,
+		accounts: accounts,
+		blobs:    blobs,
+		signer:   signer,
+		verifier: verifier,
+		notifier: notifier,
+	}
+}
+
+// withInstructions fills in the pose instructions for display.
+func withInstructions(a *Attempt) *Attempt {
+	if a != nil {
+		a.Instructions = poseInstructions(a.Pose)
+	}
+	return a
+}
+
+// Status returns userID's verification state.
+func (s *Service) Status(userID string) (*Status, error) {
+	out := &Status{}
+	p, err := s.profiles.GetProfile(userID)
+	if err != nil && !errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, err
+	}
+	if p != nil {
+		out.VerifiedAt = p.VerifiedAt
+	}
+	a, err := s.store.Latest(userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	out.Attempt = withInstructions(a)
+	return out, nil
+}
+
+// Start opens an attempt for userID's current primary photo with a random
+// pose to copy.
+func (s *Service) Start(userID string) (*Attempt, error) {
+	p, err := s.profiles.GetProfile(userID)
+	if errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, ErrNoPrimaryPhoto
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.PrimaryPhotoID == "" {
+		return nil, ErrNoPrimaryPhoto
+	}
+	latest, err := s.store.Latest(userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Status == StatusPending {
+		return nil, ErrInProgress
+	}
+
+	now := time.Now().UTC()
+	a, err := s.store.Create(Attempt{
+		ID:        idgen.New(),
+		UserID:    userID,
+		Pose:      Poses[rand.IntN(len(Poses))].ID,
+		PhotoID:   p.PrimaryPhotoID,
+		Status:    StatusAwaitingSelfie,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ChallengeTTL),
+	})
+	return withInstructions(a), err
+}
+
+// Submit stores the selfie for one of userID's attempts and hands it to
+// the Verifier. The attempt comes back decided, or pending when a human
+// has to look. A Verifier failure rejects the attempt so the user can try
+// again rather than waiting on a review that was never queued.
+func (s *Service) Submit(ctx context.Context, userID, attemptID string, selfie []byte, contentType string) (*Attempt, error) {
+	a, err := s.store.Get(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID {
+		return nil, ErrNotFound
+	}
+	if a.Status != StatusAwaitingSelfie {
+		return nil, ErrNotAwaitingSelfie
+	}
+	now := time.Now().UTC()
+	if !now.Before(a.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	key := "verification/" + media.KeySegment(userID) + "/" + idgen.New()
+	if err := s.blobs.Put(key, contentType, selfie); err != nil {
+		return nil, err
+	}
+	a, err = s.store.Submit(a.ID, key, now)
+	if err != nil {
+		if derr := s.blobs.Delete(key); derr != nil {
+			s.logger.Printf("verification: selfie cleanup error: %v", derr)
+		}
+		return nil, err
+	}
+
+	d, err := s.verifier.Verify(ctx, a, selfie)
+	if err != nil {
+		s.logger.Printf("verification: verify %s: %v", a.ID, err)
+		d = Decision{Status: StatusRejected, Reason: reasonUnavailable}
+	}
+	if d.Status == StatusPending {
+		return withInstructions(a), nil
+	}
+	return s.settle(a, d)
+}
+
+// Decide settles a pending attempt on a moderator's say-so.
+func (s *Service) Decide(attemptID string, approved bool, reason string) (*Attempt, error) {
+	a, err := s.store.Get(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	d := Decision{Status: StatusRejected, Reason: strings.TrimSpace(reason)}
+	if approved {
+		d = Decision{Status: StatusApproved}
+	}
+	return s.settle(a, d)
+}
+
+// settle records a decision and, for an approval, verifies the profile.
+// An approval for a photo that is no longer primary is turned into a
+// rejection: the badge must always vouch for the photo people see.
+func (s *Service) settle(a *Attempt, d Decision) (*Attempt, error) {
+	if d.Status != StatusApproved && d.Status != StatusRejected {
+		return nil, errors.New("verification: invalid decision " + d.Status)
+	}
+	if d.Status == StatusApproved {
+		p, err := s.profiles.GetProfile(a.UserID)
+		if err != nil && !errors.Is(err, onboarding.ErrProfileNotFound) {
+			return nil, err
+		}
+		if p == nil || p.PrimaryPhotoID != a.PhotoID {
+			d = Decision{Status: StatusRejected, Reason: reasonPhotoChanged}
+		}
+	}
+
+	now := time.Now().UTC()
+	decided, err := s.store.Decide(a.ID, d.Status, d.Reason, now)
+	if err != nil {
+		return nil, err
+	}
+	ev := decidedEvent{Type: EventDecided, Attempt: withInstructions(decided)}
+	if d.Status == StatusApproved {
+		ok, err := s.profiles.MarkVerified(a.UserID, a.PhotoID, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ev.VerifiedAt = &now
+		} else {
+			s.logger.Printf("verification: %s approved after the primary photo changed", a.ID)
+		}
+	}
+	s.notifier.SendFrame(ev, a.UserID)
+	return decided, nil
+}
+
+// Review returns an attempt as the moderator viewerID sees it.
+func (s *Service) Review(attemptID, viewerID string) (*Review, error) {
+	a, err := s.store.Get(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	out := &Review{Attempt: withInstructions(a)}
+	if a.SelfieKey != "" {
+		out.SelfieURL = s.signer.URL(a.SelfieKey, viewerID)
+	}
+	p, err := s.profiles.GetProfile(a.UserID)
+	if err != nil && !errors.Is(err, onboarding.ErrProfileNotFound) {
+		return nil, err
+	}
+	if p != nil {
+		out.PrimaryPhotoID = p.PrimaryPhotoID
+	}
+	return out, nil
+}
+
+// CanAccessMedia is the media.AccessFunc for the "verification" namespace:
+// selfies are only shown to the person in them and to admins.
+func (s *Service) CanAccessMedia(viewerID, key string) (bool, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return false, nil
+	}
+	if parts[1] == media.KeySegment(viewerID) {
+		return true, nil
+	}
+	account, err := s.accounts.Get(viewerID)
+	if err != nil {
+		return false, err
+	}
+	return account.Role == auth.RoleAdmin, nil
+}
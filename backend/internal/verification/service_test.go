@@ -0,0 +1,267 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/rijey/kindl/backend/internal/auth"
+	"github.com/rijey/kindl/backend/internal/media"
+	"github.com/rijey/kindl/backend/internal/onboarding"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []decidedEvent
+}
+
+func (n *recordingNotifier) SendFrame(v any, _ ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if ev, ok := v.(decidedEvent); ok {
+		n.events = append(n.events, ev)
+	}
+}
+
+type failingVerifier struct{}
+
+func (failingVerifier) Verify(context.Context, *Attempt, []byte) (Decision, error) {
+	return Decision{}, errors.New("verifier down")
+}
+
+type testEnv struct {
+	service  *Service
+	profiles onboarding.Store
+	notifier *recordingNotifier
+}
+
+// newTestEnv returns a Service over in-memory stores, with user "u1"
+// onboarded and photo "p1" as their primary photo.
+func newTestEnv(t *testing.T, verifier Verifier) *testEnv {
+	t.Helper()
+	profiles := onboarding.NewInMemoryStore()
+	if err := profiles.MarkOnboardingComplete("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.SetPrimaryPhoto("u1", "p1"); err != nil {
+		t.Fatal(err)
+	}
+	notifier := &recordingNotifier{}
+	s := NewService(log.New(io.Discard, "", 0), NewInMemoryStore(), profiles, auth.NewInMemoryAccountStore(),
+		media.NewInMemoryBlobStore(), nil, verifier, notifier)
+	return &testEnv{service: s, profiles: profiles, notifier: notifier}
+}
+
+// submit starts an attempt for u1 and sends a selfie for it.
+func (e *testEnv) submit(t *testing.T) *Attempt {
+	t.Helper()
+	a, err := e.service.Start("u1")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	a, err = e.service.Submit(context.Background(), "u1", a.ID, []byte("selfie"), "image/jpeg")
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	return a
+}
+
+func (e *testEnv) verifiedAt(t *testing.T) bool {
+	t.Helper()
+	st, err := e.service.Status("u1")
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	return st.VerifiedAt != nil
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier Verifier
+		status   string
+		reason   string
+		verified bool
+		events   int
+	}{
+		{"approved", NewFake(Decision{Status: StatusApproved}), StatusApproved, "", true, 1},
+		{"rejected", NewFake(Decision{Status: StatusRejected, Reason: "Face not visible"}), StatusRejected, "Face not visible", false, 1},
+		{"left for review", NewFake(Decision{Status: StatusPending}), StatusPending, "", false, 0},
+		{"verifier failure rejects", failingVerifier{}, StatusRejected, reasonUnavailable, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t, tt.verifier)
+			a := e.submit(t)
+			if a.Status != tt.status {
+				t.Errorf("status = %q, want %q", a.Status, tt.status)
+			}
+			if a.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", a.Reason, tt.reason)
+			}
+			if got := e.verifiedAt(t); got != tt.verified {
+				t.Errorf("verified = %v, want %v", got, tt.verified)
+			}
+			if got := len(e.notifier.events); got != tt.events {
+				t.Errorf("events = %d, want %d", got, tt.events)
+			}
+			if f, ok := tt.verifier.(*Fake); ok {
+				seen := f.Seen()
+				if len(seen) != 1 || seen[0].PhotoID != "p1" {
+					t.Errorf("verifier saw %+v, want one attempt against p1", seen)
+				}
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name          string
+		approve       bool
+		reason        string
+		changePhoto   bool
+		status        string
+		wantReason    string
+		verified      bool
+		eventVerified bool
+	}{
+		{name: "approve", approve: true, status: StatusApproved, verified: true, eventVerified: true},
+		{name: "reject", reason: "  Pose doesn't match  ", status: StatusRejected, wantReason: "Pose doesn't match"},
+		{
+			name: "approve after primary photo change", approve: true, changePhoto: true,
+			status: StatusRejected, wantReason: reasonPhotoChanged,
+		},
+		{
+			name: "reject after primary photo change", reason: "Blurry", changePhoto: true,
+			status: StatusRejected, wantReason: "Blurry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t, NewFake(Decision{Status: StatusPending}))
+			a := e.submit(t)
+			if tt.changePhoto {
+				if err := e.profiles.SetPrimaryPhoto("u1", "p2"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := e.service.Decide(a.ID, tt.approve, tt.reason)
+			if err != nil {
+				t.Fatalf("Decide: %v", err)
+			}
+			if got.Status != tt.status || got.Reason != tt.wantReason {
+				t.Errorf("decided %q (%q), want %q (%q)", got.Status, got.Reason, tt.status, tt.wantReason)
+			}
+			if v := e.verifiedAt(t); v != tt.verified {
+				t.Errorf("verified = %v, want %v", v, tt.verified)
+			}
+			if len(e.notifier.events) != 1 {
+				t.Fatalf("events = %d, want 1", len(e.notifier.events))
+			}
+			if ev := e.notifier.events[0]; (ev.VerifiedAt != nil) != tt.eventVerified {
+				t.Errorf("event verifiedAt set = %v, want %v", ev.VerifiedAt != nil, tt.eventVerified)
+			}
+
+			if _, err := e.service.Decide(a.ID, true, ""); !errors.Is(err, ErrNotPending) {
+				t.Errorf("second Decide error = %v, want ErrNotPending", err)
+			}
+		})
+	}
+}
+
+func TestReverifyAfterPrimaryPhotoChange(t *testing.T) {
+	fake := NewFake(Decision{Status: StatusApproved})
+	e := newTestEnv(t, fake)
+
+	if a := e.submit(t); a.Status != StatusApproved {
+		t.Fatalf("first attempt %q, want approved", a.Status)
+	}
+	if !e.verifiedAt(t) {
+		t.Fatal("not verified after approval")
+	}
+
+	if err := e.profiles.SetPrimaryPhoto("u1", "p2"); err != nil {
+		t.Fatal(err)
+	}
+	if e.verifiedAt(t) {
+		t.Fatal("still verified after the primary photo changed")
+	}
+
+	fake.SetDecision(Decision{Status: StatusRejected, Reason: "No match"})
+	if a := e.submit(t); a.Status != StatusRejected || a.PhotoID != "p2" {
+		t.Fatalf("second attempt %q against %q, want rejected against p2", a.Status, a.PhotoID)
+	}
+	if e.verifiedAt(t) {
+		t.Fatal("verified after a rejection")
+	}
+
+	fake.SetDecision(Decision{Status: StatusApproved})
+	if a := e.submit(t); a.Status != StatusApproved || a.PhotoID != "p2" {
+		t.Fatalf("third attempt %q against %q, want approved against p2", a.Status, a.PhotoID)
+	}
+	if !e.verifiedAt(t) {
+		t.Fatal("not verified after re-verification")
+	}
+	if n := len(fake.Seen()); n != 3 {
+		t.Errorf("verifier saw %d attempts, want 3", n)
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, e *testEnv)
+		want  error
+	}{
+		{"ok", func(*testing.T, *testEnv) {}, nil},
+		{
+			"no primary photo",
+			func(t *testing.T, e *testEnv) {
+				if err := e.profiles.SetPrimaryPhoto("u1", ""); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ErrNoPrimaryPhoto,
+		},
+		{"in review", func(t *testing.T, e *testEnv) { e.submit(t) }, ErrInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t, NewFake(Decision{Status: StatusPending}))
+			tt.setup(t, e)
+			a, err := e.service.Start("u1")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Start error = %v, want %v", err, tt.want)
+			}
+			if err == nil && (a.Status != StatusAwaitingSelfie || a.Instructions == "") {
+				t.Errorf("started %+v, want an attempt awaiting a selfie with instructions", a)
+			}
+		})
+	}
+	if _, err := newTestEnv(t, nil).service.Start("nobody"); !errors.Is(err, ErrNoPrimaryPhoto) {
+		t.Errorf("Start for unknown user error = %v, want ErrNoPrimaryPhoto", err)
+	}
+}
+
+func TestSubmitChecks(t *testing.T) {
+	e := newTestEnv(t, NewFake(Decision{Status: StatusPending}))
+	a, err := e.service.Start("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := e.service.Submit(ctx, "u2", a.ID, []byte("x"), "image/jpeg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("someone else's attempt: error = %v, want ErrNotFound", err)
+	}
+	if _, err := e.service.Submit(ctx, "u1", a.ID, []byte("x"), "image/jpeg"); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := e.service.Submit(ctx, "u1", a.ID, []byte("x"), "image/jpeg"); !errors.Is(err, ErrNotAwaitingSelfie) {
+		t.Errorf("second selfie: error = %v, want ErrNotAwaitingSelfie", err)
+	}
+}
@@ -0,0 +1,112 @@
+package verification
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]*Attempt
+	// latest is each user's most recent attempt ID.
+	latest map[string]string
+}
+
+// NewInMemoryStore returns an in-memory verification Store.
+func NewInMemoryStore() Store {
+	return &memoryStore{
+		attempts: make(map[string]*Attempt),
+		latest:   make(map[string]string),
+	}
+}
+
+func (s *memoryStore) Create(a Attempt) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[a.ID] = &a
+	s.latest[a.UserID] = a.ID
+	out := a
+	return &out, nil
+}
+
+func (s *memoryStore) Get(id string) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := *a
+	return &out, nil
+}
+
+func (s *memoryStore) Latest(userID string) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[s.latest[userID]]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := *a
+	return &out, nil
+}
+
+func (s *memoryStore) List(userID string) ([]Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Attempt
+	for _, a := range s.attempts {
+		if a.UserID == userID {
+			out = append(out, *a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) Submit(id, selfieKey string, at time.Time) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if a.Status != StatusAwaitingSelfie {
+		return nil, ErrNotAwaitingSelfie
+	}
+	a.SelfieKey = selfieKey
+	a.Status = StatusPending
+	a.SubmittedAt = &at
+	out := *a
+	return &out, nil
+}
+
+func (s *memoryStore) Decide(id, status, reason string, at time.Time) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if a.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+	a.Status = status
+	a.Reason = reason
+	a.DecidedAt = &at
+	out := *a
+	return &out, nil
+}
+
+func (s *memoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, a := range s.attempts {
+		if a.UserID == userID {
+			delete(s.attempts, id)
+		}
+	}
+	delete(s.latest, userID)
+	return nil
+}
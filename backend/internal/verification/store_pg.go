@@ -0,0 +1,122 @@
+package verification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// pgStore keeps attempts in verification_attempts
+// (sql/0021_verification.sql).
+type pgStore struct {
+	db *sql.DB
+}
+
+// NewPGStore constructs a verification Store backed by Postgres.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db}
+}
+
+const attemptColumns = `id, user_id, pose, photo_id, selfie_key, status, reason,
+	created_at, expires_at, submitted_at, decided_at`
+
+func scanAttempt(row interface{ Scan(...any) error }) (*Attempt, error) {
+	var (
+		a                      Attempt
+		submittedAt, decidedAt sql.NullTime
+	)
+	if err := row.Scan(&a.ID, &a.UserID, &a.Pose, &a.PhotoID, &a.SelfieKey, &a.Status, &a.Reason,
+		&a.CreatedAt, &a.ExpiresAt, &submittedAt, &decidedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if submittedAt.Valid {
+		a.SubmittedAt = &submittedAt.Time
+	}
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	return &a, nil
+}
+
+func (s *pgStore) Create(a Attempt) (*Attempt, error) {
+	return scanAttempt(s.db.QueryRowContext(context.Background(), `
+		INSERT INTO verification_attempts (id, user_id, pose, photo_id, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+attemptColumns,
+		a.ID, a.UserID, a.Pose, a.PhotoID, a.Status, a.CreatedAt, a.ExpiresAt))
+}
+
+func (s *pgStore) Get(id string) (*Attempt, error) {
+	return scanAttempt(s.db.QueryRowContext(context.Background(),
+		`SELECT `+attemptColumns+` FROM verification_attempts WHERE id = $1`, id))
+}
+
+func (s *pgStore) Latest(userID string) (*Attempt, error) {
+	return scanAttempt(s.db.QueryRowContext(context.Background(), `
+		SELECT `+attemptColumns+` FROM verification_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, userID))
+}
+
+func (s *pgStore) List(userID string) ([]Attempt, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT `+attemptColumns+` FROM verification_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Attempt
+	for rows.Next() {
+		a, err := scanAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// transition runs a conditional update, telling a missing attempt apart
+// from one in the wrong status.
+func (s *pgStore) transition(id string, wrongState error, query string, args ...any) (*Attempt, error) {
+	a, err := scanAttempt(s.db.QueryRowContext(context.Background(), query, args...))
+	if !errors.Is(err, ErrNotFound) {
+		return a, err
+	}
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return nil, wrongState
+}
+
+func (s *pgStore) Submit(id, selfieKey string, at time.Time) (*Attempt, error) {
+	return s.transition(id, ErrNotAwaitingSelfie, `
+		UPDATE verification_attempts
+		SET selfie_key = $2, status = $3, submitted_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+attemptColumns,
+		id, selfieKey, StatusPending, at, StatusAwaitingSelfie)
+}
+
+func (s *pgStore) Decide(id, status, reason string, at time.Time) (*Attempt, error) {
+	return s.transition(id, ErrNotPending, `
+		UPDATE verification_attempts
+		SET status = $2, reason = $3, decided_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+attemptColumns,
+		id, status, reason, at, StatusPending)
+}
+
+func (s *pgStore) DeleteUser(userID string) error {
+	_, err := s.db.ExecContext(context.Background(), `DELETE FROM verification_attempts WHERE user_id = $1`, userID)
+	return err
+}
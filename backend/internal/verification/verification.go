@@ -0,0 +1,118 @@
+// Package verification runs selfie verification: a user photographs
+// themselves doing a requested pose, a Verifier compares the selfie with
+// their primary photo, and approval earns the profile its verified badge.
+// The badge lasts until the primary photo changes.
+package verification
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Attempt statuses.
+const (
+	// StatusAwaitingSelfie is a started attempt: the pose has been handed
+	// out and the selfie is due before ExpiresAt.
+	StatusAwaitingSelfie = "awaiting_selfie"
+	// StatusPending attempts have a selfie and wait for the Verifier.
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// ChallengeTTL is how long a user has to take the selfie once a pose has
+// been requested, so the pose proves the photo is fresh.
+const ChallengeTTL = 10 * time.Minute
+
+var (
+	ErrNotFound = errors.New("verification not found")
+	// ErrNotAwaitingSelfie is returned when a selfie arrives for an attempt
+	// that already has one.
+	ErrNotAwaitingSelfie = errors.New("a selfie was already submitted for this verification")
+	// ErrNotPending is returned when deciding an attempt twice.
+	ErrNotPending = errors.New("verification already decided")
+	// ErrExpired is returned for selfies sent after the challenge expired.
+	ErrExpired = errors.New("this pose has expired; start verification again")
+	// ErrNoPrimaryPhoto is returned when starting without a primary photo
+	// to verify against.
+	ErrNoPrimaryPhoto = errors.New("set a primary photo before verifying")
+	// ErrInProgress is returned when starting while a selfie is in review.
+	ErrInProgress = errors.New("a verification is already in review")
+)
+
+// Pose is a gesture the user is asked to make in their selfie.
+type Pose struct {
+	ID           string
+	Instructions string
+}
+
+// Poses are handed out at random.
+var Poses = []Pose{
+	{ID: "thumbs_up", Instructions: "Give a thumbs up next to your face"},
+	{ID: "peace_sign", Instructions: "Make a peace sign next to your face"},
+	{ID: "hand_on_head", Instructions: "Put one hand flat on top of your head"},
+	{ID: "touch_nose", Instructions: "Touch the tip of your nose with one finger"},
+	{ID: "open_palm", Instructions: "Hold up an open palm beside your face"},
+}
+
+func poseInstructions(id string) string {
+	for _, p := range Poses {
+		if p.ID == id {
+			return p.Instructions
+		}
+	}
+	return ""
+}
+
+// Attempt is one run through verification.
+type Attempt struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Pose   string `json:"pose"`
+	// Instructions describe Pose; they aren't stored.
+	Instructions string `json:"instructions,omitempty"`
+	// PhotoID is the primary photo the selfie is checked against.
+	PhotoID   string `json:"photoId"`
+	SelfieKey string `json:"-"`
+	Status    string `json:"status"`
+	// Reason explains a rejection to the user.
+	Reason      string     `json:"reason,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	SubmittedAt *time.Time `json:"submittedAt,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+}
+
+// Store persists attempts.
+type Store interface {
+	Create(a Attempt) (*Attempt, error)
+	// Get returns an attempt, or ErrNotFound.
+	Get(id string) (*Attempt, error)
+	// Latest returns userID's most recent attempt, or ErrNotFound.
+	Latest(userID string) (*Attempt, error)
+	// List returns all of userID's attempts, newest first.
+	List(userID string) ([]Attempt, error)
+	// Submit attaches a selfie to an attempt awaiting one and makes it
+	// pending. It returns ErrNotAwaitingSelfie otherwise.
+	Submit(id, selfieKey string, at time.Time) (*Attempt, error)
+	// Decide settles a pending attempt as approved or rejected. It returns
+	// ErrNotPending otherwise.
+	Decide(id, status, reason string, at time.Time) (*Attempt, error)
+	// DeleteUser removes userID's attempts. Their selfies stay in the
+	// BlobStore.
+	DeleteUser(userID string) error
+}
+
+// Decision is a Verifier's answer: StatusApproved or StatusRejected, or
+// StatusPending when the answer comes later through Service.Decide.
+type Decision struct {
+	Status string
+	// Reason explains a rejection to the user.
+	Reason string
+}
+
+// Verifier checks a submitted selfie against the user's primary photo.
+type Verifier interface {
+	Verify(ctx context.Context, a *Attempt, selfie []byte) (Decision, error)
+}
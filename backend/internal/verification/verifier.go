@@ -0,0 +1,64 @@
+package verification
+
+import (
+	"context"
+	"sync"
+)
+
+// ReviewQueue hands attempts to human moderators;
+// safety.VerificationReviews files them as cases in the moderation queue.
+type ReviewQueue interface {
+	QueueVerification(a *Attempt) error
+}
+
+type manualReview struct {
+	queue ReviewQueue
+}
+
+// NewManualReview returns a Verifier that leaves every selfie to a
+// moderator. Attempts stay pending until the case is decided through
+// Service.Decide.
+func NewManualReview(queue ReviewQueue) Verifier {
+	return &manualReview{queue: queue}
+}
+
+func (m *manualReview) Verify(_ context.Context, a *Attempt, _ []byte) (Decision, error) {
+	if err := m.queue.QueueVerification(a); err != nil {
+		return Decision{}, err
+	}
+	return Decision{Status: StatusPending}, nil
+}
+
+// Fake is a Verifier for tests and local development. It answers every
+// attempt with the same decision at once and remembers what it was shown.
+type Fake struct {
+	mu       sync.Mutex
+	decision Decision
+	seen     []Attempt
+}
+
+// NewFake returns a Fake that answers with d.
+func NewFake(d Decision) *Fake {
+	return &Fake{decision: d}
+}
+
+// SetDecision changes the answer for later attempts.
+func (f *Fake) SetDecision(d Decision) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decision = d
+}
+
+// Seen returns the attempts verified so far, oldest first.
+func (f *Fake) Seen() []Attempt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Attempt(nil), f.seen...)
+}
+
+func (f *Fake) Verify(_ context.Context, a *Attempt, _ []byte) (Decision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen = append(f.seen, *a)
+	return f.decision, nil
+}
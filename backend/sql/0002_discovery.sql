@@ -0,0 +1,31 @@
+-- Discovery feed support.
+-- Interaction tables let discovery hide people the viewer already acted on.
+-- The likes/passes APIs and block management build on these tables.
+
+CREATE TABLE IF NOT EXISTS likes (
+    from_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (from_user_id, to_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS passes (
+    from_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (from_user_id, to_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS blocks_blocked_idx ON blocks (blocked_id);
+
+-- Keyset pagination walks completed profiles newest first.
+CREATE INDEX IF NOT EXISTS profiles_onboarded_idx
+    ON profiles (onboarded_at DESC, user_id)
+    WHERE onboarded_at IS NOT NULL;
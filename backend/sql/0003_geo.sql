@@ -0,0 +1,64 @@
+-- Geospatial indexing for discovery without PostGIS.
+-- Each profile stores the geohash of its location; radius queries become a
+-- few prefix matches (LIKE 'u33d%') that the text_pattern_ops index serves,
+-- followed by an exact haversine check on the surviving rows.
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS location_geohash TEXT;
+
+CREATE INDEX IF NOT EXISTS profiles_location_geohash_idx
+    ON profiles (location_geohash text_pattern_ops)
+    WHERE location_geohash IS NOT NULL;
+
+-- Same algorithm as geo.Encode in Go, used to backfill existing rows.
+CREATE OR REPLACE FUNCTION kindl_geohash(lat DOUBLE PRECISION, lng DOUBLE PRECISION, len INTEGER)
+RETURNS TEXT
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+    alphabet CONSTANT TEXT := '0123456789bcdefghjkmnpqrstuvwxyz';
+    lat_lo DOUBLE PRECISION := -90;
+    lat_hi DOUBLE PRECISION := 90;
+    lng_lo DOUBLE PRECISION := -180;
+    lng_hi DOUBLE PRECISION := 180;
+    mid DOUBLE PRECISION;
+    even BOOLEAN := true;
+    nbits INTEGER := 0;
+    ch INTEGER := 0;
+    result TEXT := '';
+BEGIN
+    WHILE length(result) < len LOOP
+        IF even THEN
+            mid := (lng_lo + lng_hi) / 2;
+            IF lng >= mid THEN
+                ch := ch * 2 + 1;
+                lng_lo := mid;
+            ELSE
+                ch := ch * 2;
+                lng_hi := mid;
+            END IF;
+        ELSE
+            mid := (lat_lo + lat_hi) / 2;
+            IF lat >= mid THEN
+                ch := ch * 2 + 1;
+                lat_lo := mid;
+            ELSE
+                ch := ch * 2;
+                lat_hi := mid;
+            END IF;
+        END IF;
+        even := NOT even;
+        nbits := nbits + 1;
+        IF nbits = 5 THEN
+            result := result || substr(alphabet, ch + 1, 1);
+            nbits := 0;
+            ch := 0;
+        END IF;
+    END LOOP;
+    RETURN result;
+END;
+$$;
+
+UPDATE profiles
+SET location_geohash = kindl_geohash(location_lat, location_lng, 9)
+WHERE location_lat IS NOT NULL
+  AND location_lng IS NOT NULL
+  AND location_geohash IS NULL;
@@ -0,0 +1,25 @@
+-- Likes, passes and matches.
+-- A like can point at a specific photo or prompt on the target's profile.
+
+ALTER TABLE likes ADD COLUMN IF NOT EXISTS item_type TEXT
+    CHECK (item_type IN ('photo', 'prompt'));
+ALTER TABLE likes ADD COLUMN IF NOT EXISTS item_id TEXT;
+
+-- "Who liked me" lookups.
+CREATE INDEX IF NOT EXISTS likes_to_user_idx ON likes (to_user_id, created_at DESC);
+
+-- One row per matched pair; user_a_id < user_b_id keeps the pair canonical so
+-- the unique constraint makes concurrent match creation idempotent. The
+-- comparison is in byte order (COLLATE "C") to agree with matches.OrderPair;
+-- the database collation can order mixed ID prefixes differently.
+CREATE TABLE IF NOT EXISTS matches (
+    id TEXT PRIMARY KEY,
+    user_a_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (user_a_id COLLATE "C" < user_b_id COLLATE "C"),
+    UNIQUE (user_a_id, user_b_id)
+);
+
+CREATE INDEX IF NOT EXISTS matches_user_b_idx ON matches (user_b_id);
@@ -0,0 +1,25 @@
+-- Sparks: likes with a short note on a specific photo or prompt.
+-- A sender may have one pending spark per person. Once it is declined they
+-- may spark that person again after a cooldown (sparks.DeclineCooldown);
+-- the cooldown and the note limit (50 words) are enforced by the API.
+
+CREATE TABLE IF NOT EXISTS sparks (
+    id TEXT PRIMARY KEY,
+    from_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_type TEXT NOT NULL CHECK (item_type IN ('photo', 'prompt')),
+    item_id TEXT NOT NULL,
+    note TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    responded_at TIMESTAMPTZ
+);
+
+-- One pending spark per pair; answered sparks stay as history.
+CREATE UNIQUE INDEX IF NOT EXISTS sparks_pending_pair_idx ON sparks (from_user_id, to_user_id)
+    WHERE status = 'pending';
+
+-- Inbox: pending sparks for a recipient, newest first.
+CREATE INDEX IF NOT EXISTS sparks_inbox_idx ON sparks (to_user_id, created_at DESC) WHERE status = 'pending';
+-- Sent list and daily quota counting.
+CREATE INDEX IF NOT EXISTS sparks_sent_idx ON sparks (from_user_id, created_at DESC);
@@ -0,0 +1,17 @@
+-- Match lifecycle.
+-- Matches start active; a participant can unmatch, and matches where nobody
+-- sent a message within the expiry window become expired. Both end states
+-- hide the conversation from both people.
+
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS state TEXT NOT NULL DEFAULT 'active'
+    CHECK (state IN ('active', 'unmatched', 'expired'));
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS first_message_at TIMESTAMPTZ;
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS ended_at TIMESTAMPTZ;
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS ended_by TEXT;
+
+CREATE INDEX IF NOT EXISTS matches_active_a_idx ON matches (user_a_id) WHERE state = 'active';
+CREATE INDEX IF NOT EXISTS matches_active_b_idx ON matches (user_b_id) WHERE state = 'active';
+
+-- Expiry sweep: active matches nobody has messaged yet.
+CREATE INDEX IF NOT EXISTS matches_unmessaged_idx ON matches (created_at)
+    WHERE state = 'active' AND first_message_at IS NULL;
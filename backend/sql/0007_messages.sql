@@ -0,0 +1,27 @@
+-- Chat history.
+-- A conversation is identified by its match ID, so deleting a match (or
+-- either user) removes the conversation. seq gives a total order used for
+-- cursor pagination and read positions.
+
+CREATE TABLE IF NOT EXISTS messages (
+    id TEXT PRIMARY KEY,
+    seq BIGSERIAL NOT NULL UNIQUE,
+    conversation_id TEXT NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    sender_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Client-generated ID used to make retried sends idempotent.
+    client_id TEXT,
+    kind TEXT NOT NULL DEFAULT 'text',
+    text TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (conversation_id, sender_id, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS messages_conversation_seq_idx ON messages (conversation_id, seq DESC);
+
+CREATE TABLE IF NOT EXISTS conversation_reads (
+    conversation_id TEXT NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id)
+);
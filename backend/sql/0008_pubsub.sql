@@ -0,0 +1,11 @@
+-- Payloads too large for a NOTIFY message (8000 bytes). The notification
+-- carries the row ID instead; rows are purged after a few minutes.
+
+CREATE TABLE IF NOT EXISTS pubsub_payloads (
+    id BIGSERIAL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS pubsub_payloads_created_idx ON pubsub_payloads (created_at);
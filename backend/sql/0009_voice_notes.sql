@@ -0,0 +1,7 @@
+-- Media attachments on messages (voice notes first).
+-- media_key points into the media BlobStore; attachment holds the derived
+-- metadata (content type, size, duration, waveform peaks) as JSON.
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS media_key TEXT,
+    ADD COLUMN IF NOT EXISTS attachment JSONB;
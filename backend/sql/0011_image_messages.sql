@@ -0,0 +1,13 @@
+-- Photo messages: blurred photos record when the recipient opened them, and
+-- each participant can refuse photos until enough text has been exchanged.
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS revealed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS conversation_settings (
+    conversation_id TEXT NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    min_text_messages_for_images INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id)
+);
@@ -0,0 +1,25 @@
+-- Blind date matchmaking queue.
+-- One row per person who has opted in. Waiting rows have no session_id; once
+-- paired, both rows carry the same session until each side collects it or
+-- leaves. Pairing happens under a transaction-scoped advisory lock so a
+-- waiting row is never paired twice.
+
+CREATE TABLE IF NOT EXISTS blind_date_queue (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    gender TEXT NOT NULL DEFAULT '',
+    preferred_genders TEXT NOT NULL DEFAULT '',
+    age INT NOT NULL,
+    min_age INT NOT NULL,
+    max_age INT NOT NULL,
+    lat DOUBLE PRECISION,
+    lng DOUBLE PRECISION,
+    joined_at TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    session_id TEXT,
+    partner_id TEXT,
+    paired_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS blind_date_queue_waiting_idx ON blind_date_queue (joined_at)
+    WHERE session_id IS NULL;
+CREATE INDEX IF NOT EXISTS blind_date_queue_last_seen_idx ON blind_date_queue (last_seen);
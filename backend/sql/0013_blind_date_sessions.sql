@@ -0,0 +1,37 @@
+-- Blind date sessions.
+-- A session starts when two people are paired. The chat closes at ends_at;
+-- each side then sparks or passes before decide_by. Mutual sparks record the
+-- resulting match; anything else deletes the session and, through the
+-- cascade, its messages.
+
+CREATE TABLE IF NOT EXISTS blind_date_sessions (
+    id TEXT PRIMARY KEY,
+    user_a_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    alias_a TEXT NOT NULL,
+    alias_b TEXT NOT NULL,
+    spark_a BOOLEAN,
+    spark_b BOOLEAN,
+    state TEXT NOT NULL DEFAULT 'active'
+        CHECK (state IN ('active', 'deciding', 'matched', 'ended')),
+    started_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    decide_by TIMESTAMPTZ NOT NULL,
+    match_id TEXT REFERENCES matches(id) ON DELETE SET NULL
+);
+
+-- The session worker looks for timers that ran out.
+CREATE INDEX IF NOT EXISTS blind_date_sessions_open_idx ON blind_date_sessions (ends_at)
+    WHERE state IN ('active', 'deciding');
+
+CREATE TABLE IF NOT EXISTS blind_date_messages (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL REFERENCES blind_date_sessions(id) ON DELETE CASCADE,
+    sender_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id TEXT NOT NULL,
+    body TEXT NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (session_id, sender_id, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS blind_date_messages_session_idx ON blind_date_messages (session_id, sent_at);
@@ -0,0 +1,49 @@
+-- Intent rooms.
+-- Group spaces tied to an intent, with a capped member list. Joins lock the
+-- room row so the capacity check and insert can't race.
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id TEXT PRIMARY KEY,
+    intent_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    capacity INT NOT NULL CHECK (capacity > 0),
+    created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS rooms_intent_idx ON rooms (intent_id, created_at);
+
+CREATE TABLE IF NOT EXISTS room_members (
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    muted_until TIMESTAMPTZ,
+    PRIMARY KEY (room_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS room_members_user_idx ON room_members (user_id);
+
+CREATE TABLE IF NOT EXISTS room_bans (
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+    banned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (room_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS room_messages (
+    id TEXT PRIMARY KEY,
+    seq BIGSERIAL NOT NULL,
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    sender_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id TEXT NOT NULL,
+    body TEXT NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    deleted_by TEXT,
+    UNIQUE (room_id, sender_id, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS room_messages_room_seq_idx ON room_messages (room_id, seq DESC);
@@ -0,0 +1,47 @@
+-- Intent catalogue.
+-- What people say they're looking for, previously hard-coded in the app.
+-- Tag columns are comma-separated like profiles.preferred_genders. Retire an
+-- intent by clearing active rather than deleting it, so profiles and rooms
+-- that reference it stay valid.
+
+CREATE TABLE IF NOT EXISTS intents (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    vibe_tags TEXT NOT NULL DEFAULT '',
+    ambient_vibes TEXT NOT NULL DEFAULT '',
+    aliases TEXT NOT NULL DEFAULT '',
+    sort_order INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO intents (id, title, description, icon, vibe_tags, ambient_vibes, aliases, sort_order) VALUES
+    ('slow-intentional', 'Slow & Intentional', 'Meet people who take things at a meaningful pace.', '🕊️',
+     'thoughtful,reflective,intentional,meaningful', 'Thoughtful,Reflective,Honest,Warm', 'slow', 1),
+    ('meaningful-connection', 'Meaningful Connection', 'For those who want something real and lasting.', '✨',
+     'deep,authentic,genuine,lasting', 'Deep,Authentic,Genuine,Lasting', 'lasting', 2),
+    ('deep-conversations', 'Deep Conversations', 'People who value emotional depth and honesty.', '💬',
+     'philosophical,emotional,vulnerable,honest', 'Philosophical,Emotional,Vulnerable,Real', '', 3),
+    ('creative-energy', 'Creative Energy', 'For artists, creators, and expressive personalities.', '🎨',
+     'artistic,expressive,imaginative,inspiring', 'Artistic,Expressive,Imaginative,Inspiring', '', 4),
+    ('outdoors-active', 'Outdoors & Active', 'Hiking, adventures, and nature lovers.', '🌿',
+     'adventurous,energetic,nature-loving,active', 'Adventurous,Energetic,Nature-loving,Active', '', 5),
+    ('food-coffee', 'Food & Coffee Dates', 'For the chill, cozy, café-date vibe.', '☕',
+     'cozy,relaxed,social,comfortable', 'Cozy,Relaxed,Social,Comfortable', '', 6),
+    ('spiritual-calm', 'Spiritual & Calm', 'Mindful, compassionate, peaceful energy.', '🌙',
+     'mindful,peaceful,compassionate,grounded', 'Mindful,Peaceful,Compassionate,Grounded', '', 7),
+    ('open-to-connection', 'Open to Connection', 'Open to whatever feels right, no pressure.', '🌱',
+     'open,curious,easygoing,spontaneous', 'Open,Curious,Easygoing,Spontaneous', 'right,unsure', 8)
+ON CONFLICT (id) DO NOTHING;
+
+-- Profiles saved before the catalogue hold WhatBringsYouScreen answers; map
+-- them onto catalogue IDs.
+UPDATE profiles p SET intent = i.id
+FROM intents i
+WHERE p.intent IS NOT NULL
+  AND p.intent <> i.id
+  AND (',' || i.aliases || ',') LIKE '%,' || p.intent || ',%';
+
+CREATE INDEX IF NOT EXISTS profiles_intent_idx ON profiles (intent, onboarded_at DESC, user_id);
@@ -0,0 +1,42 @@
+-- Behaviour events.
+-- Append-only log of what people do in the app (intent views, time spent,
+-- scroll depth, reactions), deduplicated on the client-chosen event ID. An
+-- aggregation job folds events into user_intent_stats in seq order, tracking
+-- its position in behavior_event_cursors.
+
+CREATE TABLE IF NOT EXISTS behavior_events (
+    seq BIGSERIAL NOT NULL UNIQUE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    intent_id TEXT NOT NULL DEFAULT '',
+    profile_id TEXT NOT NULL DEFAULT '',
+    reaction TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    depth DOUBLE PRECISION NOT NULL DEFAULT 0,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS behavior_event_cursors (
+    name TEXT PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO behavior_event_cursors (name) VALUES ('intent_stats') ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS user_intent_stats (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    intent_id TEXT NOT NULL REFERENCES intents(id),
+    views INT NOT NULL DEFAULT 0,
+    time_spent_ms BIGINT NOT NULL DEFAULT 0,
+    max_scroll_depth DOUBLE PRECISION NOT NULL DEFAULT 0,
+    feels_right INT NOT NULL DEFAULT 0,
+    maybe_later INT NOT NULL DEFAULT 0,
+    not_vibe INT NOT NULL DEFAULT 0,
+    last_viewed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, intent_id)
+);
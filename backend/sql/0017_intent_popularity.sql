@@ -0,0 +1,5 @@
+-- Intent popularity.
+-- Recommendations count recently active rows in user_intent_stats per
+-- intent; index the recency filter.
+
+CREATE INDEX IF NOT EXISTS user_intent_stats_updated_idx ON user_intent_stats (updated_at, intent_id);
@@ -0,0 +1,24 @@
+-- Blocks and reports.
+-- Blocks reuse the table from 0002_discovery.sql and gain the place they
+-- were made. Reports carry snapshots of the offending messages so evidence
+-- survives edits and deletes; open reports form the moderation queue.
+
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'profile';
+
+CREATE TABLE IF NOT EXISTS reports (
+    id TEXT PRIMARY KEY,
+    reporter_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reported_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    context TEXT NOT NULL,
+    context_id TEXT NOT NULL DEFAULT '',
+    messages JSONB NOT NULL DEFAULT '[]',
+    photo_ids JSONB NOT NULL DEFAULT '[]',
+    priority INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS reports_queue_idx ON reports (priority DESC, created_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS reports_reported_idx ON reports (reported_user_id, created_at DESC);
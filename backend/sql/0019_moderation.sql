@@ -0,0 +1,53 @@
+-- Moderation.
+-- Accounts hold what a token can't: the admin role, suspensions and bans,
+-- and when sessions were last revoked (tokens issued earlier are refused).
+-- Moderators resolve reports, and every action they take is appended to
+-- moderation_actions, which a trigger keeps append-only.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    user_id TEXT PRIMARY KEY,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'banned')),
+    suspended_until TIMESTAMPTZ,
+    sessions_revoked_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS resolved_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMPTZ;
+
+-- No foreign keys: the trail must outlive the accounts it mentions.
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id TEXT PRIMARY KEY,
+    case_id TEXT NOT NULL DEFAULT '',
+    actor_id TEXT NOT NULL,
+    target_user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    photo_id TEXT NOT NULL DEFAULT '',
+    until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS moderation_actions_target_idx ON moderation_actions (target_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS moderation_actions_case_idx ON moderation_actions (case_id) WHERE case_id <> '';
+CREATE INDEX IF NOT EXISTS moderation_actions_actor_idx ON moderation_actions (actor_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION moderation_actions_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'moderation_actions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS moderation_actions_append_only ON moderation_actions;
+CREATE TRIGGER moderation_actions_append_only
+    BEFORE UPDATE OR DELETE ON moderation_actions
+    FOR EACH ROW EXECUTE FUNCTION moderation_actions_append_only();
+
+CREATE TABLE IF NOT EXISTS hidden_photos (
+    user_id TEXT NOT NULL,
+    photo_id TEXT NOT NULL,
+    action_id TEXT NOT NULL,
+    hidden_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, photo_id)
+);
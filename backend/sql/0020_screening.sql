@@ -0,0 +1,8 @@
+-- Content screening.
+-- Automated screening files reports with no reporter, and soft-hidden
+-- messages are stored with the reason clients show instead of the text.
+
+ALTER TABLE reports ALTER COLUMN reporter_id DROP NOT NULL;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS hidden_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE room_messages ADD COLUMN IF NOT EXISTS hidden_reason TEXT NOT NULL DEFAULT '';
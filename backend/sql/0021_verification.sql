@@ -0,0 +1,24 @@
+-- Selfie verification.
+-- Profiles record their primary photo and when a selfie last matched it;
+-- changing the primary photo clears verified_at. Each run through
+-- verification is an attempt, decided at once by an automated verifier or
+-- later by a moderator through the case queue.
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS primary_photo_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS verification_attempts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pose TEXT NOT NULL,
+    photo_id TEXT NOT NULL,
+    selfie_key TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('awaiting_selfie', 'pending', 'approved', 'rejected')),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    submitted_at TIMESTAMPTZ,
+    decided_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS verification_attempts_user_idx ON verification_attempts (user_id, created_at DESC);
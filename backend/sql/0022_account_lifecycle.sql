@@ -0,0 +1,29 @@
+-- Account deletion and data export.
+-- A deletion waits out its grace period in account_deletions, then a worker
+-- erases the user (mostly through the users row's ON DELETE CASCADE) and
+-- forgets the deletion. account_exports points at each user's latest data
+-- archive in the blob store.
+-- Accounts and the moderation trail are kept, so a ban survives deletion.
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id TEXT PRIMARY KEY,
+    requested_at TIMESTAMPTZ NOT NULL,
+    purge_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS account_deletions_purge_idx ON account_deletions (purge_at);
+
+CREATE TABLE IF NOT EXISTS account_exports (
+    user_id TEXT PRIMARY KEY,
+    id TEXT NOT NULL,
+    blob_key TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Reports outlive the person who filed them, who is forgotten, and the
+-- person they are about, who stays named like in moderation_actions.
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS reports_reporter_id_fkey;
+ALTER TABLE reports ADD CONSTRAINT reports_reporter_id_fkey
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS reports_reported_user_id_fkey;
@@ -0,0 +1,10 @@
+-- Snooze: a snoozed profile is hidden from discovery, blind dates and room
+-- member lists while its matches and conversations carry on.
+-- snoozed_until ends the snooze, or is NULL while the user snoozes
+-- indefinitely; a worker clears snoozes once they end.
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS snoozed_at TIMESTAMPTZ;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS profiles_snoozed_until_idx ON profiles (snoozed_until)
+    WHERE snoozed_until IS NOT NULL;
@@ -0,0 +1,42 @@
+-- Push notifications.
+-- Devices may be registered before onboarding creates the users row, so
+-- these tables don't reference users; account deletion clears them
+-- explicitly. push_outbox holds rendered pushes until a worker delivers
+-- them; at most one pending push per user and collapse key.
+
+CREATE TABLE IF NOT EXISTS push_devices (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    platform TEXT NOT NULL CHECK (platform IN ('expo', 'apns', 'fcm')),
+    token TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS push_devices_user_idx ON push_devices (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS quiet_hours (
+    user_id TEXT PRIMARY KEY,
+    start_time TEXT NOT NULL,
+    end_time TEXT NOT NULL,
+    time_zone TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS push_outbox (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    collapse_key TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    not_before TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS push_outbox_collapse_idx ON push_outbox (user_id, collapse_key)
+    WHERE collapse_key <> '';
+CREATE INDEX IF NOT EXISTS push_outbox_due_idx ON push_outbox (not_before);
+CREATE INDEX IF NOT EXISTS push_outbox_user_idx ON push_outbox (user_id);
@@ -0,0 +1,8 @@
+-- Activity: when each user last used the app, for the recency signal in
+-- discovery ranking. Writes are throttled (onboarding.TrackActivity), so the
+-- value is accurate to a few minutes.
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+
+UPDATE profiles SET last_active_at = onboarded_at
+WHERE last_active_at IS NULL AND onboarded_at IS NOT NULL;
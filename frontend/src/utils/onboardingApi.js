@@ -12,12 +12,21 @@ const getBaseUrl = () => {
 
 const BASE_URL = getBaseUrl();
 
+// Without a token, dev builds identify as a fixed debug user. The backend
+// only honours X-Debug-UserID when started with DEV_DEBUG_USER_HEADER=1.
+const authHeaders = (accessToken) => {
+  if (accessToken) {
+    return { Authorization: `Bearer ${accessToken}` };
+  }
+  return __DEV__ ? { 'X-Debug-UserID': 'debug-user-1' } : {};
+};
+
 async function put(path, body, accessToken) {
   const res = await fetch(`${BASE_URL}${path}`, {
     method: 'PUT',
     headers: {
       'Content-Type': 'application/json',
-      ...(accessToken ? { Authorization: `Bearer ${accessToken}` } : { 'X-Debug-UserID': 'debug-user-1' }),
+      ...authHeaders(accessToken),
     },
     body: JSON.stringify(body),
   });
@@ -39,7 +48,7 @@ async function post(path, body, accessToken) {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
-      ...(accessToken ? { Authorization: `Bearer ${accessToken}` } : { 'X-Debug-UserID': 'debug-user-1' }),
+      ...authHeaders(accessToken),
     },
     body: JSON.stringify(body),
   });